@@ -17,7 +17,7 @@ func main() {
 	cfg, err := config.Load(*cfgPath)
 	checkErr(err)
 
-	checkErr(api.Start(cfg))
+	checkErr(api.Start(cfg, config.NewWatcher(cfg, *cfgPath)))
 }
 
 func checkErr(err error) {
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/figassis/goduck/pkg/utl/breach"
+)
+
+const usage = `Usage: breach [flags] build|check
+
+Commands:
+  build  build breached hashes file from SHA-1 dump ordered by hash, e.g. pwned-passwords-sha1-ordered-by-hash-v8.txt
+  check  read passwords from standard input, one per line, and report whether they were found in breach
+
+Flags:
+`
+
+func main() {
+	path := flag.String("f", "./data/breached.bin", "Path to breached hashes file")
+	in := flag.String("i", "-", "Path to SHA-1 dump, - reads standard input")
+	minCount := flag.Int("min", 0, "Skip hashes seen less than this many times")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	switch flag.Arg(0) {
+	case "build":
+		r := io.Reader(os.Stdin)
+		if *in != "-" {
+			f, err := os.Open(*in)
+			checkErr(err)
+			defer f.Close()
+			r = f
+		}
+		n, err := build(*path, r, *minCount)
+		checkErr(err)
+		log.Printf("wrote %d hashes to %s", n, *path)
+	case "check":
+		l, err := breach.Open(*path)
+		checkErr(err)
+		defer l.Close()
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			found, err := l.Contains(sc.Text())
+			checkErr(err)
+			if found {
+				fmt.Println("found in breach")
+			} else {
+				fmt.Println("not found")
+			}
+		}
+		checkErr(sc.Err())
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// build writes hashes file next to path and renames it, so the API never opens a partially written file
+func build(path string, r io.Reader, minCount int) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+
+	n, err := breach.Build(f, r, minCount)
+	if err != nil {
+		f.Close()
+		return n, err
+	}
+	if err := f.Close(); err != nil {
+		return n, err
+	}
+	return n, os.Rename(f.Name(), path)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/figassis/goduck/pkg/utl/migrate"
+	"github.com/figassis/goduck/pkg/utl/secure"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// seedAdmin creates the initial super admin, unless it already exists.
+// It is a Go migration as the password has to be hashed.
+var seedAdmin = &migrate.Migration{
+	Version: 3,
+	Name:    "seed_admin",
+	Up: func(db orm.DB) error {
+		hash, err := secure.New(1, "").Hash("admin")
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(`INSERT INTO users (id, created_at, updated_at, first_name, last_name, username, password, email, active, role_id, company_id, location_id)
+			SELECT 1, now(), now(), 'Admin', 'Admin', 'admin', ?, 'johndoe@mail.com', true, 100, 1, 1
+			WHERE NOT EXISTS (SELECT 1 FROM users WHERE id = 1 OR lower(username) = 'admin')`, hash); err != nil {
+			return err
+		}
+		_, err = db.Exec("SELECT setval('users_id_seq', (SELECT max(id) FROM users))")
+		return err
+	},
+	Down: func(db orm.DB) error {
+		_, err := db.Exec("DELETE FROM users WHERE id = 1 AND username = 'admin'")
+		return err
+	},
+}
@@ -0,0 +1,12 @@
+DROP TABLE login_attempts;
+DROP TABLE recovery_codes;
+DROP TABLE login_challenges;
+DROP TABLE retired_tokens;
+DROP TABLE sessions;
+DROP TABLE user_revocations;
+DROP TABLE revoked_tokens;
+DROP TABLE password_resets;
+DROP TABLE users;
+DROP TABLE roles;
+DROP TABLE locations;
+DROP TABLE companies;
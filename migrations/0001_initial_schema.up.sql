@@ -0,0 +1,125 @@
+CREATE TABLE companies (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name text,
+	active boolean
+);
+
+CREATE TABLE locations (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	name text,
+	active boolean,
+	address text,
+	company_id bigint REFERENCES companies (id)
+);
+
+CREATE TABLE roles (
+	id bigserial PRIMARY KEY,
+	access_level bigint,
+	name text
+);
+
+CREATE TABLE users (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	first_name text,
+	last_name text,
+	username text,
+	password text,
+	email text,
+	mobile text,
+	phone text,
+	address text,
+	active boolean,
+	last_login timestamptz,
+	last_password_change timestamptz,
+	mfa_enabled boolean,
+	totp_secret text,
+	totp_counter bigint,
+	role_id bigint REFERENCES roles (id),
+	company_id bigint REFERENCES companies (id),
+	location_id bigint REFERENCES locations (id)
+);
+
+CREATE INDEX users_username_idx ON users (lower(username));
+
+CREATE TABLE password_resets (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint,
+	token_hash text,
+	expires_at timestamptz,
+	used_at timestamptz
+);
+
+CREATE INDEX password_resets_token_hash_idx ON password_resets (token_hash);
+
+CREATE TABLE revoked_tokens (
+	jti text PRIMARY KEY,
+	user_id bigint,
+	expires_at timestamptz
+);
+
+CREATE TABLE user_revocations (
+	user_id bigint PRIMARY KEY,
+	revoked_before timestamptz
+);
+
+CREATE TABLE sessions (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint,
+	token_hash text,
+	user_agent text,
+	ip text,
+	last_used timestamptz,
+	expires_at timestamptz
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX sessions_token_hash_idx ON sessions (token_hash);
+
+CREATE TABLE retired_tokens (
+	token_hash text PRIMARY KEY,
+	session_id bigint,
+	retired_at timestamptz
+);
+
+CREATE TABLE login_challenges (
+	id bigserial PRIMARY KEY,
+	user_id bigint,
+	token_hash text,
+	attempts bigint,
+	expires_at timestamptz,
+	created_at timestamptz
+);
+
+CREATE INDEX login_challenges_token_hash_idx ON login_challenges (token_hash);
+
+CREATE TABLE recovery_codes (
+	id bigserial PRIMARY KEY,
+	user_id bigint,
+	code_hash text,
+	used_at timestamptz,
+	created_at timestamptz
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
+
+CREATE TABLE login_attempts (
+	key text PRIMARY KEY,
+	failures bigint,
+	last_failure timestamptz,
+	locked_until timestamptz
+);
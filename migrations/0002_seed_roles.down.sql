@@ -0,0 +1,3 @@
+DELETE FROM locations WHERE id = 1 AND NOT EXISTS (SELECT 1 FROM users WHERE location_id = 1);
+DELETE FROM companies WHERE id = 1 AND NOT EXISTS (SELECT 1 FROM locations WHERE company_id = 1);
+DELETE FROM roles WHERE id IN (100, 110, 120, 130, 200) AND NOT EXISTS (SELECT 1 FROM users WHERE role_id = roles.id);
@@ -0,0 +1,20 @@
+INSERT INTO roles (id, access_level, name) VALUES
+	(100, 100, 'SUPER_ADMIN'),
+	(110, 110, 'ADMIN'),
+	(120, 120, 'COMPANY_ADMIN'),
+	(130, 130, 'LOCATION_ADMIN'),
+	(200, 200, 'USER')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO companies (id, created_at, updated_at, name, active)
+VALUES (1, now(), now(), 'admin_company', true)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO locations (id, created_at, updated_at, name, active, address, company_id)
+VALUES (1, now(), now(), 'admin_location', true, 'admin_address', 1)
+ON CONFLICT (id) DO NOTHING;
+
+-- Explicit ids don't advance sequences
+SELECT setval('roles_id_seq', (SELECT max(id) FROM roles));
+SELECT setval('companies_id_seq', (SELECT max(id) FROM companies));
+SELECT setval('locations_id_seq', (SELECT max(id) FROM locations));
@@ -0,0 +1,44 @@
+CREATE TABLE permissions (
+	name text PRIMARY KEY,
+	description text
+);
+
+CREATE TABLE role_permissions (
+	role_id bigint NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+	permission text NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+	PRIMARY KEY (role_id, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+	('users:read', 'View users'),
+	('users:create', 'Create users'),
+	('users:update', 'Update users'),
+	('users:delete', 'Delete users'),
+	('users:unlock', 'Lift lockouts caused by failed logins'),
+	('sessions:revoke', 'Terminate all sessions of other users'),
+	('companies:read', 'View companies'),
+	('companies:create', 'Create companies'),
+	('companies:update', 'Update companies'),
+	('companies:delete', 'Delete companies'),
+	('locations:read', 'View locations'),
+	('locations:create', 'Create locations'),
+	('locations:update', 'Update locations'),
+	('locations:delete', 'Delete locations')
+ON CONFLICT (name) DO NOTHING;
+
+-- Built-in roles get permissions matching what their access level allowed before
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r, permissions p WHERE r.id IN (100, 110)
+UNION ALL
+SELECT 120, unnest(ARRAY['users:read', 'users:create', 'users:update', 'users:delete',
+	'companies:read', 'companies:update',
+	'locations:read', 'locations:create', 'locations:update', 'locations:delete'])
+FROM roles WHERE id = 120
+UNION ALL
+SELECT 130, unnest(ARRAY['users:read', 'users:create', 'users:update', 'users:delete',
+	'locations:read', 'locations:update'])
+FROM roles WHERE id = 130
+UNION ALL
+SELECT 200, unnest(ARRAY['users:read', 'users:update'])
+FROM roles WHERE id = 200
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+-- Fails while custom roles are assigned to users
+DELETE FROM roles WHERE id NOT IN (100, 110, 120, 130, 200);
+DELETE FROM permissions WHERE name LIKE 'roles:%';
+
+ALTER TABLE roles DROP COLUMN company_id;
@@ -0,0 +1,18 @@
+-- Roles without company are global, others can only be assigned to users of their company
+ALTER TABLE roles ADD COLUMN company_id bigint REFERENCES companies (id) ON DELETE CASCADE;
+
+CREATE INDEX roles_company_id_idx ON roles (company_id);
+
+INSERT INTO permissions (name, description) VALUES
+	('roles:read', 'View roles'),
+	('roles:create', 'Create roles'),
+	('roles:update', 'Update roles'),
+	('roles:delete', 'Delete roles')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.name FROM roles r, permissions p
+WHERE r.id IN (100, 110, 120) AND p.name LIKE 'roles:%'
+UNION ALL
+SELECT 130, 'roles:read' FROM roles WHERE id = 130
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+DELETE FROM permissions WHERE name = 'passwords:expire';
+
+DROP TABLE password_history;
+
+ALTER TABLE login_challenges DROP COLUMN password_change;
+ALTER TABLE users DROP COLUMN password_change_required;
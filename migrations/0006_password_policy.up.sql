@@ -0,0 +1,20 @@
+ALTER TABLE users ADD COLUMN password_change_required boolean;
+ALTER TABLE login_challenges ADD COLUMN password_change boolean;
+
+-- Hashes of users' previous passwords, which can't be reused
+CREATE TABLE password_history (
+	id bigserial PRIMARY KEY,
+	user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	hash text,
+	created_at timestamptz
+);
+
+CREATE INDEX password_history_user_id_idx ON password_history (user_id, id);
+
+INSERT INTO permissions (name, description) VALUES
+	('passwords:expire', 'Force users to change password on next login')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'passwords:expire' FROM roles WHERE id IN (100, 110, 120, 130)
+ON CONFLICT DO NOTHING;
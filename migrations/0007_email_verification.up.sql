@@ -0,0 +1,21 @@
+ALTER TABLE users ADD COLUMN email_verified_at timestamptz;
+
+-- Existing users, including the seeded admin, aren't locked out when verified emails become required.
+UPDATE users SET email_verified_at = now() WHERE email IS NOT NULL AND email <> '';
+
+-- Tokens confirming users' current email, or the new one when changing it.
+-- Only hashes of the tokens are stored.
+CREATE TABLE email_verifications (
+	id bigserial PRIMARY KEY,
+	created_at timestamptz,
+	updated_at timestamptz,
+	deleted_at timestamptz,
+	user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	email text,
+	token_hash text,
+	expires_at timestamptz,
+	used_at timestamptz
+);
+
+CREATE INDEX email_verifications_token_hash_idx ON email_verifications (token_hash);
+CREATE INDEX email_verifications_user_id_idx ON email_verifications (user_id);
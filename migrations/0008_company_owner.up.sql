@@ -0,0 +1,2 @@
+-- User who signed the company up, and administers it
+ALTER TABLE companies ADD COLUMN owner_id bigint REFERENCES users (id) ON DELETE SET NULL;
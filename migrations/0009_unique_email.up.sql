@@ -0,0 +1,3 @@
+-- Emails are looked up case-insensitively, so each may belong to one active user only.
+-- Fails if existing users share an email, which has to be resolved by hand first.
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email)) WHERE deleted_at IS NULL;
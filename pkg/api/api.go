@@ -32,50 +32,158 @@
 package api
 
 import (
-	"crypto/sha1"
+	"context"
+	"crypto/rand"
+	"time"
 
 	"github.com/figassis/goduck/pkg/utl/zlog"
 
 	"github.com/figassis/goduck/pkg/api/auth"
 	al "github.com/figassis/goduck/pkg/api/auth/logging"
 	at "github.com/figassis/goduck/pkg/api/auth/transport"
+	"github.com/figassis/goduck/pkg/api/company"
+	cl "github.com/figassis/goduck/pkg/api/company/logging"
+	ct "github.com/figassis/goduck/pkg/api/company/transport"
+	"github.com/figassis/goduck/pkg/api/location"
+	ll "github.com/figassis/goduck/pkg/api/location/logging"
+	lt "github.com/figassis/goduck/pkg/api/location/transport"
 	"github.com/figassis/goduck/pkg/api/password"
 	pl "github.com/figassis/goduck/pkg/api/password/logging"
 	pt "github.com/figassis/goduck/pkg/api/password/transport"
+	"github.com/figassis/goduck/pkg/api/role"
+	rl "github.com/figassis/goduck/pkg/api/role/logging"
+	rt "github.com/figassis/goduck/pkg/api/role/transport"
 	"github.com/figassis/goduck/pkg/api/user"
 	ul "github.com/figassis/goduck/pkg/api/user/logging"
 	ut "github.com/figassis/goduck/pkg/api/user/transport"
 
+	"github.com/figassis/goduck/pkg/utl/breach"
 	"github.com/figassis/goduck/pkg/utl/config"
+	"github.com/figassis/goduck/pkg/utl/mail"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/paging"
 	"github.com/figassis/goduck/pkg/utl/middleware/jwt"
+	secmw "github.com/figassis/goduck/pkg/utl/middleware/secure"
 	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
 	"github.com/figassis/goduck/pkg/utl/rbac"
+	"github.com/figassis/goduck/pkg/utl/revoke"
 	"github.com/figassis/goduck/pkg/utl/secure"
 	"github.com/figassis/goduck/pkg/utl/server"
 )
 
-// Start starts the API service
-func Start(cfg *config.Configuration) error {
-	db, err := postgres.New(cfg.DB.PSN, cfg.DB.Timeout, cfg.DB.LogQueries)
+// reloadable lists configuration fields applied without restart
+var reloadable = map[string]bool{
+	"application.min_password_strength": true,
+	"application.password_min_length":   true,
+	"application.log_level":             true,
+	"jwt.secret":                        true,
+	"jwt.signing_algorithm":             true,
+	"jwt.keys":                          true,
+	"jwt.signing_kid":                   true,
+	"jwt.duration_minutes":              true,
+	"jwt.issuer":                        true,
+	"jwt.audience":                      true,
+	"jwt.leeway_seconds":                true,
+	"server.cors_origins":               true,
+	"database.log_queries":              true,
+}
+
+// Start starts the API service.
+// If watcher is not nil, reloadable configuration is applied whenever it changes.
+func Start(cfg *config.Configuration, watcher *config.Watcher) error {
+	queryLog := postgres.NewQueryLogger(cfg.DB.LogQueries)
+	db, err := postgres.NewWithLogger(cfg.DB.PSN, cfg.DB.Timeout, queryLog)
 	if err != nil {
 		return err
 	}
 
-	sec := secure.New(cfg.App.MinPasswordStr, sha1.New())
-	rbac := rbac.New()
-	jwt := jwt.New(cfg.JWT.Secret, cfg.JWT.SigningAlgorithm, cfg.JWT.Duration)
 	log := zlog.New()
+	if err := log.SetLevel(cfg.App.LogLevel); err != nil {
+		return err
+	}
 
-	e := server.New()
-	e.Static("/swaggerui", cfg.App.SwaggerUIPath)
+	sec := secure.NewWithHasher(cfg.App.MinPasswordStr, cfg.App.TokenKey, passwordHasher(cfg.Hash), cfg.Hash.Pepper)
+	sec.SetMinPasswordLength(cfg.App.PasswordMinLength)
+	if cfg.App.BreachedPasswords != "" {
+		breaches, err := breach.Open(cfg.App.BreachedPasswords)
+		if err != nil {
+			return err
+		}
+		defer breaches.Close()
+		sec.SetBreachList(breaches)
+	}
+	hist := pwhistory.New(pwhistory.NewPG(db), cfg.App.PasswordHistory, sec.HashMatchesPassword)
+	rbacPG := rbac.NewPG(db)
+	rbac := rbac.NewWithStore(rbacPG, rbacPG, rbac.DefaultCacheTTL)
+	ks, jc, err := jwtKeyset(cfg.JWT)
+	if err != nil {
+		return err
+	}
+	jwt := jwt.NewWithKeyset(ks, jc, revoke.NewPG(db))
+	cors := secmw.NewCORS(cfg.Server.CORSOrigins)
+	pager, err := newPager(cfg.App)
+	if err != nil {
+		return err
+	}
+
+	mailer, err := mail.New(mailConfig(cfg.Mail))
+	if err != nil {
+		return err
+	}
 
-	at.NewHTTP(al.New(auth.Initialize(db, jwt, sec, rbac), log), e, jwt.MWFunc())
+	proxies, err := server.ParseProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	e := server.NewWithCORS(cors.MWFunc(), proxies)
+	e.Static("/swaggerui", cfg.App.SwaggerUIPath)
 
 	v1 := e.Group("/v1")
 	v1.Use(jwt.MWFunc())
 
-	ut.NewHTTP(ul.New(user.Initialize(db, rbac, sec), log), v1)
-	pt.NewHTTP(pl.New(password.Initialize(db, rbac, sec), log), v1)
+	at.NewHTTP(al.New(auth.Initialize(db, hist, jwt, sec, rbac, authConfig(cfg)), log), e, v1, jwt.MWFunc(), rbac.RequirePermission)
+	ut.NewHTTP(ul.New(user.Initialize(db, rbac, sec, mailer, &user.Config{
+		VerifyDuration: time.Duration(cfg.App.VerifyDuration) * time.Hour,
+		VerifyURL:      cfg.App.VerifyURL,
+		ResendInterval: time.Duration(cfg.App.VerifyResendInterval) * time.Second,
+		DisableSignup:  cfg.App.DisableSignup,
+	}), log), e, v1, rbac.RequirePermission, pager)
+	pt.NewHTTP(pl.New(password.Initialize(db, hist, jwt, rbac, sec, mailer, &password.Config{
+		ResetDuration: time.Duration(cfg.App.ResetDuration) * time.Minute,
+		ResetURL:      cfg.App.ResetURL,
+	}), log), e, v1)
+	ct.NewHTTP(cl.New(company.Initialize(db, rbac), log), v1, rbac.RequirePermission)
+	lt.NewHTTP(ll.New(location.Initialize(db, rbac), log), v1, rbac.RequirePermission)
+	rt.NewHTTP(rl.New(role.Initialize(db, rbac), log), v1, rbac.RequirePermission)
+
+	if watcher != nil {
+		watcher.OnReload(func(old, cfg *config.Configuration) error {
+			ks, jc, err := jwtKeyset(cfg.JWT)
+			if err != nil {
+				return err
+			}
+			if err := log.SetLevel(cfg.App.LogLevel); err != nil {
+				return err
+			}
+			jwt.Reload(ks, jc)
+			sec.SetMinPasswordStrength(cfg.App.MinPasswordStr)
+			sec.SetMinPasswordLength(cfg.App.PasswordMinLength)
+			cors.SetOrigins(cfg.Server.CORSOrigins)
+			queryLog.SetEnabled(cfg.DB.LogQueries)
+			for _, field := range config.Changed(old, cfg) {
+				if !reloadable[field] {
+					log.Log(context.Background(), "config", "Changed setting requires a restart to apply", nil, map[string]interface{}{"field": field})
+				}
+			}
+			log.Log(context.Background(), "config", "Configuration reloaded", nil, nil)
+			return nil
+		})
+		stop := watcher.Watch(5*time.Second, func(err error) {
+			log.Log(context.Background(), "config", "Reload failed, keeping current configuration", err, nil)
+		})
+		defer stop()
+	}
 
 	server.Start(e, &server.Config{
 		Port:                cfg.Server.Port,
@@ -86,3 +194,95 @@ func Start(cfg *config.Configuration) error {
 
 	return nil
 }
+
+func jwtKeyset(cfg *config.JWT) (*jwt.Keyset, *jwt.Config, error) {
+	keys := make([]*jwt.Key, len(cfg.Keys))
+	for i, k := range cfg.Keys {
+		key, err := jwt.LoadKey(k.ID, k.File, k.Algorithm)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = key
+	}
+	if len(keys) == 0 {
+		key, err := jwt.NewHMACKey(cfg.Secret, cfg.SigningAlgorithm)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+	ks, err := jwt.NewKeyset(cfg.SigningKeyID, keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ks, &jwt.Config{
+		Duration: cfg.Duration,
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   time.Duration(cfg.Leeway) * time.Second,
+	}, nil
+}
+
+func newPager(cfg *config.Application) (*paging.Pager, error) {
+	secret := []byte(cfg.CursorSecret)
+	if len(secret) == 0 {
+		// Cursors are then rejected after restart and by other instances
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return paging.New(secret, cfg.CountEstimate), nil
+}
+
+func passwordHasher(cfg *config.PasswordHash) secure.Hasher {
+	switch cfg.Algorithm {
+	case "argon2id":
+		return secure.Argon2id{
+			Memory:      uint32(cfg.Argon2Memory),
+			Iterations:  uint32(cfg.Argon2Iterations),
+			Parallelism: uint8(cfg.Argon2Parallelism),
+		}
+	case "scrypt":
+		return secure.Scrypt{
+			Cost:        uint8(cfg.ScryptCost),
+			BlockSize:   cfg.ScryptBlockSize,
+			Parallelism: cfg.ScryptParallelism,
+		}
+	default:
+		return secure.Bcrypt{Cost: cfg.BcryptCost}
+	}
+}
+
+func authConfig(cfg *config.Configuration) *auth.Config {
+	ac := &auth.Config{
+		RefreshDuration:      time.Duration(cfg.JWT.RefreshDuration) * time.Minute,
+		MaxRefresh:           time.Duration(cfg.JWT.MaxRefresh) * time.Minute,
+		MaxFailures:          cfg.App.LoginMaxFailures,
+		MaxIPFailures:        cfg.App.LoginMaxIPFailures,
+		Lockout:              time.Duration(cfg.App.LoginLockout) * time.Minute,
+		Delay:                time.Duration(cfg.App.LoginDelay) * time.Second,
+		PasswordMaxAge:       time.Duration(cfg.App.PasswordMaxAge) * 24 * time.Hour,
+		RequireVerifiedEmail: cfg.App.RequireVerifiedEmail,
+	}
+	if cfg.MFA != nil {
+		ac.MFAIssuer = cfg.MFA.Issuer
+		ac.MFARequiredRole = gorsk.AccessRole(cfg.MFA.RequiredRole)
+	}
+	return ac
+}
+
+func mailConfig(cfg *config.Mail) *mail.Config {
+	if cfg == nil {
+		return &mail.Config{}
+	}
+	return &mail.Config{
+		Driver:   cfg.Driver,
+		From:     cfg.From,
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Dir:      cfg.Dir,
+	}
+}
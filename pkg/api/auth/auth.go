@@ -1,62 +1,273 @@
 package auth
 
 import (
+	"context"
 	"net/http"
+	"time"
 
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/secure"
 
 	"github.com/labstack/echo"
 )
 
 // Custom errors
 var (
-	ErrInvalidCredentials = echo.NewHTTPError(http.StatusUnauthorized, "Username or password does not exist")
+	ErrInvalidCredentials  = echo.NewHTTPError(http.StatusUnauthorized, "Username or password does not exist")
+	ErrInvalidRefreshToken = echo.NewHTTPError(http.StatusUnauthorized, "Refresh token is invalid or expired")
+	ErrSessionNotFound     = echo.NewHTTPError(http.StatusNotFound, "Session does not exist")
+	ErrEmailNotVerified    = echo.NewHTTPError(http.StatusForbidden, "Email address is not verified")
 )
 
-// Authenticate tries to authenticate the user provided by username and password
-func (a *Auth) Authenticate(c echo.Context, user, pass string) (*gorsk.AuthToken, error) {
-	u, err := a.udb.FindByUsername(a.db, user)
+// Authenticate tries to authenticate the user provided by username and password.
+// If the user has to pass a second factor, or to change expired password, a challenge is returned instead of the token.
+// Repeated failures are throttled per username and client IP.
+func (a *Auth) Authenticate(ctx context.Context, user, pass string) (*gorsk.AuthToken, *gorsk.MFAChallenge, error) {
+	db := a.db.WithContext(ctx)
+	now := a.now()
+	keys := a.attemptKeys(ctx, user)
+	if err := a.checkAttempts(ctx, keys, now); err != nil {
+		return nil, nil, err
+	}
+
+	u, err := a.udb.FindByUsername(db, user)
+	if err == pg.ErrNoRows {
+		return nil, nil, a.failAttempt(ctx, keys, now)
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if !a.sec.HashMatchesPassword(u.Password, pass) {
-		return nil, ErrInvalidCredentials
+	match, rehash := a.sec.VerifyPassword(u.Password, pass)
+	if !match {
+		return nil, nil, a.failAttempt(ctx, keys, now)
 	}
 
 	if !u.Active {
-		return nil, gorsk.ErrUnauthorized
+		return nil, nil, gorsk.ErrUnauthorized
+	}
+
+	if a.cfg.RequireVerifiedEmail && !u.EmailVerified() {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	if rehash {
+		if err := a.rehash(db, u, pass); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Failures are forgotten only once the second factor is passed too,
+	// so the password can't be used to get fresh MFA attempts
+	if u.MFAEnabled || a.mfaRequired(u) {
+		ch, err := a.challenge(db, u, false)
+		return nil, ch, err
+	}
+
+	if err := a.resetAttempts(ctx, keys, user); err != nil {
+		return nil, nil, err
+	}
+
+	if u.PasswordExpired(now, a.cfg.PasswordMaxAge) {
+		ch, err := a.challenge(db, u, true)
+		return nil, ch, err
+	}
+
+	token, err := a.login(ctx, u)
+	return token, nil, err
+}
+
+// rehash hashes the password again, as its stored hash uses an outdated algorithm or parameters.
+// Only the hash is replaced, the password isn't considered changed.
+func (a *Auth) rehash(db orm.DB, u *gorsk.User, pass string) error {
+	hash, err := a.sec.Hash(pass)
+	if err != nil {
+		return err
+	}
+	u.Password = hash
+	return a.udb.Update(db, u)
+}
+
+// login starts a new session for the user and issues tokens for it
+func (a *Auth) login(ctx context.Context, u *gorsk.User) (*gorsk.AuthToken, error) {
+	db := a.db.WithContext(ctx)
+	refresh, err := a.sec.RandomToken(secure.RefreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	client := gorsk.ClientFromContext(ctx)
+	session := gorsk.Session{
+		UserID:    u.ID,
+		TokenHash: a.sec.TokenHash(refresh),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		LastUsed:  now,
+	}
+	if a.cfg.MaxRefresh > 0 {
+		session.ExpiresAt = now.Add(a.cfg.MaxRefresh)
+	}
+
+	s, err := a.sdb.Create(db, session)
+	if err != nil {
+		return nil, err
 	}
 
-	token, expire, err := a.tg.GenerateToken(u)
+	token, expire, err := a.tg.GenerateToken(u, s.ID)
 	if err != nil {
 		return nil, gorsk.ErrUnauthorized
 	}
 
-	u.UpdateLastLogin(a.sec.Token(token))
+	u.UpdateLastLogin()
 
-	if err := a.udb.Update(a.db, u); err != nil {
+	if err := a.udb.Update(db, u); err != nil {
 		return nil, err
 	}
 
-	return &gorsk.AuthToken{Token: token, Expires: expire, RefreshToken: u.Token}, nil
+	return &gorsk.AuthToken{Token: token, Expires: expire, RefreshToken: refresh}, nil
 }
 
-// Refresh refreshes jwt token and puts new claims inside
-func (a *Auth) Refresh(c echo.Context, token string) (*gorsk.RefreshToken, error) {
-	user, err := a.udb.FindByToken(a.db, token)
+// Refresh refreshes jwt token and puts new claims inside.
+// Refresh token is rotated, presenting a previously rotated token terminates the session.
+func (a *Auth) Refresh(ctx context.Context, token string) (*gorsk.RefreshToken, error) {
+	db := a.db.WithContext(ctx)
+	hash := a.sec.TokenHash(token)
+	s, err := a.sdb.FindByToken(db, hash)
+	if err == pg.ErrNoRows {
+		return nil, a.detectReuse(db, hash)
+	}
 	if err != nil {
 		return nil, err
 	}
-	token, expire, err := a.tg.GenerateToken(user)
+
+	if s.Expired(a.now(), a.cfg.RefreshDuration) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := a.udb.View(db, s.UserID)
 	if err != nil {
 		return nil, err
 	}
-	return &gorsk.RefreshToken{Token: token, Expires: expire}, nil
+
+	if !user.Active {
+		return nil, gorsk.ErrUnauthorized
+	}
+
+	refresh, err := a.sec.RandomToken(secure.RefreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	s.TokenHash = a.sec.TokenHash(refresh)
+	s.UpdateLastUsed(gorsk.ClientFromContext(ctx).IP)
+	if err := a.sdb.Rotate(db, s, hash); err != nil {
+		if err == pg.ErrNoRows {
+			// Token was rotated by a concurrent request, treat it as reused
+			return nil, a.terminate(db, s.ID)
+		}
+		return nil, err
+	}
+
+	token, expire, err := a.tg.GenerateToken(user, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gorsk.RefreshToken{Token: token, Expires: expire, RefreshToken: refresh}, nil
+}
+
+func (a *Auth) now() time.Time {
+	if a.cfg.Clock != nil {
+		return a.cfg.Clock()
+	}
+	return time.Now()
+}
+
+// detectReuse terminates the session a retired refresh token belonged to
+func (a *Auth) detectReuse(db orm.DB, hash string) error {
+	sessionID, err := a.sdb.FindRetired(db, hash)
+	if err == pg.ErrNoRows {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return err
+	}
+	return a.terminate(db, sessionID)
+}
+
+func (a *Auth) terminate(db orm.DB, sessionID int) error {
+	if err := a.sdb.Delete(db, &gorsk.Session{Base: gorsk.Base{ID: sessionID}}); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
 }
 
 // Me returns info about currently logged user
-func (a *Auth) Me(c echo.Context) (*gorsk.User, error) {
-	au := a.rbac.User(c)
-	return a.udb.View(a.db, au.ID)
+func (a *Auth) Me(ctx context.Context) (*gorsk.User, error) {
+	db := a.db.WithContext(ctx)
+	au := a.rbac.User(ctx)
+	return a.udb.View(db, au.ID)
+}
+
+// JWKS returns public keys other services can use for verifying access tokens
+func (a *Auth) JWKS(ctx context.Context) *gorsk.JWKS {
+	return a.tg.JWKS()
+}
+
+// Logout terminates current session and revokes the access token used for the request
+func (a *Auth) Logout(ctx context.Context) error {
+	au := a.rbac.User(ctx)
+	if au.SessionID != 0 {
+		if err := a.DeleteSession(ctx, au.SessionID); err != nil && err != ErrSessionNotFound {
+			return err
+		}
+	}
+	return a.tg.Revoke(ctx)
+}
+
+// RevokeAll terminates all user's sessions and revokes all access tokens issued to the user
+func (a *Auth) RevokeAll(ctx context.Context, userID int) error {
+	db := a.db.WithContext(ctx)
+	if err := a.rbac.EnforceUser(ctx, gorsk.PermSessionsRevoke, userID); err != nil {
+		return err
+	}
+	if err := a.sdb.DeleteByUser(db, userID); err != nil {
+		return err
+	}
+	return a.tg.RevokeUser(ctx, userID)
+}
+
+// Sessions returns currently logged user's sessions
+func (a *Auth) Sessions(ctx context.Context) ([]gorsk.Session, error) {
+	db := a.db.WithContext(ctx)
+	au := a.rbac.User(ctx)
+	sessions, err := a.sdb.List(db, au.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == au.SessionID
+	}
+	return sessions, nil
+}
+
+// DeleteSession terminates one of currently logged user's sessions.
+// Access tokens already issued for the session remain valid until they expire.
+func (a *Auth) DeleteSession(ctx context.Context, id int) error {
+	db := a.db.WithContext(ctx)
+	au := a.rbac.User(ctx)
+	s, err := a.sdb.View(db, id)
+	if err == pg.ErrNoRows {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if s.UserID != au.ID {
+		return ErrSessionNotFound
+	}
+	return a.sdb.Delete(db, s)
 }
@@ -1,6 +1,7 @@
 package auth_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,8 +10,8 @@ import (
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
 	"github.com/figassis/goduck/pkg/utl/model"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
-	"github.com/labstack/echo"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,8 +25,11 @@ func TestAuthenticate(t *testing.T) {
 		name     string
 		args     args
 		wantData *gorsk.AuthToken
+		wantMFA  *gorsk.MFAChallenge
 		wantErr  bool
 		udb      *mockdb.User
+		sdb      *mockdb.Session
+		mdb      *mockdb.MFA
 		jwt      *mock.JWT
 		sec      *mock.Secure
 	}{
@@ -51,8 +55,8 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return false
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return false, false
 				},
 			},
 		},
@@ -70,8 +74,8 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
 				},
 			},
 		},
@@ -89,16 +93,52 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u *gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
 					return "", "", gorsk.ErrGeneric
 				},
 			},
 		},
+		{
+			name:    "Fail on session create",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{
+						Username: user,
+						Password: "pass",
+						Active:   true,
+					}, nil
+				},
+			},
+			sdb: &mockdb.Session{
+				CreateFn: func(orm.DB, gorsk.Session) (*gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+		},
 		{
 			name:    "Fail on updating last login",
 			args:    args{user: "juzernejm", pass: "pass"},
@@ -116,15 +156,18 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
 				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u *gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
 					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", mock.TestTime(2000).Format(time.RFC3339), nil
 				},
 			},
@@ -145,16 +188,76 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u *gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
+					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", mock.TestTime(2000).Format(time.RFC3339), nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(prefix string) (string, error) {
+					return prefix + "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantData: &gorsk.AuthToken{
+				Token:        "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+				Expires:      mock.TestTime(2000).Format(time.RFC3339),
+				RefreshToken: "rt_refreshtoken",
+			},
+		},
+		{
+			name:    "Fail on rehashing password",
+			args:    args{user: "juzernejm", pass: "pass"},
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Password: "oldhash", Active: true}, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, true
+				},
+				HashFn: func(string) (string, error) {
+					return "", gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Success with rehashed password",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Password: "oldhash", Active: true}, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					if u.Password != "newhash" {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
 					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", mock.TestTime(2000).Format(time.RFC3339), nil
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				VerifyPasswordFn: func(hash, pass string) (bool, bool) {
+					return hash == "oldhash" && pass == "pass", true
+				},
+				HashFn: func(pass string) (string, error) {
+					return "newhash", nil
 				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
 			wantData: &gorsk.AuthToken{
@@ -163,92 +266,450 @@ func TestAuthenticate(t *testing.T) {
 				RefreshToken: "refreshtoken",
 			},
 		},
+		{
+			name: "Fail on creating challenge",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Active: true, MFAEnabled: true}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(orm.DB, gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "MFA enabled",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 3}, Username: user, Active: true, MFAEnabled: true}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					if ch.UserID != 3 || ch.TokenHash != "hashedtoken" || !ch.ExpiresAt.Equal(mock.TestTime(2018).Add(5*time.Minute)) {
+						return nil, gorsk.ErrGeneric
+					}
+					return &ch, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantMFA: &gorsk.MFAChallenge{
+				MFARequired:    true,
+				ChallengeToken: "challengetoken",
+				Expires:        mock.TestTime(2018).Add(5 * time.Minute).Format(time.RFC3339),
+			},
+		},
+		{
+			name: "MFA required for role",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Active: true, Role: &gorsk.Role{AccessLevel: gorsk.SuperAdminRole}}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					return &ch, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantMFA: &gorsk.MFAChallenge{
+				MFARequired:        true,
+				ChallengeToken:     "challengetoken",
+				Expires:            mock.TestTime(2018).Add(5 * time.Minute).Format(time.RFC3339),
+				EnrollmentRequired: true,
+			},
+		},
+		{
+			name: "Password expired",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 3}, Username: user, Active: true, LastPasswordChange: mock.TestTime(2017)}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					if ch.UserID != 3 || !ch.PasswordChange {
+						return nil, gorsk.ErrGeneric
+					}
+					return &ch, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantMFA: &gorsk.MFAChallenge{
+				PasswordChangeRequired: true,
+				ChallengeToken:         "challengetoken",
+				Expires:                mock.TestTime(2018).Add(5 * time.Minute).Format(time.RFC3339),
+			},
+		},
+		{
+			name: "MFA before password change",
+			args: args{user: "juzernejm", pass: "pass"},
+			udb: &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Active: true, MFAEnabled: true, PasswordChangeRequired: true}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					if ch.PasswordChange {
+						return nil, gorsk.ErrGeneric
+					}
+					return &ch, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantMFA: &gorsk.MFAChallenge{
+				MFARequired:    true,
+				ChallengeToken: "challengetoken",
+				Expires:        mock.TestTime(2018).Add(5 * time.Minute).Format(time.RFC3339),
+			},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, tt.jwt, tt.sec, nil)
-			token, err := s.Authenticate(nil, tt.args.user, tt.args.pass)
+			sdb := tt.sdb
+			if sdb == nil {
+				sdb = &mockdb.Session{
+					CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+						if s.TokenHash != "hashedtoken" || s.ExpiresAt.Sub(s.LastUsed) != time.Hour {
+							return nil, gorsk.ErrGeneric
+						}
+						s.ID = 1
+						return &s, nil
+					},
+				}
+			}
+			s := auth.New(mockdb.DB{}, tt.udb, sdb, tt.mdb, nil, nil, tt.jwt, tt.sec, nil, &auth.Config{
+				MaxRefresh:      time.Hour,
+				MFARequiredRole: gorsk.AdminRole,
+				PasswordMaxAge:  90 * 24 * time.Hour,
+				Clock:           func() time.Time { return mock.TestTime(2018) },
+			})
+			token, ch, err := s.Authenticate(context.Background(), tt.args.user, tt.args.pass)
 			if tt.wantData != nil {
 				tt.wantData.RefreshToken = token.RefreshToken
 				assert.Equal(t, tt.wantData, token)
 			}
+			assert.Equal(t, tt.wantMFA, ch)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
 }
-func TestRefresh(t *testing.T) {
-	type args struct {
-		c     echo.Context
-		token string
+
+func TestAuthenticateUnknownUser(t *testing.T) {
+	udb := &mockdb.User{
+		FindByUsernameFn: func(orm.DB, string) (*gorsk.User, error) {
+			return nil, pg.ErrNoRows
+		},
 	}
+	s := auth.New(mockdb.DB{}, udb, nil, nil, nil, nil, nil, nil, nil, &auth.Config{})
+	_, _, err := s.Authenticate(context.Background(), "juzernejm", "hunter123")
+	assert.Equal(t, auth.ErrInvalidCredentials, err)
+}
+
+func TestAuthenticateVerifiedEmail(t *testing.T) {
+	now := mock.TestTime(2018)
 	cases := []struct {
 		name     string
-		args     args
-		wantData *gorsk.RefreshToken
-		wantErr  bool
-		udb      *mockdb.User
-		jwt      *mock.JWT
+		require  bool
+		verified time.Time
+		wantErr  error
 	}{
 		{
-			name:    "Fail on finding token",
-			args:    args{token: "refreshtoken"},
-			wantErr: true,
-			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (*gorsk.User, error) {
+			name: "Verification not required",
+		},
+		{
+			name:    "Unverified email",
+			require: true,
+			wantErr: auth.ErrEmailNotVerified,
+		},
+		{
+			name:     "Verified email",
+			require:  true,
+			verified: now,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+					return &gorsk.User{Username: user, Password: "pass", Active: true, EmailVerifiedAt: tt.verified}, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.User) error {
+					return nil
+				},
+			}
+			sdb := &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+					return &s, nil
+				},
+			}
+			jwt := &mock.JWT{
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+					return "jwttokenstring", now.Format(time.RFC3339), nil
+				},
+			}
+			sec := &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, sdb, nil, nil, nil, jwt, sec, nil, &auth.Config{
+				RequireVerifiedEmail: tt.require,
+				Clock:                func() time.Time { return now },
+			})
+			_, _, err := s.Authenticate(context.Background(), "johndoe", "pass")
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	activeUser := &mockdb.User{
+		ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
+			return &gorsk.User{
+				Username: "username",
+				Password: "password",
+				Active:   true,
+			}, nil
+		},
+	}
+	cases := []struct {
+		name       string
+		token      string
+		cfg        *auth.Config
+		wantData   *gorsk.RefreshToken
+		wantErr    error
+		wantDelete int
+		udb        *mockdb.User
+		sdb        *mockdb.Session
+		jwt        *mock.JWT
+	}{
+		{
+			name:    "Fail on finding session",
+			token:   "refreshtoken",
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
 					return nil, gorsk.ErrGeneric
 				},
 			},
 		},
 		{
-			name:    "Fail on token generation",
-			args:    args{token: "refreshtoken"},
-			wantErr: true,
+			name:    "Unknown token",
+			token:   "refreshtoken",
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return nil, pg.ErrNoRows
+				},
+				FindRetiredFn: func(orm.DB, string) (int, error) {
+					return 0, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:       "Reused token",
+			token:      "refreshtoken",
+			wantErr:    auth.ErrInvalidRefreshToken,
+			wantDelete: 5,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return nil, pg.ErrNoRows
+				},
+				FindRetiredFn: func(db orm.DB, hash string) (int, error) {
+					return 5, nil
+				},
+			},
+		},
+		{
+			name:    "Session expired",
+			token:   "refreshtoken",
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return &gorsk.Session{UserID: 1, ExpiresAt: mock.TestTime(2000)}, nil
+				},
+			},
+		},
+		{
+			name:    "Session idle for too long",
+			token:   "refreshtoken",
+			cfg:     &auth.Config{RefreshDuration: time.Hour},
+			wantErr: auth.ErrInvalidRefreshToken,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return &gorsk.Session{UserID: 1, LastUsed: time.Now().Add(-2 * time.Hour)}, nil
+				},
+			},
+		},
+		{
+			name:    "Inactive user",
+			token:   "refreshtoken",
+			wantErr: gorsk.ErrUnauthorized,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return &gorsk.Session{UserID: 1}, nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (*gorsk.User, error) {
-					return &gorsk.User{
-						Username: "username",
-						Password: "password",
-						Active:   true,
-						Token:    token,
-					}, nil
+				ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
+					return &gorsk.User{Username: "username"}, nil
+				},
+			},
+		},
+		{
+			name:       "Rotated concurrently",
+			token:      "refreshtoken",
+			wantErr:    auth.ErrInvalidRefreshToken,
+			wantDelete: 5,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: 5}, UserID: 1}, nil
+				},
+				RotateFn: func(orm.DB, *gorsk.Session, string) error {
+					return pg.ErrNoRows
 				},
 			},
+			udb: activeUser,
+		},
+		{
+			name:    "Fail on token generation",
+			token:   "refreshtoken",
+			wantErr: gorsk.ErrGeneric,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					return &gorsk.Session{UserID: 1}, nil
+				},
+				RotateFn: func(orm.DB, *gorsk.Session, string) error {
+					return nil
+				},
+			},
+			udb: activeUser,
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u *gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
 					return "", "", gorsk.ErrGeneric
 				},
 			},
 		},
 		{
-			name: "Success",
-			args: args{token: "refreshtoken"},
-			udb: &mockdb.User{
-				FindByTokenFn: func(db orm.DB, token string) (*gorsk.User, error) {
-					return &gorsk.User{
-						Username: "username",
-						Password: "password",
-						Active:   true,
-						Token:    token,
-					}, nil
+			name:  "Success",
+			token: "refreshtoken",
+			cfg:   &auth.Config{RefreshDuration: time.Hour},
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(db orm.DB, hash string) (*gorsk.Session, error) {
+					if hash != "hashed:refreshtoken" {
+						return nil, pg.ErrNoRows
+					}
+					return &gorsk.Session{Base: gorsk.Base{ID: 5}, UserID: 1, LastUsed: time.Now()}, nil
+				},
+				RotateFn: func(db orm.DB, s *gorsk.Session, oldHash string) error {
+					if oldHash != "hashed:refreshtoken" || s.TokenHash != "hashed:newrefreshtoken" || s.LastUsed.IsZero() {
+						return gorsk.ErrGeneric
+					}
+					return nil
 				},
 			},
+			udb: activeUser,
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(u *gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(u *gorsk.User, sid int) (string, string, error) {
+					if sid != 5 {
+						return "", "", gorsk.ErrGeneric
+					}
 					return "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9", mock.TestTime(2000).Format(time.RFC3339), nil
 				},
 			},
 			wantData: &gorsk.RefreshToken{
-				Token:   "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
-				Expires: mock.TestTime(2000).Format(time.RFC3339),
+				Token:        "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+				Expires:      mock.TestTime(2000).Format(time.RFC3339),
+				RefreshToken: "newrefreshtoken",
 			},
 		},
 	}
+	sec := &mock.Secure{
+		TokenHashFn: func(token string) string {
+			return "hashed:" + token
+		},
+		RandomTokenFn: func(string) (string, error) {
+			return "newrefreshtoken", nil
+		},
+	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, tt.jwt, nil, nil)
-			token, err := s.Refresh(tt.args.c, tt.args.token)
+			var deleted int
+			tt.sdb.DeleteFn = func(db orm.DB, s *gorsk.Session) error {
+				deleted = s.ID
+				return nil
+			}
+			s := auth.New(mockdb.DB{}, tt.udb, tt.sdb, nil, nil, nil, tt.jwt, sec, nil, tt.cfg)
+			token, err := s.Refresh(context.Background(), tt.token)
 			assert.Equal(t, tt.wantData, token)
-			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantDelete, deleted)
 		})
 	}
 }
@@ -264,7 +725,7 @@ func TestMe(t *testing.T) {
 		{
 			name: "Success",
 			rbac: &mock.RBAC{
-				UserFn: func(echo.Context) *gorsk.AuthUser {
+				UserFn: func(context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{ID: 9}
 				},
 			},
@@ -300,16 +761,258 @@ func TestMe(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := auth.New(nil, tt.udb, nil, nil, tt.rbac)
-			user, err := s.Me(nil)
+			s := auth.New(mockdb.DB{}, tt.udb, nil, nil, nil, nil, nil, nil, tt.rbac, nil)
+			user, err := s.Me(context.Background())
 			assert.Equal(t, tt.wantData, user)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
 }
 
+func TestLogout(t *testing.T) {
+	cases := []struct {
+		name        string
+		sessionID   int
+		sdb         *mockdb.Session
+		wantErr     bool
+		wantRevoked bool
+	}{
+		{
+			name:      "Fail on session view",
+			sessionID: 3,
+			wantErr:   true,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (*gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:        "Token without session",
+			wantRevoked: true,
+		},
+		{
+			name:        "Session already terminated",
+			sessionID:   3,
+			wantRevoked: true,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (*gorsk.Session, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:        "Success",
+			sessionID:   3,
+			wantRevoked: true,
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(db orm.DB, s *gorsk.Session) error {
+					if s.ID != 3 {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked bool
+			jwt := &mock.JWT{
+				RevokeFn: func(context.Context) error {
+					revoked = true
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, SessionID: tt.sessionID}
+				},
+			}
+			s := auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, jwt, nil, rbac, nil)
+			err := s.Logout(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantRevoked, revoked)
+		})
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	cases := []struct {
+		name        string
+		id          int
+		rbac        *mock.RBAC
+		sdb         *mockdb.Session
+		wantErr     bool
+		wantRevoked int
+	}{
+		{
+			name:    "Fail on RBAC",
+			id:      2,
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on deleting sessions",
+			id:      2,
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(orm.DB, int) error {
+					return gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:        "Success",
+			id:          2,
+			wantRevoked: 2,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(db orm.DB, id int) error {
+					if id != 2 {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked int
+			jwt := &mock.JWT{
+				RevokeUserFn: func(_ context.Context, id int) error {
+					revoked = id
+					return nil
+				},
+			}
+			s := auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, jwt, nil, tt.rbac, nil)
+			err := s.RevokeAll(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantRevoked, revoked)
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	cases := []struct {
+		name     string
+		sdb      *mockdb.Session
+		wantData []gorsk.Session
+		wantErr  bool
+	}{
+		{
+			name:    "Fail on list",
+			wantErr: true,
+			sdb: &mockdb.Session{
+				ListFn: func(orm.DB, int) ([]gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name: "Success",
+			sdb: &mockdb.Session{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.Session, error) {
+					return []gorsk.Session{
+						{Base: gorsk.Base{ID: 3}, UserID: userID, UserAgent: "laptop"},
+						{Base: gorsk.Base{ID: 4}, UserID: userID, UserAgent: "phone"},
+					}, nil
+				},
+			},
+			wantData: []gorsk.Session{
+				{Base: gorsk.Base{ID: 3}, UserID: 1, UserAgent: "laptop", Current: true},
+				{Base: gorsk.Base{ID: 4}, UserID: 1, UserAgent: "phone"},
+			},
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1, SessionID: 3}
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, nil, nil, rbac, nil)
+			sessions, err := s.Sessions(context.Background())
+			assert.Equal(t, tt.wantData, sessions)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		sdb     *mockdb.Session
+		wantErr error
+	}{
+		{
+			name:    "Session does not exist",
+			id:      3,
+			wantErr: auth.ErrSessionNotFound,
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (*gorsk.Session, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Session of another user",
+			id:      3,
+			wantErr: auth.ErrSessionNotFound,
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 2}, nil
+				},
+			},
+		},
+		{
+			name: "Success",
+			id:   3,
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Session) error {
+					return nil
+				},
+			},
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1}
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, nil, nil, rbac, nil)
+			err := s.DeleteSession(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
 func TestInitialize(t *testing.T) {
-	a := auth.Initialize(nil, nil, nil, nil)
+	a := auth.Initialize(nil, nil, nil, nil, nil, nil)
 	if a == nil {
 		t.Error("auth service not initialized")
 	}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
+)
+
+// Custom errors
+var (
+	ErrAccountLocked   = echo.NewHTTPError(http.StatusLocked, "Account is temporarily locked due to too many failed login attempts")
+	ErrTooManyAttempts = echo.NewHTTPError(http.StatusTooManyRequests, "Too many failed login attempts, try again later")
+)
+
+// attemptKey identifies failed login attempts tracked for a username or client IP
+type attemptKey struct {
+	key   string
+	max   int
+	delay bool
+	err   error
+}
+
+func userAttemptKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// attemptKeys returns keys failed login attempts are tracked by, nil if throttling is disabled
+func (a *Auth) attemptKeys(ctx context.Context, username string) []attemptKey {
+	var keys []attemptKey
+	if a.cfg.MaxFailures > 0 || a.cfg.Delay > 0 {
+		keys = append(keys, attemptKey{key: userAttemptKey(username), max: a.cfg.MaxFailures, delay: a.cfg.Delay > 0, err: ErrAccountLocked})
+	}
+	if ip := gorsk.ClientFromContext(ctx).IP; a.cfg.MaxIPFailures > 0 && ip != "" {
+		keys = append(keys, attemptKey{key: "ip:" + ip, max: a.cfg.MaxIPFailures, err: ErrTooManyAttempts})
+	}
+	return keys
+}
+
+// checkAttempts refuses the login while locked out, or before the delay after last failure passed
+func (a *Auth) checkAttempts(ctx context.Context, keys []attemptKey, now time.Time) error {
+	for _, k := range keys {
+		at, err := a.lim.Get(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if at.Locked(now) {
+			return k.err
+		}
+		if k.delay && at.Failures > 0 && now.Before(at.LastFailure.Add(a.delay(at.Failures))) {
+			return ErrTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// failAttempt records a failed login, locking keys which reached their limit
+func (a *Auth) failAttempt(ctx context.Context, keys []attemptKey, now time.Time) error {
+	var locked error
+	for _, k := range keys {
+		at, err := a.lim.Fail(ctx, k.key, now, a.cfg.Lockout)
+		if err != nil {
+			return err
+		}
+		if k.max > 0 && at.Failures >= k.max {
+			if err := a.lim.Lock(ctx, k.key, now.Add(a.cfg.Lockout)); err != nil {
+				return err
+			}
+			if locked == nil {
+				locked = k.err
+			}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return ErrInvalidCredentials
+}
+
+// resetAttempts forgets failed logins of the username after a successful login
+func (a *Auth) resetAttempts(ctx context.Context, keys []attemptKey, username string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return a.lim.Reset(ctx, userAttemptKey(username))
+}
+
+// delay returns the wait required after given number of failures, doubling with each failure up to lockout duration
+func (a *Auth) delay(failures int) time.Duration {
+	d := a.cfg.Delay
+	for i := 1; i < failures && d < a.cfg.Lockout; i++ {
+		d *= 2
+	}
+	if d > a.cfg.Lockout {
+		return a.cfg.Lockout
+	}
+	return d
+}
+
+// Unlock lifts the lockout of user's account, caused by failed logins
+func (a *Auth) Unlock(ctx context.Context, userID int) error {
+	db := a.db.WithContext(ctx)
+	if err := a.rbac.EnforceUser(ctx, gorsk.PermUsersUnlock, userID); err != nil {
+		return err
+	}
+	u, err := a.udb.View(db, userID)
+	if err != nil {
+		return err
+	}
+	return a.lim.Reset(ctx, userAttemptKey(u.Username))
+}
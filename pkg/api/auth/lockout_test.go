@@ -0,0 +1,292 @@
+package auth_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/auth"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/throttle"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout(t *testing.T) {
+	now := mock.TestTime(2018)
+	udb := &mockdb.User{
+		FindByUsernameFn: func(db orm.DB, user string) (*gorsk.User, error) {
+			if user != "johndoe" {
+				return nil, pg.ErrNoRows
+			}
+			return &gorsk.User{Username: user, Password: "hunter123", Active: true}, nil
+		},
+		UpdateFn: func(orm.DB, *gorsk.User) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+			return &s, nil
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+			return "jwttokenstring", now.Format(time.RFC3339), nil
+		},
+	}
+	sec := &mock.Secure{
+		VerifyPasswordFn: func(hash, pass string) (bool, bool) {
+			return hash == pass, false
+		},
+		RandomTokenFn: func(string) (string, error) {
+			return "refreshtoken", nil
+		},
+		TokenHashFn: func(s string) string {
+			return s
+		},
+	}
+
+	type attempt struct {
+		after time.Duration
+		user  string
+		pass  string
+		want  error
+	}
+	cases := []struct {
+		name     string
+		cfg      auth.Config
+		attempts []attempt
+	}{
+		{
+			name: "Throttling disabled",
+			attempts: []attempt{
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Username locked out",
+			cfg:  auth.Config{MaxFailures: 3, Lockout: 10 * time.Minute},
+			attempts: []attempt{
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "JohnDoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "wrong", want: auth.ErrAccountLocked},
+				{after: 5 * time.Minute, user: "johndoe", pass: "hunter123", want: auth.ErrAccountLocked},
+				{after: 11 * time.Minute, user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Unknown usernames count as failures",
+			cfg:  auth.Config{MaxFailures: 2},
+			attempts: []attempt{
+				{user: "janedoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "janedoe", pass: "wrong", want: auth.ErrAccountLocked},
+				{user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Success resets failures",
+			cfg:  auth.Config{MaxFailures: 2},
+			attempts: []attempt{
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "hunter123"},
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Failures outside of window are forgotten",
+			cfg:  auth.Config{MaxFailures: 2, Lockout: time.Minute},
+			attempts: []attempt{
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{after: 2 * time.Minute, user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{after: 2 * time.Minute, user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Client IP locked out",
+			cfg:  auth.Config{MaxIPFailures: 3},
+			attempts: []attempt{
+				{user: "alice", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "bob", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "carol", pass: "wrong", want: auth.ErrTooManyAttempts},
+				{user: "johndoe", pass: "hunter123", want: auth.ErrTooManyAttempts},
+				{after: 16 * time.Minute, user: "johndoe", pass: "hunter123"},
+			},
+		},
+		{
+			name: "Progressive delay",
+			cfg:  auth.Config{Delay: time.Second},
+			attempts: []attempt{
+				{user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{user: "johndoe", pass: "hunter123", want: auth.ErrTooManyAttempts},
+				{after: time.Second, user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{after: time.Second, user: "johndoe", pass: "wrong", want: auth.ErrTooManyAttempts},
+				{after: 2 * time.Second, user: "johndoe", pass: "wrong", want: auth.ErrInvalidCredentials},
+				{after: 3 * time.Second, user: "johndoe", pass: "hunter123", want: auth.ErrTooManyAttempts},
+				{after: 4 * time.Second, user: "johndoe", pass: "hunter123"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := now
+			cfg := tt.cfg
+			cfg.Clock = func() time.Time { return clock }
+			s := auth.New(mockdb.DB{}, udb, sdb, nil, throttle.NewMemory(), nil, jwt, sec, nil, &cfg)
+			ctx := gorsk.NewClientContext(context.Background(), gorsk.Client{IP: "192.0.2.1"})
+			for i, at := range tt.attempts {
+				clock = clock.Add(at.after)
+				_, _, err := s.Authenticate(ctx, at.user, at.pass)
+				assert.Equal(t, at.want, err, "attempt %d", i+1)
+			}
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	cases := []struct {
+		name    string
+		rbac    *mock.RBAC
+		udb     *mockdb.User
+		wantErr bool
+	}{
+		{
+			name: "Fail on RBAC",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fail on user view",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Username: "JohnDoe"}, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			lim := throttle.NewMemory()
+			if err := lim.Lock(context.Background(), "user:johndoe", time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			s := auth.New(mockdb.DB{}, tt.udb, nil, nil, lim, nil, nil, nil, tt.rbac, nil)
+			err := s.Unlock(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			at, _ := lim.Get(context.Background(), "user:johndoe")
+			assert.Equal(t, tt.wantErr, at.Locked(time.Now()))
+		})
+	}
+}
+
+func TestMFALockout(t *testing.T) {
+	now := mock.TestTime(2018)
+	usr := gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Password: "hunter123", Active: true, MFAEnabled: true, TOTPSecret: testSecret}
+	udb := &mockdb.User{
+		FindByUsernameFn: func(orm.DB, string) (*gorsk.User, error) {
+			u := usr
+			return &u, nil
+		},
+		ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+			u := usr
+			return &u, nil
+		},
+		UpdateFn: func(orm.DB, *gorsk.User) error {
+			return nil
+		},
+	}
+	challenges := map[string]*gorsk.LoginChallenge{}
+	mdb := &mockdb.MFA{
+		CreateChallengeFn: func(_ orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+			challenges[ch.TokenHash] = &ch
+			return &ch, nil
+		},
+		FindChallengeFn: func(_ orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+			ch, ok := challenges[hash]
+			if !ok {
+				return nil, pg.ErrNoRows
+			}
+			c := *ch
+			return &c, nil
+		},
+		UpdateChallengeFn: func(_ orm.DB, ch *gorsk.LoginChallenge) error {
+			challenges[ch.TokenHash] = ch
+			return nil
+		},
+		DeleteChallengeFn: func(_ orm.DB, ch *gorsk.LoginChallenge) error {
+			delete(challenges, ch.TokenHash)
+			return nil
+		},
+		UseRecoveryCodeFn: func(orm.DB, int, string) error {
+			return pg.ErrNoRows
+		},
+	}
+	var seq int
+	sec := &mock.Secure{
+		VerifyPasswordFn: func(hash, pass string) (bool, bool) {
+			return hash == pass, false
+		},
+		RandomTokenFn: func(prefix string) (string, error) {
+			seq++
+			return prefix + strconv.Itoa(seq), nil
+		},
+		TokenHashFn: func(s string) string {
+			return s
+		},
+	}
+	s := auth.New(mockdb.DB{}, udb, nil, mdb, throttle.NewMemory(), nil, nil, sec, nil, &auth.Config{
+		MaxFailures: 3,
+		Clock:       func() time.Time { return now },
+	})
+	ctx := context.Background()
+
+	// Each login with the right password gets a new challenge, but wrong codes add up
+	for i := 1; i <= 3; i++ {
+		_, ch, err := s.Authenticate(ctx, "johndoe", "hunter123")
+		if err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+		_, _, err = s.VerifyMFA(ctx, ch.ChallengeToken, "000000")
+		if i < 3 {
+			assert.Equal(t, auth.ErrInvalidMFACode, err, "attempt %d", i)
+		} else {
+			assert.Equal(t, auth.ErrAccountLocked, err, "attempt %d", i)
+		}
+	}
+
+	_, _, err := s.Authenticate(ctx, "johndoe", "hunter123")
+	assert.Equal(t, auth.ErrAccountLocked, err)
+}
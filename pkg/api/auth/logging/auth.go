@@ -1,9 +1,9 @@
 package auth
 
 import (
+	"context"
 	"time"
 
-	"github.com/labstack/echo"
 	"github.com/figassis/goduck/pkg/api/auth"
 	"github.com/figassis/goduck/pkg/utl/model"
 )
@@ -25,25 +25,70 @@ type LogService struct {
 const name = "auth"
 
 // Authenticate logging
-func (ls *LogService) Authenticate(c echo.Context, user, password string) (resp *gorsk.AuthToken, err error) {
+func (ls *LogService) Authenticate(ctx context.Context, user, password string) (resp *gorsk.AuthToken, ch *gorsk.MFAChallenge, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Authenticate request", err,
 			map[string]interface{}{
-				"req":  user,
+				"req":                      user,
+				"mfa_required":             ch != nil && ch.MFARequired,
+				"password_change_required": ch != nil && ch.PasswordChangeRequired,
+				"took":                     time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Authenticate(ctx, user, password)
+}
+
+// VerifyMFA logging
+func (ls *LogService) VerifyMFA(ctx context.Context, challenge, code string) (resp *gorsk.AuthToken, ch *gorsk.MFAChallenge, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Verify MFA request", err,
+			map[string]interface{}{
+				"password_change_required": ch != nil,
+				"took":                     time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.VerifyMFA(ctx, challenge, code)
+}
+
+// ChangeExpiredPassword logging
+func (ls *LogService) ChangeExpiredPassword(ctx context.Context, challenge, password string) (resp *gorsk.AuthToken, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Change expired password request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ChangeExpiredPassword(ctx, challenge, password)
+}
+
+// EnrollMFAChallenge logging
+func (ls *LogService) EnrollMFAChallenge(ctx context.Context, challenge string) (resp *gorsk.TOTPEnrollment, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Enroll MFA on login request", err,
+			map[string]interface{}{
 				"took": time.Since(begin),
 			},
 		)
 	}(time.Now())
-	return ls.Service.Authenticate(c, user, password)
+	return ls.Service.EnrollMFAChallenge(ctx, challenge)
 }
 
 // Refresh logging
-func (ls *LogService) Refresh(c echo.Context, req string) (resp *gorsk.RefreshToken, err error) {
+func (ls *LogService) Refresh(ctx context.Context, req string) (resp *gorsk.RefreshToken, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Refresh request", err,
 			map[string]interface{}{
 				"req":  req,
@@ -52,14 +97,14 @@ func (ls *LogService) Refresh(c echo.Context, req string) (resp *gorsk.RefreshTo
 			},
 		)
 	}(time.Now())
-	return ls.Service.Refresh(c, req)
+	return ls.Service.Refresh(ctx, req)
 }
 
 // Me logging
-func (ls *LogService) Me(c echo.Context) (resp *gorsk.User, err error) {
+func (ls *LogService) Me(ctx context.Context) (resp *gorsk.User, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Me request", err,
 			map[string]interface{}{
 				"resp": resp,
@@ -67,5 +112,120 @@ func (ls *LogService) Me(c echo.Context) (resp *gorsk.User, err error) {
 			},
 		)
 	}(time.Now())
-	return ls.Service.Me(c)
+	return ls.Service.Me(ctx)
+}
+
+// Logout logging
+func (ls *LogService) Logout(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Logout request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Logout(ctx)
+}
+
+// RevokeAll logging
+func (ls *LogService) RevokeAll(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Revoke all sessions request", err,
+			map[string]interface{}{
+				"req":  id,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.RevokeAll(ctx, id)
+}
+
+// Unlock logging
+func (ls *LogService) Unlock(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Unlock user request", err,
+			map[string]interface{}{
+				"req":  id,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Unlock(ctx, id)
+}
+
+// Sessions logging
+func (ls *LogService) Sessions(ctx context.Context) (resp []gorsk.Session, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "List sessions request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Sessions(ctx)
+}
+
+// DeleteSession logging
+func (ls *LogService) DeleteSession(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Delete session request", err,
+			map[string]interface{}{
+				"req":  id,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.DeleteSession(ctx, id)
+}
+
+// EnrollTOTP logging
+func (ls *LogService) EnrollTOTP(ctx context.Context) (resp *gorsk.TOTPEnrollment, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Enroll TOTP request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.EnrollTOTP(ctx)
+}
+
+// ConfirmTOTP logging
+func (ls *LogService) ConfirmTOTP(ctx context.Context, code string) (resp []string, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Confirm TOTP request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ConfirmTOTP(ctx, code)
+}
+
+// DisableTOTP logging
+func (ls *LogService) DisableTOTP(ctx context.Context, code string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Disable TOTP request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.DisableTOTP(ctx, code)
 }
@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/secure"
+	"github.com/figassis/goduck/pkg/utl/totp"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	challengeDuration    = 5 * time.Minute
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+// Custom errors
+var (
+	ErrInvalidChallenge  = echo.NewHTTPError(http.StatusUnauthorized, "MFA challenge is invalid or expired")
+	ErrInvalidMFACode    = echo.NewHTTPError(http.StatusUnauthorized, "MFA code is invalid")
+	ErrMFAAlreadyEnabled = echo.NewHTTPError(http.StatusBadRequest, "MFA is already enabled")
+	ErrMFANotEnrolled    = echo.NewHTTPError(http.StatusBadRequest, "MFA is not enrolled")
+	ErrMFARequired       = echo.NewHTTPError(http.StatusForbidden, "MFA is required for your role")
+)
+
+// VerifyMFA completes login started with Authenticate, by checking TOTP or recovery code.
+// If the user was enrolling during login, MFA gets enabled and recovery codes are returned with the token.
+// If user's password has expired, a password change challenge is returned instead of the token.
+// Wrong codes count as failed logins of the user, so they are throttled across challenges.
+func (a *Auth) VerifyMFA(ctx context.Context, challengeToken, code string) (*gorsk.AuthToken, *gorsk.MFAChallenge, error) {
+	db := a.db.WithContext(ctx)
+	ch, u, err := a.findChallenge(db, challengeToken, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if u.TOTPSecret == "" {
+		return nil, nil, ErrMFANotEnrolled
+	}
+
+	keys := a.attemptKeys(ctx, u.Username)
+	if err := a.checkAttempts(ctx, keys, a.now()); err != nil {
+		return nil, nil, err
+	}
+
+	enrolling := !u.MFAEnabled
+	ok, err := a.verifyCode(db, u, code, !enrolling)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, a.failChallenge(ctx, db, ch, keys)
+	}
+
+	if err := a.mdb.DeleteChallenge(db, ch); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	if err := a.resetAttempts(ctx, keys, u.Username); err != nil {
+		return nil, nil, err
+	}
+
+	var codes []string
+	if enrolling {
+		u.EnableMFA()
+		if codes, err = a.newRecoveryCodes(db, u.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if u.PasswordExpired(a.now(), a.cfg.PasswordMaxAge) {
+		// Accepted TOTP time step and enabled MFA are kept, although no session is started yet
+		if err := a.udb.Update(db, u); err != nil {
+			return nil, nil, err
+		}
+		pch, err := a.challenge(db, u, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		pch.RecoveryCodes = codes
+		return nil, pch, nil
+	}
+
+	token, err := a.login(ctx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	token.RecoveryCodes = codes
+	return token, nil, nil
+}
+
+// EnrollMFAChallenge starts TOTP enrollment during login, for users required to use MFA that have not enabled it yet
+func (a *Auth) EnrollMFAChallenge(ctx context.Context, challengeToken string) (*gorsk.TOTPEnrollment, error) {
+	db := a.db.WithContext(ctx)
+	_, u, err := a.findChallenge(db, challengeToken, false)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return a.enroll(db, u)
+}
+
+// EnrollTOTP starts TOTP enrollment for currently logged in user.
+// MFA gets enabled once the first code is confirmed with ConfirmTOTP.
+func (a *Auth) EnrollTOTP(ctx context.Context) (*gorsk.TOTPEnrollment, error) {
+	db := a.db.WithContext(ctx)
+	u, err := a.udb.View(db, a.rbac.User(ctx).ID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return a.enroll(db, u)
+}
+
+// ConfirmTOTP enables MFA for currently logged in user and returns new recovery codes.
+// Recovery codes are stored hashed, so this is the only time they are shown.
+func (a *Auth) ConfirmTOTP(ctx context.Context, code string) ([]string, error) {
+	db := a.db.WithContext(ctx)
+	u, err := a.udb.View(db, a.rbac.User(ctx).ID)
+	if err != nil {
+		return nil, err
+	}
+	if u.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if u.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	ok, err := a.verifyCode(db, u, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	u.EnableMFA()
+	if err := a.udb.Update(db, u); err != nil {
+		return nil, err
+	}
+	return a.newRecoveryCodes(db, u.ID)
+}
+
+// DisableTOTP disables MFA for currently logged in user, after checking TOTP or recovery code
+func (a *Auth) DisableTOTP(ctx context.Context, code string) error {
+	db := a.db.WithContext(ctx)
+	u, err := a.udb.View(db, a.rbac.User(ctx).ID)
+	if err != nil {
+		return err
+	}
+	if !u.MFAEnabled {
+		return ErrMFANotEnrolled
+	}
+	if a.mfaRequired(u) {
+		return ErrMFARequired
+	}
+
+	ok, err := a.verifyCode(db, u, code, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	u.DisableMFA()
+	if err := a.udb.Update(db, u); err != nil {
+		return err
+	}
+	return a.mdb.ReplaceRecoveryCodes(db, u.ID, nil)
+}
+
+// mfaRequired checks whether user's role requires MFA
+func (a *Auth) mfaRequired(u *gorsk.User) bool {
+	return a.cfg.MFARequiredRole != 0 && u.Role != nil && u.Role.AccessLevel <= a.cfg.MFARequiredRole
+}
+
+// challenge creates a login challenge for the user, who passed password check.
+// The challenge is completed by changing password if passwordChange is set, otherwise by MFA code.
+func (a *Auth) challenge(db orm.DB, u *gorsk.User, passwordChange bool) (*gorsk.MFAChallenge, error) {
+	token, err := a.sec.RandomToken(secure.ChallengeTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := a.now().Add(challengeDuration)
+	ch := gorsk.LoginChallenge{
+		UserID:         u.ID,
+		TokenHash:      a.sec.TokenHash(token),
+		ExpiresAt:      expires,
+		PasswordChange: passwordChange,
+	}
+	if _, err := a.mdb.CreateChallenge(db, ch); err != nil {
+		return nil, err
+	}
+
+	return &gorsk.MFAChallenge{
+		MFARequired:            !passwordChange,
+		PasswordChangeRequired: passwordChange,
+		ChallengeToken:         token,
+		Expires:                expires.Format(time.RFC3339),
+		EnrollmentRequired:     !passwordChange && !u.MFAEnabled,
+	}, nil
+}
+
+// findChallenge returns the pending challenge of the given kind and the user it was issued to
+func (a *Auth) findChallenge(db orm.DB, token string, passwordChange bool) (*gorsk.LoginChallenge, *gorsk.User, error) {
+	ch, err := a.mdb.FindChallenge(db, a.sec.TokenHash(token))
+	if err == pg.ErrNoRows {
+		return nil, nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ch.PasswordChange != passwordChange {
+		return nil, nil, ErrInvalidChallenge
+	}
+
+	if ch.Expired(a.now()) || ch.Attempts >= maxChallengeAttempts {
+		if err := a.mdb.DeleteChallenge(db, ch); err != nil && err != pg.ErrNoRows {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidChallenge
+	}
+
+	u, err := a.udb.View(db, ch.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !u.Active {
+		return nil, nil, gorsk.ErrUnauthorized
+	}
+	return ch, u, nil
+}
+
+// failChallenge counts a failed attempt, dropping the challenge once attempts run out.
+// The attempt is recorded as a failed login too, locking the user out once limits are reached.
+func (a *Auth) failChallenge(ctx context.Context, db orm.DB, ch *gorsk.LoginChallenge, keys []attemptKey) error {
+	ch.Attempts++
+	var err error
+	if ch.Attempts >= maxChallengeAttempts {
+		err = a.mdb.DeleteChallenge(db, ch)
+	} else {
+		err = a.mdb.UpdateChallenge(db, ch)
+	}
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	if len(keys) > 0 {
+		if err := a.failAttempt(ctx, keys, a.now()); err != ErrInvalidCredentials {
+			return err
+		}
+	}
+	return ErrInvalidMFACode
+}
+
+// enroll generates a new TOTP secret for the user, replacing any unconfirmed one
+func (a *Auth) enroll(db orm.DB, u *gorsk.User) (*gorsk.TOTPEnrollment, error) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	u.TOTPSecret = secret
+	u.TOTPCounter = 0
+	if err := a.udb.Update(db, u); err != nil {
+		return nil, err
+	}
+	return &gorsk.TOTPEnrollment{Secret: secret, URI: totp.URI(a.cfg.MFAIssuer, u.Username, secret)}, nil
+}
+
+// verifyCode checks TOTP code, rejecting already used time steps, and optionally a recovery code.
+// The accepted TOTP time step is stored on user, callers have to persist it.
+func (a *Auth) verifyCode(db orm.DB, u *gorsk.User, code string, recovery bool) (bool, error) {
+	if counter, ok := totp.Validate(u.TOTPSecret, code, a.now()); ok {
+		if counter <= u.TOTPCounter {
+			return false, nil
+		}
+		u.TOTPCounter = counter
+		return true, nil
+	}
+
+	if !recovery {
+		return false, nil
+	}
+
+	err := a.mdb.UseRecoveryCode(db, u.ID, a.sec.TokenHash(normalizeRecoveryCode(code)))
+	if err == pg.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// newRecoveryCodes replaces user's recovery codes, returning them in xxxxx-xxxxx format
+func (a *Auth) newRecoveryCodes(db orm.DB, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		token, err := a.sec.RandomToken("")
+		if err != nil {
+			return nil, err
+		}
+		code := normalizeRecoveryCode(token)[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = a.sec.TokenHash(code)
+	}
+	if err := a.mdb.ReplaceRecoveryCodes(db, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,548 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/auth"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/totp"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+func mfaConfig() *auth.Config {
+	return &auth.Config{
+		MFAIssuer:       "GoDuck",
+		MFARequiredRole: gorsk.AdminRole,
+		Clock:           func() time.Time { return mock.TestTime(2018) },
+	}
+}
+
+func mfaSecure() *mock.Secure {
+	return &mock.Secure{
+		RandomTokenFn: func(prefix string) (string, error) {
+			return prefix + "0123456789abcdef", nil
+		},
+		TokenHashFn: func(s string) string {
+			return "hash:" + s
+		},
+	}
+}
+
+func testCode(t *testing.T, at time.Time) string {
+	code, err := totp.Code(testSecret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifyMFA(t *testing.T) {
+	now := mock.TestTime(2018)
+	code := testCode(t, now)
+	validChallenge := func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+		return &gorsk.LoginChallenge{ID: 2, UserID: 1, ExpiresAt: now.Add(time.Minute)}, nil
+	}
+	cases := []struct {
+		name         string
+		code         string
+		user         *gorsk.User
+		mdb          *mockdb.MFA
+		wantErr      error
+		wantCodes    int
+		wantAttempts int
+		wantDeleted  bool
+		wantChange   bool
+	}{
+		{
+			name: "Invalid challenge",
+			code: code,
+			mdb: &mockdb.MFA{
+				FindChallengeFn: func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+			wantErr: auth.ErrInvalidChallenge,
+		},
+		{
+			name: "Expired challenge",
+			code: code,
+			mdb: &mockdb.MFA{
+				FindChallengeFn: func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+					return &gorsk.LoginChallenge{ID: 2, UserID: 1, ExpiresAt: now}, nil
+				},
+			},
+			wantErr:     auth.ErrInvalidChallenge,
+			wantDeleted: true,
+		},
+		{
+			name: "Password change challenge",
+			code: code,
+			mdb: &mockdb.MFA{
+				FindChallengeFn: func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+					return &gorsk.LoginChallenge{ID: 2, UserID: 1, ExpiresAt: now.Add(time.Minute), PasswordChange: true}, nil
+				},
+			},
+			wantErr: auth.ErrInvalidChallenge,
+		},
+		{
+			name: "Inactive user",
+			code: code,
+			user: &gorsk.User{TOTPSecret: testSecret, MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+			},
+			wantErr: gorsk.ErrUnauthorized,
+		},
+		{
+			name: "Wrong code",
+			code: "000000",
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				UseRecoveryCodeFn: func(orm.DB, int, string) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr:      auth.ErrInvalidMFACode,
+			wantAttempts: 1,
+		},
+		{
+			name: "Attempts exhausted",
+			code: "000000",
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+					return &gorsk.LoginChallenge{ID: 2, UserID: 1, Attempts: 4, ExpiresAt: now.Add(time.Minute)}, nil
+				},
+				UseRecoveryCodeFn: func(orm.DB, int, string) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr:     auth.ErrInvalidMFACode,
+			wantDeleted: true,
+		},
+		{
+			name: "Replayed code",
+			code: code,
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, TOTPCounter: totp.Counter(now), MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				UseRecoveryCodeFn: func(orm.DB, int, string) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr:      auth.ErrInvalidMFACode,
+			wantAttempts: 1,
+		},
+		{
+			name: "Challenge used concurrently",
+			code: code,
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				DeleteChallengeFn: func(orm.DB, *gorsk.LoginChallenge) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr: auth.ErrInvalidChallenge,
+		},
+		{
+			name:        "Success",
+			code:        code,
+			user:        &gorsk.User{Active: true, TOTPSecret: testSecret, MFAEnabled: true},
+			mdb:         &mockdb.MFA{FindChallengeFn: validChallenge},
+			wantDeleted: true,
+		},
+		{
+			name: "Success with recovery code",
+			code: "ABCDE-12345",
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, MFAEnabled: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				UseRecoveryCodeFn: func(db orm.DB, userID int, hash string) error {
+					if userID != 1 || hash != "hash:abcde12345" {
+						return pg.ErrNoRows
+					}
+					return nil
+				},
+			},
+			wantDeleted: true,
+		},
+		{
+			name: "Recovery code can't complete enrollment",
+			code: "abcde-12345",
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+			},
+			wantErr:      auth.ErrInvalidMFACode,
+			wantAttempts: 1,
+		},
+		{
+			name: "Success with enrollment",
+			code: code,
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				ReplaceRecoveryCodesFn: func(db orm.DB, userID int, hashes []string) error {
+					if userID != 1 || len(hashes) != 10 || hashes[0] != "hash:0123456789" {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+			wantCodes:   10,
+			wantDeleted: true,
+		},
+		{
+			name: "Password expired",
+			code: code,
+			user: &gorsk.User{Active: true, TOTPSecret: testSecret, PasswordChangeRequired: true},
+			mdb: &mockdb.MFA{
+				FindChallengeFn: validChallenge,
+				ReplaceRecoveryCodesFn: func(orm.DB, int, []string) error {
+					return nil
+				},
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					if !ch.PasswordChange {
+						return nil, gorsk.ErrGeneric
+					}
+					return &ch, nil
+				},
+			},
+			wantCodes:   10,
+			wantDeleted: true,
+			wantChange:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			var deleted bool
+			var updated *gorsk.User
+			tt.mdb.UpdateChallengeFn = func(db orm.DB, ch *gorsk.LoginChallenge) error {
+				attempts = ch.Attempts
+				return nil
+			}
+			if tt.mdb.DeleteChallengeFn == nil {
+				tt.mdb.DeleteChallengeFn = func(db orm.DB, ch *gorsk.LoginChallenge) error {
+					deleted = ch.ID == 2
+					return nil
+				}
+			}
+			udb := &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
+					u := *tt.user
+					u.ID = id
+					return &u, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			sdb := &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+					return &s, nil
+				},
+			}
+			jwt := &mock.JWT{
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+					return "jwttokenstring", now.Format(time.RFC3339), nil
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, sdb, tt.mdb, nil, nil, jwt, mfaSecure(), nil, mfaConfig())
+			token, ch, err := s.VerifyMFA(context.Background(), "challengetoken", tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantDeleted, deleted)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.True(t, updated.MFAEnabled)
+			var codes []string
+			if tt.wantChange {
+				assert.Nil(t, token)
+				assert.True(t, ch.PasswordChangeRequired)
+				assert.False(t, ch.MFARequired)
+				assert.Equal(t, "lc_0123456789abcdef", ch.ChallengeToken)
+				codes = ch.RecoveryCodes
+			} else {
+				assert.Nil(t, ch)
+				assert.Equal(t, "jwttokenstring", token.Token)
+				codes = token.RecoveryCodes
+			}
+			assert.Len(t, codes, tt.wantCodes)
+			if tt.wantCodes > 0 {
+				assert.Equal(t, "01234-56789", codes[0])
+			}
+		})
+	}
+}
+
+func TestEnrollMFAChallenge(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    *gorsk.User
+		wantErr error
+	}{
+		{
+			name:    "Already enabled",
+			user:    &gorsk.User{Active: true, MFAEnabled: true},
+			wantErr: auth.ErrMFAAlreadyEnabled,
+		},
+		{
+			name: "Success",
+			user: &gorsk.User{Username: "johndoe", Active: true},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			mdb := &mockdb.MFA{
+				FindChallengeFn: func(db orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+					if hash != "hash:challengetoken" {
+						return nil, pg.ErrNoRows
+					}
+					return &gorsk.LoginChallenge{UserID: 1, ExpiresAt: mock.TestTime(2019)}, nil
+				},
+			}
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return tt.user, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, nil, mdb, nil, nil, nil, mfaSecure(), nil, mfaConfig())
+			resp, err := s.EnrollMFAChallenge(context.Background(), "challengetoken")
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(t, resp.Secret, updated.TOTPSecret)
+			assert.False(t, updated.MFAEnabled)
+			assert.Equal(t, totp.URI("GoDuck", "johndoe", resp.Secret), resp.URI)
+		})
+	}
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	cases := []struct {
+		name    string
+		udb     *mockdb.User
+		wantErr bool
+	}{
+		{
+			name: "Fail on user view",
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Already enabled",
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{MFAEnabled: true}, nil
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fail on update",
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{}, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.User) error {
+					return gorsk.ErrGeneric
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Username: "johndoe", TOTPSecret: "OLDSECRET", TOTPCounter: 5}, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					if u.TOTPSecret == "OLDSECRET" || u.TOTPCounter != 0 {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rbac := &mock.RBAC{
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1}
+				},
+			}
+			s := auth.New(mockdb.DB{}, tt.udb, nil, nil, nil, nil, nil, nil, rbac, mfaConfig())
+			resp, err := s.EnrollTOTP(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.NotEmpty(t, resp.Secret)
+				assert.Contains(t, resp.URI, "otpauth://totp/GoDuck:johndoe?")
+			}
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	now := mock.TestTime(2018)
+	cases := []struct {
+		name      string
+		code      string
+		user      *gorsk.User
+		wantErr   error
+		wantCodes []string
+	}{
+		{
+			name:    "Already enabled",
+			code:    testCode(t, now),
+			user:    &gorsk.User{TOTPSecret: testSecret, MFAEnabled: true},
+			wantErr: auth.ErrMFAAlreadyEnabled,
+		},
+		{
+			name:    "Not enrolled",
+			code:    testCode(t, now),
+			user:    &gorsk.User{},
+			wantErr: auth.ErrMFANotEnrolled,
+		},
+		{
+			name:    "Wrong code",
+			code:    testCode(t, now.Add(-2*time.Minute)),
+			user:    &gorsk.User{TOTPSecret: testSecret},
+			wantErr: auth.ErrInvalidMFACode,
+		},
+		{
+			name:      "Success within skew",
+			code:      testCode(t, now.Add(-totp.Period)),
+			user:      &gorsk.User{TOTPSecret: testSecret},
+			wantCodes: []string{"01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789", "01234-56789"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return tt.user, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			mdb := &mockdb.MFA{
+				ReplaceRecoveryCodesFn: func(orm.DB, int, []string) error {
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1}
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, nil, mdb, nil, nil, nil, mfaSecure(), rbac, mfaConfig())
+			codes, err := s.ConfirmTOTP(context.Background(), tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCodes, codes)
+			if tt.wantErr == nil {
+				assert.True(t, updated.MFAEnabled)
+				assert.Equal(t, totp.Counter(now)-1, updated.TOTPCounter)
+			}
+		})
+	}
+}
+
+func TestDisableTOTP(t *testing.T) {
+	now := mock.TestTime(2018)
+	cases := []struct {
+		name    string
+		code    string
+		user    *gorsk.User
+		wantErr error
+	}{
+		{
+			name:    "Not enabled",
+			code:    testCode(t, now),
+			user:    &gorsk.User{},
+			wantErr: auth.ErrMFANotEnrolled,
+		},
+		{
+			name:    "Required for role",
+			code:    testCode(t, now),
+			user:    &gorsk.User{TOTPSecret: testSecret, MFAEnabled: true, Role: &gorsk.Role{AccessLevel: gorsk.AdminRole}},
+			wantErr: auth.ErrMFARequired,
+		},
+		{
+			name:    "Wrong code",
+			code:    "000000",
+			user:    &gorsk.User{TOTPSecret: testSecret, MFAEnabled: true},
+			wantErr: auth.ErrInvalidMFACode,
+		},
+		{
+			name: "Success",
+			code: testCode(t, now),
+			user: &gorsk.User{TOTPSecret: testSecret, MFAEnabled: true, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			var cleared bool
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return tt.user, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			mdb := &mockdb.MFA{
+				UseRecoveryCodeFn: func(orm.DB, int, string) error {
+					return pg.ErrNoRows
+				},
+				ReplaceRecoveryCodesFn: func(db orm.DB, userID int, hashes []string) error {
+					cleared = len(hashes) == 0
+					return nil
+				},
+			}
+			rbac := &mock.RBAC{
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1}
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, nil, mdb, nil, nil, nil, mfaSecure(), rbac, mfaConfig())
+			err := s.DisableTOTP(context.Background(), tt.code)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.False(t, updated.MFAEnabled)
+				assert.Empty(t, updated.TOTPSecret)
+				assert.True(t, cleared)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
+)
+
+// Custom errors
+var (
+	ErrInsecurePassword = echo.NewHTTPError(http.StatusBadRequest, "insecure password")
+	ErrBreachedPassword = echo.NewHTTPError(http.StatusBadRequest, "password found in breach")
+	ErrPasswordReused   = echo.NewHTTPError(http.StatusBadRequest, "password was used recently")
+)
+
+// ChangeExpiredPassword completes login of user whose password has expired, by replacing it with a new one.
+// The challenge is kept until a password satisfying the password policy is set.
+func (a *Auth) ChangeExpiredPassword(ctx context.Context, challengeToken, newPass string) (*gorsk.AuthToken, error) {
+	db := a.db.WithContext(ctx)
+	ch, u, err := a.findChallenge(db, challengeToken, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !a.sec.Password(newPass, u.FirstName, u.LastName, u.Username, u.Email) {
+		return nil, ErrInsecurePassword
+	}
+	breached, err := a.sec.Breached(newPass)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		return nil, ErrBreachedPassword
+	}
+	// Expired password can't be kept, even if password history is disabled
+	if match, _ := a.sec.VerifyPassword(u.Password, newPass); match {
+		return nil, ErrPasswordReused
+	}
+	reused, err := a.hist.Reused(ctx, u, newPass)
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrPasswordReused
+	}
+
+	hash, err := a.sec.Hash(newPass)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.mdb.DeleteChallenge(db, ch); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrInvalidChallenge
+		}
+		return nil, err
+	}
+
+	if err := a.hist.Add(ctx, db, u); err != nil {
+		return nil, err
+	}
+	u.ChangePassword(hash)
+
+	return a.login(ctx, u)
+}
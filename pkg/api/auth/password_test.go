@@ -0,0 +1,149 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/auth"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeExpiredPassword(t *testing.T) {
+	now := mock.TestTime(2018)
+	passwordChallenge := func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+		return &gorsk.LoginChallenge{ID: 2, UserID: 1, ExpiresAt: now.Add(time.Minute), PasswordChange: true}, nil
+	}
+	sec := mfaSecure()
+	sec.PasswordFn = func(pass string, _ ...string) bool {
+		return pass != "insecure"
+	}
+	sec.BreachedFn = func(pass string) (bool, error) {
+		return pass == "breached", nil
+	}
+	sec.VerifyPasswordFn = func(hash, pass string) (bool, bool) {
+		return hash == "hash:"+pass, false
+	}
+	sec.HashMatchesPasswordFn = func(hash, pass string) bool {
+		return hash == "hash:"+pass
+	}
+	sec.HashFn = func(pass string) (string, error) {
+		return "hash:" + pass, nil
+	}
+	cases := []struct {
+		name        string
+		pass        string
+		mdb         *mockdb.MFA
+		wantErr     error
+		wantDeleted bool
+	}{
+		{
+			name: "MFA challenge",
+			pass: "newpassword",
+			mdb: &mockdb.MFA{
+				FindChallengeFn: func(orm.DB, string) (*gorsk.LoginChallenge, error) {
+					return &gorsk.LoginChallenge{ID: 2, UserID: 1, ExpiresAt: now.Add(time.Minute)}, nil
+				},
+			},
+			wantErr: auth.ErrInvalidChallenge,
+		},
+		{
+			name:    "Insecure password",
+			pass:    "insecure",
+			mdb:     &mockdb.MFA{FindChallengeFn: passwordChallenge},
+			wantErr: auth.ErrInsecurePassword,
+		},
+		{
+			name:    "Breached password",
+			pass:    "breached",
+			mdb:     &mockdb.MFA{FindChallengeFn: passwordChallenge},
+			wantErr: auth.ErrBreachedPassword,
+		},
+		{
+			name:    "Current password",
+			pass:    "current",
+			mdb:     &mockdb.MFA{FindChallengeFn: passwordChallenge},
+			wantErr: auth.ErrPasswordReused,
+		},
+		{
+			name:    "Previous password",
+			pass:    "previous",
+			mdb:     &mockdb.MFA{FindChallengeFn: passwordChallenge},
+			wantErr: auth.ErrPasswordReused,
+		},
+		{
+			name: "Challenge used concurrently",
+			pass: "newpassword",
+			mdb: &mockdb.MFA{
+				FindChallengeFn: passwordChallenge,
+				DeleteChallengeFn: func(orm.DB, *gorsk.LoginChallenge) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr: auth.ErrInvalidChallenge,
+		},
+		{
+			name:        "Success",
+			pass:        "newpassword",
+			mdb:         &mockdb.MFA{FindChallengeFn: passwordChallenge},
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted bool
+			var updated *gorsk.User
+			if tt.mdb.DeleteChallengeFn == nil {
+				tt.mdb.DeleteChallengeFn = func(db orm.DB, ch *gorsk.LoginChallenge) error {
+					deleted = ch.ID == 2
+					return nil
+				}
+			}
+			hist := pwhistory.New(pwhistory.NewMemory(), 3, sec.HashMatchesPassword)
+			if err := hist.Add(context.Background(), nil, &gorsk.User{Base: gorsk.Base{ID: 1}, Password: "hash:previous"}); err != nil {
+				t.Fatal(err)
+			}
+			udb := &mockdb.User{
+				ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: id}, Active: true, Password: "hash:current", PasswordChangeRequired: true}, nil
+				},
+				UpdateFn: func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				},
+			}
+			sdb := &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+					return &s, nil
+				},
+			}
+			jwt := &mock.JWT{
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+					return "jwttokenstring", now.Format(time.RFC3339), nil
+				},
+			}
+			s := auth.New(mockdb.DB{}, udb, sdb, tt.mdb, nil, hist, jwt, sec, nil, mfaConfig())
+			token, err := s.ChangeExpiredPassword(context.Background(), "challengetoken", tt.pass)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantDeleted, deleted)
+			if tt.wantErr != nil {
+				assert.Nil(t, updated)
+				return
+			}
+			assert.Equal(t, "jwttokenstring", token.Token)
+			assert.Equal(t, "hash:newpassword", updated.Password)
+			assert.False(t, updated.PasswordChangeRequired)
+			reused, err := hist.Reused(context.Background(), updated, "current")
+			assert.Nil(t, err)
+			assert.True(t, reused, "replaced password is kept in history")
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewMFA returns a new MFA database instance
+func NewMFA() *MFA {
+	return &MFA{}
+}
+
+// MFA represents the client for login_challenges and recovery_codes tables
+type MFA struct{}
+
+// CreateChallenge creates a new login challenge on database
+func (m *MFA) CreateChallenge(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+	ch.CreatedAt = time.Now()
+	if err := db.Insert(&ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// FindChallenge queries for single login challenge by token hash
+func (m *MFA) FindChallenge(db orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+	var ch = new(gorsk.LoginChallenge)
+	if err := db.Model(ch).Where("token_hash = ?", hash).Select(); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// UpdateChallenge updates login challenge's info
+func (m *MFA) UpdateChallenge(db orm.DB, ch *gorsk.LoginChallenge) error {
+	return db.Update(ch)
+}
+
+// DeleteChallenge deletes a login challenge.
+// Returns pg.ErrNoRows if the challenge was already deleted.
+func (m *MFA) DeleteChallenge(db orm.DB, ch *gorsk.LoginChallenge) error {
+	res, err := db.Model(ch).WherePK().Delete()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes deletes user's recovery codes and stores the given code hashes instead
+func (m *MFA) ReplaceRecoveryCodes(db orm.DB, userID int, hashes []string) error {
+	if _, err := db.Model((*gorsk.RecoveryCode)(nil)).Where("user_id = ?", userID).Delete(); err != nil {
+		return err
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	codes := make([]gorsk.RecoveryCode, len(hashes))
+	for i, h := range hashes {
+		codes[i] = gorsk.RecoveryCode{UserID: userID, CodeHash: h, CreatedAt: now}
+	}
+	return db.Insert(&codes)
+}
+
+// UseRecoveryCode marks user's recovery code as used.
+// Returns pg.ErrNoRows if there is no such unused code.
+func (m *MFA) UseRecoveryCode(db orm.DB, userID int, hash string) error {
+	res, err := db.Model((*gorsk.RecoveryCode)(nil)).Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).Where("code_hash = ?", hash).Where("used_at is null").Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
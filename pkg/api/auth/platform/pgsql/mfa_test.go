@@ -0,0 +1,62 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/auth/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMFA(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.LoginChallenge{}, &gorsk.RecoveryCode{})
+
+	mdb := pgsql.NewMFA()
+
+	t.Run("Challenge", func(t *testing.T) {
+		ch, err := mdb.CreateChallenge(db, gorsk.LoginChallenge{
+			UserID:    1,
+			TokenHash: "challengehash",
+			ExpiresAt: mock.TestTime(2018),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := mdb.FindChallenge(db, "challengehash")
+		assert.Nil(t, err)
+		assert.Equal(t, ch.ID, found.ID)
+		assert.Equal(t, 1, found.UserID)
+
+		found.Attempts = 2
+		assert.Nil(t, mdb.UpdateChallenge(db, found))
+		found, err = mdb.FindChallenge(db, "challengehash")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, found.Attempts)
+
+		assert.Nil(t, mdb.DeleteChallenge(db, found))
+		assert.Equal(t, pg.ErrNoRows, mdb.DeleteChallenge(db, found))
+
+		_, err = mdb.FindChallenge(db, "challengehash")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Recovery codes", func(t *testing.T) {
+		assert.Nil(t, mdb.ReplaceRecoveryCodes(db, 1, []string{"first", "second"}))
+		assert.Nil(t, mdb.ReplaceRecoveryCodes(db, 2, []string{"first"}))
+
+		assert.Nil(t, mdb.UseRecoveryCode(db, 1, "first"))
+		assert.Equal(t, pg.ErrNoRows, mdb.UseRecoveryCode(db, 1, "first"))
+		assert.Equal(t, pg.ErrNoRows, mdb.UseRecoveryCode(db, 1, "unknown"))
+		assert.Nil(t, mdb.UseRecoveryCode(db, 2, "first"))
+
+		assert.Nil(t, mdb.ReplaceRecoveryCodes(db, 1, nil))
+		assert.Equal(t, pg.ErrNoRows, mdb.UseRecoveryCode(db, 1, "second"))
+	})
+}
@@ -0,0 +1,85 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewSession returns a new session database instance
+func NewSession() *Session {
+	return &Session{}
+}
+
+// Session represents the client for sessions table
+type Session struct{}
+
+// Create creates a new session on database
+func (s *Session) Create(db orm.DB, sess gorsk.Session) (*gorsk.Session, error) {
+	if err := db.Insert(&sess); err != nil {
+		return nil, err
+	}
+	return &sess, nil
+}
+
+// View returns single session by ID
+func (s *Session) View(db orm.DB, id int) (*gorsk.Session, error) {
+	var sess = &gorsk.Session{Base: gorsk.Base{ID: id}}
+	if err := db.Model(sess).WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// FindByToken queries for single session by refresh token hash
+func (s *Session) FindByToken(db orm.DB, hash string) (*gorsk.Session, error) {
+	var sess = new(gorsk.Session)
+	if err := db.Model(sess).Where("token_hash = ?", hash).Select(); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// List returns list of user's sessions, most recently used first
+func (s *Session) List(db orm.DB, userID int) ([]gorsk.Session, error) {
+	var sessions []gorsk.Session
+	err := db.Model(&sessions).Where("user_id = ?", userID).Order("last_used desc").Select()
+	return sessions, err
+}
+
+// Rotate replaces session's refresh token, retiring the old one.
+// Returns pg.ErrNoRows if the old token is no longer current.
+func (s *Session) Rotate(db orm.DB, sess *gorsk.Session, oldHash string) error {
+	res, err := db.Model(sess).Column("token_hash", "last_used", "ip", "updated_at").
+		WherePK().Where("token_hash = ?", oldHash).Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return db.Insert(&gorsk.RetiredToken{TokenHash: oldHash, SessionID: sess.ID, RetiredAt: time.Now()})
+}
+
+// FindRetired returns ID of the session retired refresh token belonged to
+func (s *Session) FindRetired(db orm.DB, hash string) (int, error) {
+	var rt = &gorsk.RetiredToken{TokenHash: hash}
+	if err := db.Select(rt); err != nil {
+		return 0, err
+	}
+	return rt.SessionID, nil
+}
+
+// Delete terminates a session
+func (s *Session) Delete(db orm.DB, sess *gorsk.Session) error {
+	return db.Delete(sess)
+}
+
+// DeleteByUser terminates all user's sessions
+func (s *Session) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model(&gorsk.Session{}).Set("deleted_at = ?", time.Now()).
+		Where("user_id = ?", userID).Where("deleted_at is null").Update()
+	return err
+}
@@ -0,0 +1,101 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/auth/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{}, &gorsk.RetiredToken{})
+
+	sdb := pgsql.NewSession()
+
+	laptop, err := sdb.Create(db, gorsk.Session{
+		UserID:    1,
+		TokenHash: "laptophash",
+		UserAgent: "laptop",
+		IP:        "10.0.0.1",
+		LastUsed:  mock.TestTime(2018),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := sdb.Create(db, gorsk.Session{
+		UserID:    1,
+		TokenHash: "phonehash",
+		UserAgent: "phone",
+		IP:        "10.0.0.2",
+		LastUsed:  mock.TestTime(2019),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sdb.Create(db, gorsk.Session{UserID: 2, TokenHash: "otherhash"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Find by token", func(t *testing.T) {
+		s, err := sdb.FindByToken(db, "laptophash")
+		assert.Nil(t, err)
+		assert.Equal(t, laptop.ID, s.ID)
+		assert.Equal(t, "laptop", s.UserAgent)
+
+		_, err = sdb.FindByToken(db, "unknown")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		sessions, err := sdb.List(db, 1)
+		assert.Nil(t, err)
+		if assert.Len(t, sessions, 2) {
+			assert.Equal(t, phone.ID, sessions[0].ID)
+			assert.Equal(t, laptop.ID, sessions[1].ID)
+		}
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		s, err := sdb.View(db, laptop.ID)
+		assert.Nil(t, err)
+		s.TokenHash = "newlaptophash"
+		s.UpdateLastUsed("10.0.0.3")
+		assert.Nil(t, sdb.Rotate(db, s, "laptophash"))
+
+		s, err = sdb.FindByToken(db, "newlaptophash")
+		assert.Nil(t, err)
+		assert.Equal(t, "10.0.0.3", s.IP)
+
+		_, err = sdb.FindByToken(db, "laptophash")
+		assert.Equal(t, pg.ErrNoRows, err)
+
+		sessionID, err := sdb.FindRetired(db, "laptophash")
+		assert.Nil(t, err)
+		assert.Equal(t, laptop.ID, sessionID)
+
+		s.TokenHash = "otherlaptophash"
+		assert.Equal(t, pg.ErrNoRows, sdb.Rotate(db, s, "laptophash"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Nil(t, sdb.Delete(db, phone))
+		_, err := sdb.FindByToken(db, "phonehash")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Delete by user", func(t *testing.T) {
+		assert.Nil(t, sdb.DeleteByUser(db, 1))
+		sessions, err := sdb.List(db, 1)
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 0)
+		_, err = sdb.FindByToken(db, "otherhash")
+		assert.Nil(t, err)
+	})
+}
@@ -39,18 +39,6 @@ func (u *User) FindByUsername(db orm.DB, uname string) (*gorsk.User, error) {
 	return user, nil
 }
 
-// FindByToken queries for single user by token
-func (u *User) FindByToken(db orm.DB, token string) (*gorsk.User, error) {
-	var user = new(gorsk.User)
-	sql := `SELECT "user".*, "role"."id" AS "role__id", "role"."access_level" AS "role__access_level", "role"."name" AS "role__name" 
-	FROM "users" AS "user" LEFT JOIN "roles" AS "role" ON "role"."id" = "user"."role_id" 
-	WHERE ("user"."token" = ? and deleted_at is null)`
-	_, err := db.QueryOne(user, sql, token)
-	if err != nil {
-	}
-	return user, err
-}
-
 // Update updates user's info
 func (u *User) Update(db orm.DB, user *gorsk.User) error {
 	return db.Update(user)
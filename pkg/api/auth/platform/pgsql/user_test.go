@@ -144,72 +144,6 @@ func TestFindByUsername(t *testing.T) {
 	}
 }
 
-func TestFindByToken(t *testing.T) {
-	cases := []struct {
-		name     string
-		wantErr  bool
-		token    string
-		wantData *gorsk.User
-	}{
-		{
-			name:    "User does not exist",
-			wantErr: true,
-			token:   "notExists",
-		},
-		{
-			name:  "Success",
-			token: "loginrefresh",
-			wantData: &gorsk.User{
-				Email:      "johndoe@mail.com",
-				FirstName:  "John",
-				LastName:   "Doe",
-				Username:   "johndoe",
-				RoleID:     1,
-				CompanyID:  1,
-				LocationID: 1,
-				Password:   "hunter2",
-				Base: gorsk.Base{
-					ID: 1,
-				},
-				Role: &gorsk.Role{
-					ID:          1,
-					AccessLevel: 1,
-					Name:        "SUPER_ADMIN",
-				},
-				Token: "loginrefresh",
-			},
-		},
-	}
-
-	dbCon := mock.NewPGContainer(t)
-	defer dbCon.Shutdown()
-
-	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
-
-	if err := mock.InsertMultiple(db, &gorsk.Role{
-		ID:          1,
-		AccessLevel: 1,
-		Name:        "SUPER_ADMIN"}, cases[1].wantData); err != nil {
-		t.Error(err)
-	}
-
-	udb := pgsql.NewUser()
-
-	for _, tt := range cases {
-		t.Run(tt.name, func(t *testing.T) {
-			user, err := udb.FindByToken(db, tt.token)
-			assert.Equal(t, tt.wantErr, err != nil)
-
-			if tt.wantData != nil {
-				tt.wantData.CreatedAt = user.CreatedAt
-				tt.wantData.UpdatedAt = user.UpdatedAt
-				assert.Equal(t, tt.wantData, user)
-
-			}
-		})
-	}
-}
-
 func TestUpdate(t *testing.T) {
 	cases := []struct {
 		name     string
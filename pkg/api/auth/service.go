@@ -1,65 +1,172 @@
 package auth
 
 import (
+	"context"
+	"time"
+
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
-	"github.com/labstack/echo"
 	"github.com/figassis/goduck/pkg/api/auth/platform/pgsql"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/figassis/goduck/pkg/utl/throttle"
 )
 
+// Config represents session lifetime, MFA, login throttling and login requirements configuration
+type Config struct {
+	// RefreshDuration is the time after which an unused session expires, it must be positive
+	RefreshDuration time.Duration
+	// MaxRefresh is the absolute session lifetime, counted from login, it must not be shorter than RefreshDuration
+	MaxRefresh time.Duration
+	// MFAIssuer is shown in authenticator apps next to the account name
+	MFAIssuer string
+	// MFARequiredRole requires MFA for users with this access role or a more privileged one, 0 disables the requirement
+	MFARequiredRole gorsk.AccessRole
+	// MaxFailures locks username after this many failed logins, 0 disables the lockout
+	MaxFailures int
+	// MaxIPFailures refuses logins from client IP after this many failed logins, 0 disables the lockout
+	MaxIPFailures int
+	// Lockout is how long logins are refused, failures older than it are forgotten.
+	// Defaults to DefaultLockout.
+	Lockout time.Duration
+	// Delay is the wait required after the first failed login for a username, doubled with each further failure.
+	// 0 disables the delay.
+	Delay time.Duration
+	// PasswordMaxAge requires users to change older passwords on login, 0 disables the expiry
+	PasswordMaxAge time.Duration
+	// RequireVerifiedEmail refuses logins of users who haven't verified their email
+	RequireVerifiedEmail bool
+	// Clock returns current time, defaults to time.Now
+	Clock func() time.Time
+}
+
+// DefaultLockout is used when lockout duration is not configured
+const DefaultLockout = 15 * time.Minute
+
 // New creates new iam service
-func New(db *pg.DB, udb UserDB, j TokenGenerator, sec Securer, rbac RBAC) *Auth {
+func New(db postgres.DB, udb UserDB, sdb SessionDB, mdb MFADB, lim Limiter, hist History, j TokenGenerator, sec Securer, rbac RBAC, cfg *Config) *Auth {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.Lockout == 0 {
+		cfg.Lockout = DefaultLockout
+	}
 	return &Auth{
 		db:   db,
 		udb:  udb,
+		sdb:  sdb,
+		mdb:  mdb,
+		lim:  lim,
+		hist: hist,
 		tg:   j,
 		sec:  sec,
 		rbac: rbac,
+		cfg:  cfg,
 	}
 }
 
 // Initialize initializes auth application service
-func Initialize(db *pg.DB, j TokenGenerator, sec Securer, rbac RBAC) *Auth {
-	return New(db, pgsql.NewUser(), j, sec, rbac)
+func Initialize(db *pg.DB, hist History, j TokenGenerator, sec Securer, rbac RBAC, cfg *Config) *Auth {
+	return New(postgres.Wrap(db), pgsql.NewUser(), pgsql.NewSession(), pgsql.NewMFA(), throttle.NewPG(db), hist, j, sec, rbac, cfg)
 }
 
 // Service represents auth service interface
 type Service interface {
-	Authenticate(echo.Context, string, string) (*gorsk.AuthToken, error)
-	Refresh(echo.Context, string) (*gorsk.RefreshToken, error)
-	Me(echo.Context) (*gorsk.User, error)
+	Authenticate(context.Context, string, string) (*gorsk.AuthToken, *gorsk.MFAChallenge, error)
+	VerifyMFA(context.Context, string, string) (*gorsk.AuthToken, *gorsk.MFAChallenge, error)
+	ChangeExpiredPassword(context.Context, string, string) (*gorsk.AuthToken, error)
+	EnrollMFAChallenge(context.Context, string) (*gorsk.TOTPEnrollment, error)
+	Refresh(context.Context, string) (*gorsk.RefreshToken, error)
+	Me(context.Context) (*gorsk.User, error)
+	Logout(context.Context) error
+	RevokeAll(context.Context, int) error
+	Unlock(context.Context, int) error
+	Sessions(context.Context) ([]gorsk.Session, error)
+	DeleteSession(context.Context, int) error
+	EnrollTOTP(context.Context) (*gorsk.TOTPEnrollment, error)
+	ConfirmTOTP(context.Context, string) ([]string, error)
+	DisableTOTP(context.Context, string) error
+	JWKS(context.Context) *gorsk.JWKS
 }
 
 // Auth represents auth application service
 type Auth struct {
-	db   *pg.DB
+	db   postgres.DB
 	udb  UserDB
+	sdb  SessionDB
+	mdb  MFADB
+	lim  Limiter
+	hist History
 	tg   TokenGenerator
 	sec  Securer
 	rbac RBAC
+	cfg  *Config
 }
 
 // UserDB represents user repository interface
 type UserDB interface {
 	View(orm.DB, int) (*gorsk.User, error)
 	FindByUsername(orm.DB, string) (*gorsk.User, error)
-	FindByToken(orm.DB, string) (*gorsk.User, error)
 	Update(orm.DB, *gorsk.User) error
 }
 
+// SessionDB represents session repository interface
+type SessionDB interface {
+	Create(orm.DB, gorsk.Session) (*gorsk.Session, error)
+	View(orm.DB, int) (*gorsk.Session, error)
+	FindByToken(orm.DB, string) (*gorsk.Session, error)
+	List(orm.DB, int) ([]gorsk.Session, error)
+	Rotate(orm.DB, *gorsk.Session, string) error
+	FindRetired(orm.DB, string) (int, error)
+	Delete(orm.DB, *gorsk.Session) error
+	DeleteByUser(orm.DB, int) error
+}
+
+// MFADB represents login challenge and recovery code repository interface
+type MFADB interface {
+	CreateChallenge(orm.DB, gorsk.LoginChallenge) (*gorsk.LoginChallenge, error)
+	FindChallenge(orm.DB, string) (*gorsk.LoginChallenge, error)
+	UpdateChallenge(orm.DB, *gorsk.LoginChallenge) error
+	DeleteChallenge(orm.DB, *gorsk.LoginChallenge) error
+	ReplaceRecoveryCodes(orm.DB, int, []string) error
+	UseRecoveryCode(orm.DB, int, string) error
+}
+
+// Limiter represents failed login attempt store interface
+type Limiter interface {
+	Get(context.Context, string) (*gorsk.LoginAttempt, error)
+	Fail(context.Context, string, time.Time, time.Duration) (*gorsk.LoginAttempt, error)
+	Lock(context.Context, string, time.Time) error
+	Reset(context.Context, string) error
+}
+
+// History represents previous passwords interface
+type History interface {
+	Reused(context.Context, *gorsk.User, string) (bool, error)
+	Add(context.Context, orm.DB, *gorsk.User) error
+}
+
 // TokenGenerator represents token generator (jwt) interface
 type TokenGenerator interface {
-	GenerateToken(*gorsk.User) (string, string, error)
+	GenerateToken(*gorsk.User, int) (string, string, error)
+	Revoke(context.Context) error
+	RevokeUser(context.Context, int) error
+	JWKS() *gorsk.JWKS
 }
 
 // Securer represents security interface
 type Securer interface {
-	HashMatchesPassword(string, string) bool
-	Token(string) string
+	Hash(string) (string, error)
+	VerifyPassword(string, string) (bool, bool)
+	Password(string, ...string) bool
+	Breached(string) (bool, error)
+	RandomToken(string) (string, error)
+	TokenHash(string) string
 }
 
 // RBAC represents role-based-access-control interface
 type RBAC interface {
-	User(echo.Context) *gorsk.AuthUser
+	User(context.Context) *gorsk.AuthUser
+	Enforce(context.Context, gorsk.Permission) error
+	EnforceUser(context.Context, gorsk.Permission, int) error
 }
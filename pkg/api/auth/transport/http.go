@@ -2,8 +2,10 @@ package transport
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/figassis/goduck/pkg/api/auth"
+	"github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/labstack/echo"
 )
@@ -14,22 +16,72 @@ type HTTP struct {
 }
 
 // NewHTTP creates new auth http service
-func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
+func NewHTTP(svc auth.Service, e *echo.Echo, er *echo.Group, mw echo.MiddlewareFunc, require func(...gorsk.Permission) echo.MiddlewareFunc) {
 	h := HTTP{svc}
 	// swagger:route POST /login auth login
 	// Logs in user by username and password.
+	// If the user has to pass a second factor, MFA challenge is returned instead,
+	// to be completed with POST /login/mfa.
+	// If user's password has expired, password change challenge is returned instead,
+	// to be completed with POST /login/password.
+	// Repeated failures are throttled per username and client IP.
 	// responses:
 	//  200: loginResp
 	//  400: errMsg
 	//  401: errMsg
 	// 	403: err
 	//  404: errMsg
+	//  423: errMsg
+	//  429: errMsg
 	//  500: err
 	e.POST("/login", h.login)
-	// swagger:operation GET /refresh/{token} auth refresh
+
+	// swagger:route POST /login/mfa auth loginMFA
+	// Completes login by verifying TOTP or recovery code for the MFA challenge.
+	// Recovery codes are returned if MFA was enrolled during this login.
+	// If user's password has expired, password change challenge is returned instead of the token.
+	// responses:
+	//  200: loginResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/login/mfa", h.loginMFA)
+
+	// swagger:route POST /login/password auth loginPassword
+	// Completes login by replacing expired password, for the password change challenge.
+	// The new password has to satisfy the password policy, and can't be one of the recent passwords.
+	// responses:
+	//  200: loginResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/login/password", h.loginPassword)
+
+	// swagger:route POST /login/mfa/enroll auth loginMFAEnroll
+	// Starts TOTP enrollment for users required to use MFA, that have not enabled it yet.
+	// responses:
+	//  200: totpEnrollResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/login/mfa/enroll", h.loginMFAEnroll)
+
+	// swagger:route POST /refresh auth refresh
+	// Refreshes jwt token.
+	// Rotates the refresh token, the returned refresh token replaces the one sent.
+	// Reusing a rotated refresh token terminates the whole session.
+	// responses:
+	//  200: refreshResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	e.POST("/refresh", h.refreshBody)
+
+	// swagger:operation GET /refresh/{token} auth refreshPath
 	// ---
 	// summary: Refreshes jwt token.
-	// description: Refreshes jwt token by checking at database whether refresh token exists.
+	// description: Deprecated, use POST /refresh. Sending the token in URL path exposes it in access logs.
+	// deprecated: true
 	// parameters:
 	// - name: token
 	//   in: path
@@ -47,12 +99,134 @@ func NewHTTP(svc auth.Service, e *echo.Echo, mw echo.MiddlewareFunc) {
 	//     "$ref": "#/responses/err"
 	e.GET("/refresh/:token", h.refresh)
 
+	// swagger:route GET /.well-known/jwks.json auth jwks
+	// Returns public keys used for verifying access tokens, as JSON Web Key Set.
+	// Tokens select the key by kid header.
+	// responses:
+	//  200: jwksResp
+	e.GET("/.well-known/jwks.json", h.jwks)
+
 	// swagger:route GET /me auth meReq
 	// Gets user's info from session.
 	// responses:
 	//  200: userResp
 	//  500: err
 	e.GET("/me", h.me, mw)
+
+	// swagger:route POST /logout auth logout
+	// Invalidates refresh token and revokes access token used for the request.
+	// responses:
+	//  200: ok
+	//  401: err
+	//  500: err
+	e.POST("/logout", h.logout, mw)
+
+	// swagger:operation DELETE /v1/users/{id}/sessions auth revokeSessions
+	// ---
+	// summary: Revokes all sessions of a user.
+	// description: Invalidates user's refresh token and revokes all access tokens issued to the user.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.DELETE("/users/:id/sessions", h.revokeAll, require(gorsk.PermSessionsRevoke))
+
+	// swagger:operation DELETE /v1/users/{id}/lockout auth unlockUser
+	// ---
+	// summary: Unlocks user's account.
+	// description: Lifts the temporary lockout caused by failed login attempts.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.DELETE("/users/:id/lockout", h.unlock, require(gorsk.PermUsersUnlock))
+
+	// swagger:route GET /v1/me/sessions auth sessionsReq
+	// Lists currently logged in user's sessions.
+	// responses:
+	//  200: sessionsResp
+	//  401: err
+	//  500: err
+	er.GET("/me/sessions", h.sessions)
+
+	// swagger:operation DELETE /v1/me/sessions/{id} auth deleteSession
+	// ---
+	// summary: Terminates one of currently logged in user's sessions.
+	// description: Session's refresh token stops working. Access tokens already issued for it stay valid until they expire.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of session
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.DELETE("/me/sessions/:id", h.deleteSession)
+
+	// swagger:route POST /v1/me/mfa/totp auth enrollTOTP
+	// Starts TOTP enrollment for currently logged in user.
+	// Returns the secret and provisioning URI for authenticator apps.
+	// responses:
+	//  200: totpEnrollResp
+	//  400: errMsg
+	//  401: err
+	//  500: err
+	er.POST("/me/mfa/totp", h.enrollTOTP)
+
+	// swagger:route POST /v1/me/mfa/totp/verify auth confirmTOTP
+	// Enables MFA by verifying the first TOTP code.
+	// Returns recovery codes, which are not shown again.
+	// responses:
+	//  200: recoveryCodesResp
+	//  400: errMsg
+	//  401: errMsg
+	//  500: err
+	er.POST("/me/mfa/totp/verify", h.confirmTOTP)
+
+	// swagger:route DELETE /v1/me/mfa/totp auth disableTOTP
+	// Disables MFA, after verifying TOTP or recovery code.
+	// responses:
+	//  200: ok
+	//  400: errMsg
+	//  401: errMsg
+	//  403: errMsg
+	//  500: err
+	er.DELETE("/me/mfa/totp", h.disableTOTP)
 }
 
 type credentials struct {
@@ -65,7 +239,63 @@ func (h *HTTP) login(c echo.Context) error {
 	if err := c.Bind(cred); err != nil {
 		return err
 	}
-	r, err := h.svc.Authenticate(c, cred.Username, cred.Password)
+	r, ch, err := h.svc.Authenticate(c.Request().Context(), cred.Username, cred.Password)
+	if err != nil {
+		return err
+	}
+	if ch != nil {
+		return c.JSON(http.StatusOK, ch)
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+type mfaReq struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+func (h *HTTP) loginMFA(c echo.Context) error {
+	req := new(mfaReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	r, ch, err := h.svc.VerifyMFA(c.Request().Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		return err
+	}
+	if ch != nil {
+		return c.JSON(http.StatusOK, ch)
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+type passwordChangeReq struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Password       string `json:"password" validate:"required"`
+}
+
+func (h *HTTP) loginPassword(c echo.Context) error {
+	req := new(passwordChangeReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	r, err := h.svc.ChangeExpiredPassword(c.Request().Context(), req.ChallengeToken, req.Password)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+type mfaEnrollReq struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+func (h *HTTP) loginMFAEnroll(c echo.Context) error {
+	req := new(mfaEnrollReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	r, err := h.svc.EnrollMFAChallenge(c.Request().Context(), req.ChallengeToken)
 	if err != nil {
 		return err
 	}
@@ -73,17 +303,128 @@ func (h *HTTP) login(c echo.Context) error {
 }
 
 func (h *HTTP) refresh(c echo.Context) error {
-	r, err := h.svc.Refresh(c, c.Param("token"))
+	r, err := h.svc.Refresh(c.Request().Context(), c.Param("token"))
 	if err != nil {
 		return err
 	}
 	return c.JSON(http.StatusOK, r)
 }
 
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (h *HTTP) refreshBody(c echo.Context) error {
+	req := new(refreshReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	r, err := h.svc.Refresh(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+func (h *HTTP) jwks(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.svc.JWKS(c.Request().Context()))
+}
+
 func (h *HTTP) me(c echo.Context) error {
-	user, err := h.svc.Me(c)
+	user, err := h.svc.Me(c.Request().Context())
 	if err != nil {
 		return err
 	}
 	return c.JSON(http.StatusOK, user)
 }
+
+func (h *HTTP) logout(c echo.Context) error {
+	if err := h.svc.Logout(c.Request().Context()); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *HTTP) revokeAll(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+	if err := h.svc.RevokeAll(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *HTTP) unlock(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+	if err := h.svc.Unlock(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+type sessionsResp struct {
+	Sessions []gorsk.Session `json:"sessions"`
+}
+
+func (h *HTTP) sessions(c echo.Context) error {
+	sessions, err := h.svc.Sessions(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, sessionsResp{sessions})
+}
+
+func (h *HTTP) deleteSession(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+	if err := h.svc.DeleteSession(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *HTTP) enrollTOTP(c echo.Context) error {
+	r, err := h.svc.EnrollTOTP(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, r)
+}
+
+type totpCodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type recoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *HTTP) confirmTOTP(c echo.Context) error {
+	req := new(totpCodeReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	codes, err := h.svc.ConfirmTOTP(c.Request().Context(), req.Code)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, recoveryCodesResp{codes})
+}
+
+func (h *HTTP) disableTOTP(c echo.Context) error {
+	req := new(totpCodeReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+	if err := h.svc.DisableTOTP(c.Request().Context(), req.Code); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package transport_test
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
 	"net/http"
@@ -16,8 +17,12 @@ import (
 	"github.com/figassis/goduck/pkg/utl/mock"
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
 	"github.com/figassis/goduck/pkg/utl/server"
+	"github.com/figassis/goduck/pkg/utl/throttle"
+	"github.com/figassis/goduck/pkg/utl/totp"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,7 +33,9 @@ func TestLogin(t *testing.T) {
 		req        string
 		wantStatus int
 		wantResp   *gorsk.AuthToken
+		wantMFA    *gorsk.MFAChallenge
 		udb        *mockdb.User
+		mdb        *mockdb.MFA
 		jwt        *mock.JWT
 		sec        *mock.Secure
 	}{
@@ -63,26 +70,66 @@ func TestLogin(t *testing.T) {
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(*gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
 					return "jwttokenstring", mock.TestTime(2018).Format(time.RFC3339), nil
 				},
 			},
 			sec: &mock.Secure{
-				HashMatchesPasswordFn: func(string, string) bool {
-					return true
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
 				},
-				TokenFn: func(string) string {
-					return "refreshtoken"
+				RandomTokenFn: func(string) (string, error) {
+					return "refreshtoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
 			wantResp: &gorsk.AuthToken{Token: "jwttokenstring", Expires: mock.TestTime(2018).Format(time.RFC3339), RefreshToken: "refreshtoken"},
 		},
+		{
+			name:       "MFA challenge",
+			req:        `{"username":"juzernejm","password":"hunter123"}`,
+			wantStatus: http.StatusOK,
+			udb: &mockdb.User{
+				FindByUsernameFn: func(orm.DB, string) (*gorsk.User, error) {
+					return &gorsk.User{
+						Password:   "hunter123",
+						Active:     true,
+						MFAEnabled: true,
+					}, nil
+				},
+			},
+			mdb: &mockdb.MFA{
+				CreateChallengeFn: func(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+					return &ch, nil
+				},
+			},
+			sec: &mock.Secure{
+				VerifyPasswordFn: func(string, string) (bool, bool) {
+					return true, false
+				},
+				RandomTokenFn: func(string) (string, error) {
+					return "challengetoken", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantMFA: &gorsk.MFAChallenge{MFARequired: true, ChallengeToken: "challengetoken", Expires: mock.TestTime(2018).Add(5 * time.Minute).Format(time.RFC3339)},
+		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, tt.jwt, tt.sec, nil), r, nil)
+			sdb := &mockdb.Session{
+				CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+					return &s, nil
+				},
+			}
+			cfg := &auth.Config{Clock: func() time.Time { return mock.TestTime(2018) }}
+			transport.NewHTTP(auth.New(mockdb.DB{}, tt.udb, sdb, tt.mdb, nil, nil, tt.jwt, tt.sec, nil, cfg), r, r.Group("/v1"), nil, mock.RequirePermission)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/login"
@@ -99,6 +146,13 @@ func TestLogin(t *testing.T) {
 				tt.wantResp.RefreshToken = response.RefreshToken
 				assert.Equal(t, tt.wantResp, response)
 			}
+			if tt.wantMFA != nil {
+				response := new(gorsk.MFAChallenge)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantMFA, response)
+			}
 			assert.Equal(t, tt.wantStatus, res.StatusCode)
 		})
 	}
@@ -107,28 +161,89 @@ func TestLogin(t *testing.T) {
 func TestRefresh(t *testing.T) {
 	cases := []struct {
 		name       string
+		method     string
+		path       string
 		req        string
 		wantStatus int
 		wantResp   *gorsk.RefreshToken
 		udb        *mockdb.User
+		sdb        *mockdb.Session
 		jwt        *mock.JWT
 	}{
 		{
 			name:       "Fail on FindByToken",
-			req:        "refreshtoken",
+			method:     "POST",
+			req:        `{"refresh_token":"refreshtoken"}`,
 			wantStatus: http.StatusInternalServerError,
-			udb: &mockdb.User{
-				FindByTokenFn: func(orm.DB, string) (*gorsk.User, error) {
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.Session, error) {
 					return nil, gorsk.ErrGeneric
 				},
 			},
 		},
+		{
+			name:       "Invalid request",
+			method:     "POST",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown token",
+			method:     "POST",
+			req:        `{"refresh_token":"refreshtoken"}`,
+			wantStatus: http.StatusUnauthorized,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.Session, error) {
+					return nil, pg.ErrNoRows
+				},
+				FindRetiredFn: func(orm.DB, string) (int, error) {
+					return 0, pg.ErrNoRows
+				},
+			},
+		},
 		{
 			name:       "Success",
-			req:        "refreshtoken",
+			method:     "POST",
+			req:        `{"refresh_token":"refreshtoken"}`,
+			wantStatus: http.StatusOK,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: 1}, UserID: 1}, nil
+				},
+				RotateFn: func(orm.DB, *gorsk.Session, string) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{
+						Username: "johndoe",
+						Active:   true,
+					}, nil
+				},
+			},
+			jwt: &mock.JWT{
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+					return "jwttokenstring", mock.TestTime(2018).Format(time.RFC3339), nil
+				},
+			},
+			wantResp: &gorsk.RefreshToken{Token: "jwttokenstring", Expires: mock.TestTime(2018).Format(time.RFC3339), RefreshToken: "newrefreshtoken"},
+		},
+		{
+			name:       "Success with token in path",
+			method:     "GET",
+			path:       "/refreshtoken",
 			wantStatus: http.StatusOK,
+			sdb: &mockdb.Session{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: 1}, UserID: 1}, nil
+				},
+				RotateFn: func(orm.DB, *gorsk.Session, string) error {
+					return nil
+				},
+			},
 			udb: &mockdb.User{
-				FindByTokenFn: func(orm.DB, string) (*gorsk.User, error) {
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
 					return &gorsk.User{
 						Username: "johndoe",
 						Active:   true,
@@ -136,22 +251,36 @@ func TestRefresh(t *testing.T) {
 				},
 			},
 			jwt: &mock.JWT{
-				GenerateTokenFn: func(*gorsk.User) (string, string, error) {
+				GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
 					return "jwttokenstring", mock.TestTime(2018).Format(time.RFC3339), nil
 				},
 			},
-			wantResp: &gorsk.RefreshToken{Token: "jwttokenstring", Expires: mock.TestTime(2018).Format(time.RFC3339)},
+			wantResp: &gorsk.RefreshToken{Token: "jwttokenstring", Expires: mock.TestTime(2018).Format(time.RFC3339), RefreshToken: "newrefreshtoken"},
+		},
+	}
+
+	client := &http.Client{}
+	sec := &mock.Secure{
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+		RandomTokenFn: func(string) (string, error) {
+			return "newrefreshtoken", nil
 		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, tt.jwt, nil, nil), r, nil)
+			transport.NewHTTP(auth.New(mockdb.DB{}, tt.udb, tt.sdb, nil, nil, nil, tt.jwt, sec, nil, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
-			path := ts.URL + "/refresh/" + tt.req
-			res, err := http.Get(path)
+			req, err := http.NewRequest(tt.method, ts.URL+"/refresh"+tt.path, bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -186,7 +315,7 @@ func TestMe(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				UserFn: func(echo.Context) *gorsk.AuthUser {
+				UserFn: func(context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{ID: 1}
 				},
 			},
@@ -210,7 +339,7 @@ func TestMe(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				UserFn: func(echo.Context) *gorsk.AuthUser {
+				UserFn: func(context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{ID: 1}
 				},
 			},
@@ -229,12 +358,12 @@ func TestMe(t *testing.T) {
 	}
 
 	client := &http.Client{}
-	jwtMW := jwt.New("jwtsecret", "HS256", 60)
+	jwtMW := jwt.New("jwtsecret", "HS256", 60, nil)
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
-			transport.NewHTTP(auth.New(nil, tt.udb, nil, nil, tt.rbac), r, jwtMW.MWFunc())
+			transport.NewHTTP(auth.New(mockdb.DB{}, tt.udb, nil, nil, nil, nil, nil, nil, tt.rbac, nil), r, r.Group("/v1"), jwtMW.MWFunc(), mock.RequirePermission)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/me"
@@ -259,3 +388,718 @@ func TestMe(t *testing.T) {
 		})
 	}
 }
+
+func TestLogout(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		header     string
+		sdb        *mockdb.Session
+		jwt        *mock.JWT
+	}{
+		{
+			name:       "Unauthorized",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Fail on session view",
+			wantStatus: http.StatusInternalServerError,
+			header:     mock.HeaderValid(),
+			sdb: &mockdb.Session{
+				ViewFn: func(orm.DB, int) (*gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			header:     mock.HeaderValid(),
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Session) error {
+					return nil
+				},
+			},
+			jwt: &mock.JWT{
+				RevokeFn: func(context.Context) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	client := &http.Client{}
+	jwtMW := jwt.New("jwtsecret", "HS256", 60, nil)
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1, SessionID: 1}
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, tt.jwt, nil, rbac, nil), r, r.Group("/v1"), jwtMW.MWFunc(), mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/logout", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", tt.header)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		id         string
+		rbac       *mock.RBAC
+		sdb        *mockdb.Session
+		jwt        *mock.JWT
+	}{
+		{
+			name:       "NaN",
+			wantStatus: http.StatusBadRequest,
+			id:         "abc",
+		},
+		{
+			name:       "Fail on RBAC",
+			wantStatus: http.StatusForbidden,
+			id:         "2",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			id:         "2",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			sdb: &mockdb.Session{
+				DeleteByUserFn: func(orm.DB, int) error {
+					return nil
+				},
+			},
+			jwt: &mock.JWT{
+				RevokeUserFn: func(context.Context, int) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, tt.jwt, nil, tt.rbac, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("DELETE", ts.URL+"/v1/users/"+tt.id+"/sessions", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		id         string
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "NaN",
+			wantStatus: http.StatusBadRequest,
+			id:         "abc",
+		},
+		{
+			name:       "Fail on RBAC",
+			wantStatus: http.StatusForbidden,
+			id:         "2",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			id:         "2",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+			return &gorsk.User{Username: "johndoe"}, nil
+		},
+	}
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(mockdb.DB{}, udb, nil, nil, throttle.NewMemory(), nil, nil, nil, tt.rbac, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("DELETE", ts.URL+"/v1/users/"+tt.id+"/lockout", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLoginLocked(t *testing.T) {
+	udb := &mockdb.User{
+		FindByUsernameFn: func(orm.DB, string) (*gorsk.User, error) {
+			return &gorsk.User{Password: "hunter123", Active: true}, nil
+		},
+	}
+	sec := &mock.Secure{
+		VerifyPasswordFn: func(hash, pass string) (bool, bool) {
+			return hash == pass, false
+		},
+	}
+	r := server.New()
+	transport.NewHTTP(auth.New(mockdb.DB{}, udb, nil, nil, throttle.NewMemory(), nil, nil, sec, nil, &auth.Config{MaxFailures: 2}), r, r.Group("/v1"), nil, mock.RequirePermission)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, want := range []int{http.StatusUnauthorized, http.StatusLocked, http.StatusLocked} {
+		res, err := http.Post(ts.URL+"/login", "application/json", bytes.NewBufferString(`{"username":"juzernejm","password":"wrong"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assert.Equal(t, want, res.StatusCode)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	cases := []struct {
+		name       string
+		wantStatus int
+		wantResp   []gorsk.Session
+		sdb        *mockdb.Session
+	}{
+		{
+			name:       "Fail on list",
+			wantStatus: http.StatusInternalServerError,
+			sdb: &mockdb.Session{
+				ListFn: func(orm.DB, int) ([]gorsk.Session, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:       "Success",
+			wantStatus: http.StatusOK,
+			sdb: &mockdb.Session{
+				ListFn: func(db orm.DB, userID int) ([]gorsk.Session, error) {
+					return []gorsk.Session{
+						{Base: gorsk.Base{ID: 1}, UserID: userID, UserAgent: "laptop", IP: "10.0.0.1"},
+					}, nil
+				},
+			},
+			wantResp: []gorsk.Session{
+				{Base: gorsk.Base{ID: 1}, UserID: 1, UserAgent: "laptop", IP: "10.0.0.1", Current: true},
+			},
+		},
+	}
+
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1, SessionID: 1}
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, nil, nil, rbac, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/v1/me/sessions")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(struct {
+					Sessions []gorsk.Session `json:"sessions"`
+				})
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response.Sessions)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		sdb        *mockdb.Session
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Session of another user",
+			id:         "2",
+			wantStatus: http.StatusNotFound,
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 2}, nil
+				},
+			},
+		},
+		{
+			name:       "Success",
+			id:         "2",
+			wantStatus: http.StatusOK,
+			sdb: &mockdb.Session{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Session, error) {
+					return &gorsk.Session{Base: gorsk.Base{ID: id}, UserID: 1}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Session) error {
+					return nil
+				},
+			},
+		},
+	}
+
+	client := &http.Client{}
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1}
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			transport.NewHTTP(auth.New(mockdb.DB{}, nil, tt.sdb, nil, nil, nil, nil, nil, rbac, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("DELETE", ts.URL+"/v1/me/sessions/"+tt.id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLoginMFA(t *testing.T) {
+	now := mock.TestTime(2018)
+	code, err := totp.Code("JBSWY3DPEHPK3PXP", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantToken  string
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"challenge_token":"challengetoken"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid challenge",
+			req:        `{"challenge_token":"other","code":"` + code + `"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Invalid code",
+			req:        `{"challenge_token":"challengetoken","code":"000000"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Success",
+			req:        `{"challenge_token":"challengetoken","code":"` + code + `"}`,
+			wantStatus: http.StatusOK,
+			wantToken:  "jwttokenstring",
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+			return &gorsk.User{Active: true, MFAEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP"}, nil
+		},
+		UpdateFn: func(orm.DB, *gorsk.User) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+			return &s, nil
+		},
+	}
+	mdb := &mockdb.MFA{
+		FindChallengeFn: func(db orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+			if hash != "challengetoken" {
+				return nil, pg.ErrNoRows
+			}
+			return &gorsk.LoginChallenge{UserID: 1, ExpiresAt: now.Add(time.Minute)}, nil
+		},
+		UpdateChallengeFn: func(orm.DB, *gorsk.LoginChallenge) error {
+			return nil
+		},
+		DeleteChallengeFn: func(orm.DB, *gorsk.LoginChallenge) error {
+			return nil
+		},
+		UseRecoveryCodeFn: func(orm.DB, int, string) error {
+			return pg.ErrNoRows
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+			return "jwttokenstring", now.Format(time.RFC3339), nil
+		},
+	}
+	sec := &mock.Secure{
+		RandomTokenFn: func(string) (string, error) {
+			return "refreshtoken", nil
+		},
+		TokenHashFn: func(s string) string {
+			return s
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			cfg := &auth.Config{Clock: func() time.Time { return now }}
+			transport.NewHTTP(auth.New(mockdb.DB{}, udb, sdb, mdb, nil, nil, jwt, sec, nil, cfg), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/login/mfa", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantToken != "" {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantToken, response.Token)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestLoginPassword(t *testing.T) {
+	now := mock.TestTime(2018)
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantToken  string
+	}{
+		{
+			name:       "Invalid request",
+			req:        `{"challenge_token":"challengetoken"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid challenge",
+			req:        `{"challenge_token":"other","password":"newpassword"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Insecure password",
+			req:        `{"challenge_token":"challengetoken","password":"insecure"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Success",
+			req:        `{"challenge_token":"challengetoken","password":"newpassword"}`,
+			wantStatus: http.StatusOK,
+			wantToken:  "jwttokenstring",
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+			return &gorsk.User{Active: true, Password: "oldhash", PasswordChangeRequired: true}, nil
+		},
+		UpdateFn: func(orm.DB, *gorsk.User) error {
+			return nil
+		},
+	}
+	sdb := &mockdb.Session{
+		CreateFn: func(db orm.DB, s gorsk.Session) (*gorsk.Session, error) {
+			return &s, nil
+		},
+	}
+	mdb := &mockdb.MFA{
+		FindChallengeFn: func(db orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+			if hash != "challengetoken" {
+				return nil, pg.ErrNoRows
+			}
+			return &gorsk.LoginChallenge{UserID: 1, ExpiresAt: now.Add(time.Minute), PasswordChange: true}, nil
+		},
+		DeleteChallengeFn: func(orm.DB, *gorsk.LoginChallenge) error {
+			return nil
+		},
+	}
+	jwt := &mock.JWT{
+		GenerateTokenFn: func(*gorsk.User, int) (string, string, error) {
+			return "jwttokenstring", now.Format(time.RFC3339), nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(pass string, _ ...string) bool {
+			return pass != "insecure"
+		},
+		BreachedFn: func(string) (bool, error) {
+			return false, nil
+		},
+		VerifyPasswordFn: func(string, string) (bool, bool) {
+			return false, false
+		},
+		HashFn: func(string) (string, error) {
+			return "newhash", nil
+		},
+		RandomTokenFn: func(string) (string, error) {
+			return "refreshtoken", nil
+		},
+		TokenHashFn: func(s string) string {
+			return s
+		},
+	}
+	hist := pwhistory.New(pwhistory.NewMemory(), 0, nil)
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			cfg := &auth.Config{Clock: func() time.Time { return now }}
+			transport.NewHTTP(auth.New(mockdb.DB{}, udb, sdb, mdb, nil, hist, jwt, sec, nil, cfg), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/login/password", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantToken != "" {
+				response := new(gorsk.AuthToken)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantToken, response.Token)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	now := mock.TestTime(2018)
+	code, err := totp.Code("JBSWY3DPEHPK3PXP", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		req        string
+		user       gorsk.User
+		wantStatus int
+		wantCodes  int
+	}{
+		{
+			name:       "Enroll",
+			method:     "POST",
+			path:       "/v1/me/mfa/totp",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Enroll when enabled",
+			method:     "POST",
+			path:       "/v1/me/mfa/totp",
+			user:       gorsk.User{MFAEnabled: true},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Confirm invalid request",
+			method:     "POST",
+			path:       "/v1/me/mfa/totp/verify",
+			req:        `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Confirm wrong code",
+			method:     "POST",
+			path:       "/v1/me/mfa/totp/verify",
+			req:        `{"code":"000000"}`,
+			user:       gorsk.User{TOTPSecret: "JBSWY3DPEHPK3PXP"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Confirm",
+			method:     "POST",
+			path:       "/v1/me/mfa/totp/verify",
+			req:        `{"code":"` + code + `"}`,
+			user:       gorsk.User{TOTPSecret: "JBSWY3DPEHPK3PXP"},
+			wantStatus: http.StatusOK,
+			wantCodes:  10,
+		},
+		{
+			name:       "Disable required",
+			method:     "DELETE",
+			path:       "/v1/me/mfa/totp",
+			req:        `{"code":"` + code + `"}`,
+			user:       gorsk.User{TOTPSecret: "JBSWY3DPEHPK3PXP", MFAEnabled: true, Role: &gorsk.Role{AccessLevel: gorsk.SuperAdminRole}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Disable",
+			method:     "DELETE",
+			path:       "/v1/me/mfa/totp",
+			req:        `{"code":"` + code + `"}`,
+			user:       gorsk.User{TOTPSecret: "JBSWY3DPEHPK3PXP", MFAEnabled: true},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1}
+		},
+	}
+	mdb := &mockdb.MFA{
+		ReplaceRecoveryCodesFn: func(orm.DB, int, []string) error {
+			return nil
+		},
+		UseRecoveryCodeFn: func(orm.DB, int, string) error {
+			return pg.ErrNoRows
+		},
+	}
+	sec := &mock.Secure{
+		RandomTokenFn: func(string) (string, error) {
+			return "0123456789abcdef", nil
+		},
+		TokenHashFn: func(s string) string {
+			return s
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					u := tt.user
+					return &u, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.User) error {
+					return nil
+				},
+			}
+			r := server.New()
+			cfg := &auth.Config{MFARequiredRole: gorsk.AdminRole, Clock: func() time.Time { return now }}
+			transport.NewHTTP(auth.New(mockdb.DB{}, udb, nil, mdb, nil, nil, nil, sec, rbac, cfg), r, r.Group("/v1"), nil, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantCodes > 0 {
+				response := new(struct {
+					RecoveryCodes []string `json:"recovery_codes"`
+				})
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Len(t, response.RecoveryCodes, tt.wantCodes)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	set := &gorsk.JWKS{Keys: []gorsk.JWK{{KeyType: "OKP", KeyID: "2019", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}}}
+	jwt := &mock.JWT{
+		JWKSFn: func() *gorsk.JWKS {
+			return set
+		},
+	}
+	r := server.New()
+	transport.NewHTTP(auth.New(mockdb.DB{}, nil, nil, nil, nil, nil, jwt, nil, nil, nil), r, r.Group("/v1"), nil, mock.RequirePermission)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	res, err := http.Get(ts.URL + "/.well-known/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	response := new(gorsk.JWKS)
+	if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, set, response)
+}
@@ -11,12 +11,48 @@ type swaggLoginReq struct {
 	Body credentials
 }
 
+// Token refresh request
+// swagger:parameters refresh
+type swaggRefreshReq struct {
+	// in:body
+	Body refreshReq
+}
+
+// MFA login request
+// swagger:parameters loginMFA
+type swaggMFAReq struct {
+	// in:body
+	Body mfaReq
+}
+
+// Expired password change on login request
+// swagger:parameters loginPassword
+type swaggPasswordChangeReq struct {
+	// in:body
+	Body passwordChangeReq
+}
+
+// MFA enrollment on login request
+// swagger:parameters loginMFAEnroll
+type swaggMFAEnrollReq struct {
+	// in:body
+	Body mfaEnrollReq
+}
+
+// TOTP code request
+// swagger:parameters confirmTOTP disableTOTP
+type swaggTOTPCodeReq struct {
+	// in:body
+	Body totpCodeReq
+}
+
 // Login response
 // swagger:response loginResp
 type swaggLoginResp struct {
 	// in:body
 	Body struct {
 		*gorsk.AuthToken
+		*gorsk.MFAChallenge
 	}
 }
 
@@ -28,3 +64,39 @@ type swaggRefreshResp struct {
 		*gorsk.RefreshToken
 	}
 }
+
+// Sessions response
+// swagger:response sessionsResp
+type swaggSessionsResp struct {
+	// in:body
+	Body struct {
+		Sessions []gorsk.Session `json:"sessions"`
+	}
+}
+
+// TOTP enrollment response
+// swagger:response totpEnrollResp
+type swaggTOTPEnrollResp struct {
+	// in:body
+	Body struct {
+		*gorsk.TOTPEnrollment
+	}
+}
+
+// Recovery codes response
+// swagger:response recoveryCodesResp
+type swaggRecoveryCodesResp struct {
+	// in:body
+	Body struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+}
+
+// JSON Web Key Set response
+// swagger:response jwksResp
+type swaggJWKSResp struct {
+	// in:body
+	Body struct {
+		*gorsk.JWKS
+	}
+}
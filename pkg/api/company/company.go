@@ -0,0 +1,94 @@
+// Package company contains company application services
+package company
+
+import (
+	"context"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/query"
+)
+
+// Create creates a new company
+func (cs *Company) Create(ctx context.Context, req gorsk.Company) (*gorsk.Company, error) {
+	db := cs.db.WithContext(ctx)
+	if err := cs.rbac.Enforce(ctx, gorsk.PermCompaniesCreate); err != nil {
+		return nil, err
+	}
+	return cs.cdb.Create(db, req)
+}
+
+// List returns list of companies
+func (cs *Company) List(ctx context.Context, p *gorsk.Pagination) ([]gorsk.Company, error) {
+	db := cs.db.WithContext(ctx)
+	if err := cs.rbac.Enforce(ctx, gorsk.PermCompaniesRead); err != nil {
+		return nil, err
+	}
+	au := cs.rbac.User(ctx)
+	q, err := query.Company(au)
+	if err != nil {
+		return nil, err
+	}
+	return cs.cdb.List(db, q, p)
+}
+
+// View returns single company
+func (cs *Company) View(ctx context.Context, id int) (*gorsk.Company, error) {
+	db := cs.db.WithContext(ctx)
+	if err := cs.rbac.EnforceCompany(ctx, gorsk.PermCompaniesRead, id); err != nil {
+		return nil, err
+	}
+	return cs.cdb.View(db, id)
+}
+
+// Delete deletes a company
+func (cs *Company) Delete(ctx context.Context, id int) error {
+	db := cs.db.WithContext(ctx)
+	if err := cs.rbac.EnforceCompany(ctx, gorsk.PermCompaniesDelete, id); err != nil {
+		return err
+	}
+	company, err := cs.cdb.View(db, id)
+	if err != nil {
+		return err
+	}
+	return cs.cdb.Delete(db, company)
+}
+
+// Update contains company's information used for updating
+type Update struct {
+	ID     int
+	Name   string
+	Active *bool
+}
+
+// Update updates company's information
+func (cs *Company) Update(ctx context.Context, r *Update) (*gorsk.Company, error) {
+	db := cs.db.WithContext(ctx)
+	if err := cs.rbac.EnforceCompany(ctx, gorsk.PermCompaniesUpdate, r.ID); err != nil {
+		return nil, err
+	}
+
+	// Only admins are allowed to (de)activate a company
+	if r.Active != nil {
+		if err := cs.rbac.EnforceRole(ctx, gorsk.AdminRole); err != nil {
+			return nil, err
+		}
+	}
+
+	company, err := cs.cdb.View(db, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Name != "" {
+		company.Name = r.Name
+	}
+	if r.Active != nil {
+		company.Active = *r.Active
+	}
+
+	if err := cs.cdb.Update(db, company); err != nil {
+		return nil, err
+	}
+
+	return company, nil
+}
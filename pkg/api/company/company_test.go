@@ -0,0 +1,303 @@
+package company_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/api/company"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      gorsk.Company
+		wantErr  bool
+		wantData *gorsk.Company
+		cdb      *mockdb.Company
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: true,
+			req:     gorsk.Company{Name: "Gophers"},
+		},
+		{
+			name: "Success",
+			req:  gorsk.Company{Name: "Gophers", Active: true},
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				CreateFn: func(db orm.DB, c gorsk.Company) (*gorsk.Company, error) {
+					c.ID = 1
+					c.CreatedAt = mock.TestTime(2000)
+					c.UpdatedAt = mock.TestTime(2000)
+					return &c, nil
+				},
+			},
+			wantData: &gorsk.Company{
+				Base: gorsk.Base{
+					ID:        1,
+					CreatedAt: mock.TestTime(2000),
+					UpdatedAt: mock.TestTime(2000),
+				},
+				Name:   "Gophers",
+				Active: true,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := company.New(mockdb.DB{}, tt.cdb, tt.rbac)
+			cmp, err := s.Create(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, cmp)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		pgn      *gorsk.Pagination
+		wantData []gorsk.Company
+		wantErr  bool
+		cdb      *mockdb.Company
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on query Company",
+			pgn:     &gorsk.Pagination{Limit: 100},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.LocationAdminRole}
+				}},
+		},
+		{
+			name: "Success",
+			pgn:  &gorsk.Pagination{Limit: 100},
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.CompanyAdminRole}
+				}},
+			cdb: &mockdb.Company{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Company, error) {
+					if q.ID != 2 {
+						return nil, gorsk.ErrGeneric
+					}
+					return []gorsk.Company{{Base: gorsk.Base{ID: 2}, Name: "Gophers"}}, nil
+				}},
+			wantData: []gorsk.Company{{Base: gorsk.Base{ID: 2}, Name: "Gophers"}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := company.New(mockdb.DB{}, tt.cdb, tt.rbac)
+			cmps, err := s.List(context.Background(), tt.pgn)
+			assert.Equal(t, tt.wantData, cmps)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       int
+		wantData *gorsk.Company
+		wantErr  error
+		cdb      *mockdb.Company
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			id:   5,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Success",
+			id:   1,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}, Name: "Gophers"}, nil
+				}},
+			wantData: &gorsk.Company{Base: gorsk.Base{ID: 1}, Name: "Gophers"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := company.New(mockdb.DB{}, tt.cdb, tt.rbac)
+			cmp, err := s.View(context.Background(), tt.id)
+			assert.Equal(t, tt.wantData, cmp)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+		cdb     *mockdb.Company
+		rbac    *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			id:   1,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Fail on View",
+			id:   1,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				ViewFn: func(orm.DB, int) (*gorsk.Company, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Success",
+			id:   1,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Company) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := company.New(mockdb.DB{}, tt.cdb, tt.rbac)
+			err := s.Delete(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	active := false
+	cases := []struct {
+		name     string
+		upd      *company.Update
+		wantData *gorsk.Company
+		wantErr  error
+		cdb      *mockdb.Company
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			upd:  &company.Update{ID: 1},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Fail on changing active status as company admin",
+			upd:  &company.Update{ID: 1, Active: &active},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on Update",
+			upd:  &company.Update{ID: 1, Name: "Gophers Inc"},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}, Name: "Gophers", Active: true}, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.Company) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Success",
+			upd:  &company.Update{ID: 1, Name: "Gophers Inc", Active: &active},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return nil
+				}},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}, Name: "Gophers", Active: true}, nil
+				},
+				UpdateFn: func(db orm.DB, c *gorsk.Company) error {
+					c.UpdatedAt = mock.TestTime(2010)
+					return nil
+				}},
+			wantData: &gorsk.Company{
+				Base:   gorsk.Base{ID: 1, UpdatedAt: mock.TestTime(2010)},
+				Name:   "Gophers Inc",
+				Active: false,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := company.New(mockdb.DB{}, tt.cdb, tt.rbac)
+			cmp, err := s.Update(context.Background(), tt.upd)
+			assert.Equal(t, tt.wantData, cmp)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	c := company.Initialize(nil, nil)
+	if c == nil {
+		t.Error("Company service not initialized")
+	}
+}
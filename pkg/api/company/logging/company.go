@@ -0,0 +1,104 @@
+package company
+
+import (
+	"context"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/company"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// New creates new company logging service
+func New(svc company.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents company logging service
+type LogService struct {
+	company.Service
+	logger gorsk.Logger
+}
+
+const name = "company"
+
+// Create logging
+func (ls *LogService) Create(ctx context.Context, req gorsk.Company) (resp *gorsk.Company, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Create company request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Create(ctx, req)
+}
+
+// List logging
+func (ls *LogService) List(ctx context.Context, req *gorsk.Pagination) (resp []gorsk.Company, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "List company request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(ctx, req)
+}
+
+// View logging
+func (ls *LogService) View(ctx context.Context, req int) (resp *gorsk.Company, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "View company request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.View(ctx, req)
+}
+
+// Delete logging
+func (ls *LogService) Delete(ctx context.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Delete company request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Delete(ctx, req)
+}
+
+// Update logging
+func (ls *LogService) Update(ctx context.Context, req *company.Update) (resp *gorsk.Company, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Update company request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Update(ctx, req)
+}
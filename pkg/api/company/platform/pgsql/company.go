@@ -0,0 +1,72 @@
+package pgsql
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewCompany returns a new company database instance
+func NewCompany() *Company {
+	return &Company{}
+}
+
+// Company represents the client for company table
+type Company struct{}
+
+// Custom errors
+var (
+	ErrAlreadyExists = echo.NewHTTPError(http.StatusInternalServerError, "Company name already exists.")
+)
+
+// Create creates a new company on database
+func (c *Company) Create(db orm.DB, cmp gorsk.Company) (*gorsk.Company, error) {
+	var company = new(gorsk.Company)
+	err := db.Model(company).Where("lower(name) = ?", strings.ToLower(cmp.Name)).Select()
+	if (err == nil) || (err != nil && err != pg.ErrNoRows) {
+		return nil, ErrAlreadyExists
+	}
+
+	if err := db.Insert(&cmp); err != nil {
+		return nil, err
+	}
+
+	return &cmp, nil
+}
+
+// View returns single company by ID
+func (c *Company) View(db orm.DB, id int) (*gorsk.Company, error) {
+	var company = &gorsk.Company{Base: gorsk.Base{ID: id}}
+	if err := db.Model(company).WherePK().Relation("Locations").Select(); err != nil {
+		return nil, err
+	}
+	return company, nil
+}
+
+// Update updates company's info
+func (c *Company) Update(db orm.DB, company *gorsk.Company) error {
+	return db.Update(company)
+}
+
+// List returns list of all companies retrievable for the current user, depending on role
+func (c *Company) List(db orm.DB, qp *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Company, error) {
+	var companies []gorsk.Company
+	q := db.Model(&companies).Limit(p.Limit).Offset(p.Offset).Order("company.id desc")
+	if qp != nil {
+		q.Where(qp.Query, qp.ID)
+	}
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
+
+// Delete sets deleted_at for a company
+func (c *Company) Delete(db orm.DB, company *gorsk.Company) error {
+	return db.Delete(company)
+}
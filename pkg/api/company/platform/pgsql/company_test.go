@@ -0,0 +1,235 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/company/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		req      gorsk.Company
+		wantData *gorsk.Company
+	}{
+		{
+			name:    "Fail on insert duplicate ID",
+			wantErr: true,
+			req: gorsk.Company{
+				Name: "Rustaceans",
+				Base: gorsk.Base{ID: 1},
+			},
+		},
+		{
+			name: "Success",
+			req: gorsk.Company{
+				Name:   "Gophers",
+				Active: true,
+				Base:   gorsk.Base{ID: 2},
+			},
+			wantData: &gorsk.Company{
+				Name:   "Gophers",
+				Active: true,
+				Base:   gorsk.Base{ID: 2},
+			},
+		},
+		{
+			name:    "Company already exists",
+			wantErr: true,
+			req:     gorsk.Company{Name: "GOPHERS"},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Company{
+		Name: "Existing",
+		Base: gorsk.Base{ID: 1},
+	}); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.NewCompany()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := cdb.Create(db, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				if resp == nil {
+					t.Error("Expected data, but received nil.")
+					return
+				}
+				tt.wantData.CreatedAt = resp.CreatedAt
+				tt.wantData.UpdatedAt = resp.UpdatedAt
+				assert.Equal(t, tt.wantData, resp)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		id       int
+		wantData *gorsk.Company
+	}{
+		{
+			name:    "Company does not exist",
+			wantErr: true,
+			id:      1000,
+		},
+		{
+			name: "Success",
+			id:   2,
+			wantData: &gorsk.Company{
+				Name:   "Gophers",
+				Active: true,
+				Base:   gorsk.Base{ID: 2},
+				Locations: []gorsk.Location{{
+					Name:      "HQ",
+					Address:   "Main St",
+					CompanyID: 2,
+					Base:      gorsk.Base{ID: 1},
+				}},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{}, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, cases[1].wantData, &cases[1].wantData.Locations[0]); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.NewCompany()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			company, err := cdb.View(db, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				if company == nil {
+					t.Errorf("response was nil due to: %v", err)
+					return
+				}
+				tt.wantData.CreatedAt = company.CreatedAt
+				tt.wantData.UpdatedAt = company.UpdatedAt
+				for i, v := range company.Locations {
+					tt.wantData.Locations[i].CreatedAt = v.CreatedAt
+					tt.wantData.Locations[i].UpdatedAt = v.UpdatedAt
+				}
+				assert.Equal(t, tt.wantData, company)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		qp       *gorsk.ListQuery
+		pg       *gorsk.Pagination
+		wantData []gorsk.Company
+	}{
+		{
+			name:    "Invalid pagination values",
+			wantErr: true,
+			pg:      &gorsk.Pagination{Limit: -100},
+		},
+		{
+			name: "Success",
+			pg:   &gorsk.Pagination{Limit: 100},
+			qp:   &gorsk.ListQuery{ID: 2, Query: "id = ?"},
+			wantData: []gorsk.Company{
+				{Name: "Gophers", Active: true, Base: gorsk.Base{ID: 2}},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db,
+		&gorsk.Company{Name: "Rustaceans", Base: gorsk.Base{ID: 1}},
+		&cases[1].wantData[0]); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.NewCompany()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			companies, err := cdb.List(db, tt.qp, tt.pg)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				for i, v := range companies {
+					tt.wantData[i].CreatedAt = v.CreatedAt
+					tt.wantData[i].UpdatedAt = v.UpdatedAt
+				}
+				assert.Equal(t, tt.wantData, companies)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cmp := &gorsk.Company{Name: "Gophers", Active: true, Base: gorsk.Base{ID: 1}}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db, cmp); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.NewCompany()
+
+	cmp.Name = "Gophers Inc"
+	cmp.Active = false
+	assert.Nil(t, cdb.Update(db, cmp))
+
+	company := &gorsk.Company{Base: gorsk.Base{ID: 1}}
+	if err := db.Select(company); err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, "Gophers Inc", company.Name)
+	assert.False(t, company.Active)
+}
+
+func TestDelete(t *testing.T) {
+	cmp := &gorsk.Company{Name: "Gophers", Base: gorsk.Base{ID: 1}}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{})
+
+	if err := mock.InsertMultiple(db, cmp); err != nil {
+		t.Error(err)
+	}
+
+	cdb := pgsql.NewCompany()
+
+	assert.Nil(t, cdb.Delete(db, cmp))
+
+	_, err := cdb.View(db, 1)
+	assert.NotNil(t, err)
+}
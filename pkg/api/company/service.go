@@ -0,0 +1,54 @@
+package company
+
+import (
+	"context"
+
+	"github.com/figassis/goduck/pkg/api/company/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Service represents company application interface
+type Service interface {
+	Create(context.Context, gorsk.Company) (*gorsk.Company, error)
+	List(context.Context, *gorsk.Pagination) ([]gorsk.Company, error)
+	View(context.Context, int) (*gorsk.Company, error)
+	Delete(context.Context, int) error
+	Update(context.Context, *Update) (*gorsk.Company, error)
+}
+
+// New creates new company application service
+func New(db postgres.DB, cdb CDB, rbac RBAC) *Company {
+	return &Company{db: db, cdb: cdb, rbac: rbac}
+}
+
+// Initialize initalizes Company application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) *Company {
+	return New(postgres.Wrap(db), pgsql.NewCompany(), rbac)
+}
+
+// Company represents company application service
+type Company struct {
+	db   postgres.DB
+	cdb  CDB
+	rbac RBAC
+}
+
+// CDB represents company repository interface
+type CDB interface {
+	Create(orm.DB, gorsk.Company) (*gorsk.Company, error)
+	View(orm.DB, int) (*gorsk.Company, error)
+	List(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Company, error)
+	Update(orm.DB, *gorsk.Company) error
+	Delete(orm.DB, *gorsk.Company) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(context.Context) *gorsk.AuthUser
+	Enforce(context.Context, gorsk.Permission) error
+	EnforceRole(context.Context, gorsk.AccessRole) error
+	EnforceCompany(context.Context, gorsk.Permission, int) error
+}
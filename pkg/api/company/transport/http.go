@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/figassis/goduck/pkg/api/company"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents company http service
+type HTTP struct {
+	svc company.Service
+}
+
+// NewHTTP creates new company http service
+// Routes are authorized by require, which returns middleware checking user's permissions.
+func NewHTTP(svc company.Service, er *echo.Group, require func(...gorsk.Permission) echo.MiddlewareFunc) {
+	h := HTTP{svc}
+	cr := er.Group("/companies")
+	// swagger:route POST /v1/companies companies companyCreate
+	// Creates new company.
+	// responses:
+	//  200: companyResp
+	//  400: errMsg
+	//  401: err
+	//  403: errMsg
+	//  500: err
+	cr.POST("", h.create, require(gorsk.PermCompaniesCreate))
+
+	// swagger:operation GET /v1/companies companies listCompanies
+	// ---
+	// summary: Returns list of companies.
+	// description: Returns list of companies. Depending on the user role requesting it, it may return all companies for SuperAdmin/Admin users, the user's own company for Company admins, and an error for other users.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/companyListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.GET("", h.list, require(gorsk.PermCompaniesRead))
+
+	// swagger:operation GET /v1/companies/{id} companies getCompany
+	// ---
+	// summary: Returns a single company.
+	// description: Returns a single company by its ID, including its locations.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/companyResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.GET("/:id", h.view, require(gorsk.PermCompaniesRead))
+
+	// swagger:operation PATCH /v1/companies/{id} companies companyUpdate
+	// ---
+	// summary: Updates company's information
+	// description: Updates company's name and active status. Only admins can change the active status.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/companyUpdate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/companyResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.PATCH("/:id", h.update, require(gorsk.PermCompaniesUpdate))
+
+	// swagger:operation DELETE /v1/companies/{id} companies companyDelete
+	// ---
+	// summary: Deletes a company
+	// description: Deletes a company with requested ID.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of company
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	cr.DELETE("/:id", h.delete, require(gorsk.PermCompaniesDelete))
+}
+
+// Company create request
+// swagger:model companyCreate
+type createReq struct {
+	Name   string `json:"name" validate:"required,min=2"`
+	Active bool   `json:"active"`
+}
+
+func (h *HTTP) create(c echo.Context) error {
+	r := new(createReq)
+
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	cmp, err := h.svc.Create(c.Request().Context(), gorsk.Company{
+		Name:   r.Name,
+		Active: r.Active,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cmp)
+}
+
+type listResponse struct {
+	Companies []gorsk.Company `json:"companies"`
+	Page      int             `json:"page"`
+}
+
+func (h *HTTP) list(c echo.Context) error {
+	p := new(gorsk.PaginationReq)
+	if err := c.Bind(p); err != nil {
+		return err
+	}
+
+	result, err := h.svc.List(c.Request().Context(), p.Transform())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, p.Page})
+}
+
+func (h *HTTP) view(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	result, err := h.svc.View(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Company update request
+// swagger:model companyUpdate
+type updateReq struct {
+	Name   string `json:"name,omitempty" validate:"omitempty,min=2"`
+	Active *bool  `json:"active,omitempty"`
+}
+
+func (h *HTTP) update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	req := new(updateReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	cmp, err := h.svc.Update(c.Request().Context(), &company.Update{
+		ID:     id,
+		Name:   req.Name,
+		Active: req.Active,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cmp)
+}
+
+func (h *HTTP) delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,411 @@
+package transport_test
+
+import (
+	"context"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/company"
+	"github.com/figassis/goduck/pkg/api/company/transport"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/server"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Company
+		cdb        *mockdb.Company
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"name":"g"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"name":"Gophers","active":true}`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"name":"Gophers","active":true}`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error {
+					return nil
+				},
+			},
+			cdb: &mockdb.Company{
+				CreateFn: func(db orm.DB, c gorsk.Company) (*gorsk.Company, error) {
+					c.ID = 1
+					c.CreatedAt = mock.TestTime(2018)
+					c.UpdatedAt = mock.TestTime(2018)
+					return &c, nil
+				},
+			},
+			wantResp: &gorsk.Company{
+				Base: gorsk.Base{
+					ID:        1,
+					CreatedAt: mock.TestTime(2018),
+					UpdatedAt: mock.TestTime(2018),
+				},
+				Name:   "Gophers",
+				Active: true,
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(company.New(mockdb.DB{}, tt.cdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/companies"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Company)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	type listResponse struct {
+		Companies []gorsk.Company `json:"companies"`
+		Page      int             `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		cdb        *mockdb.Company
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on query list",
+			req:  `?limit=100&page=1`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.UserRole}
+				}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `?limit=100&page=1`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.SuperAdminRole}
+				}},
+			cdb: &mockdb.Company{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Company, error) {
+					if p.Limit == 100 && p.Offset == 100 {
+						return []gorsk.Company{
+							{Base: gorsk.Base{ID: 10}, Name: "Gophers", Active: true},
+							{Base: gorsk.Base{ID: 11}, Name: "Rustaceans"},
+						}, nil
+					}
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Companies: []gorsk.Company{
+					{Base: gorsk.Base{ID: 10}, Name: "Gophers", Active: true},
+					{Base: gorsk.Base{ID: 11}, Name: "Rustaceans"},
+				},
+				Page: 1,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(company.New(mockdb.DB{}, tt.cdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/companies" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Company
+		cdb        *mockdb.Company
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `1`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `1`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{
+						Base:      gorsk.Base{ID: id},
+						Name:      "Gophers",
+						Locations: []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ", CompanyID: id}},
+					}, nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &gorsk.Company{
+				Base:      gorsk.Base{ID: 1},
+				Name:      "Gophers",
+				Locations: []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ", CompanyID: 1}},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(company.New(mockdb.DB{}, tt.cdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/companies/" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Company)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		id         string
+		wantStatus int
+		wantResp   *gorsk.Company
+		cdb        *mockdb.Company
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on validation",
+			id:         `1`,
+			req:        `{"name":"g"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `1`,
+			req:  `{"name":"Gophers Inc"}`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			req:  `{"name":"Gophers Inc"}`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}, Name: "Gophers", Active: true}, nil
+				},
+				UpdateFn: func(db orm.DB, c *gorsk.Company) error {
+					c.UpdatedAt = mock.TestTime(2010)
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &gorsk.Company{
+				Base:   gorsk.Base{ID: 1, UpdatedAt: mock.TestTime(2010)},
+				Name:   "Gophers Inc",
+				Active: true,
+			},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(company.New(mockdb.DB{}, tt.cdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/companies/" + tt.id
+			req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Company)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		cdb        *mockdb.Company
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `1`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			cdb: &mockdb.Company{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Company, error) {
+					return &gorsk.Company{Base: gorsk.Base{ID: id}}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Company) error {
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(company.New(mockdb.DB{}, tt.cdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/companies/" + tt.id
+			req, _ := http.NewRequest("DELETE", path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Company model response
+// swagger:response companyResp
+type swaggCompanyResponse struct {
+	// in:body
+	Body struct {
+		*gorsk.Company
+	}
+}
+
+// Companies model response
+// swagger:response companyListResp
+type swaggCompanyListResponse struct {
+	// in:body
+	Body struct {
+		Companies []gorsk.Company `json:"companies"`
+		Page      int             `json:"page"`
+	}
+}
@@ -0,0 +1,111 @@
+// Package location contains location application services
+package location
+
+import (
+	"context"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/query"
+)
+
+// Create creates a new location for a company
+func (ls *Location) Create(ctx context.Context, req gorsk.Location) (*gorsk.Location, error) {
+	db := ls.db.WithContext(ctx)
+	if err := ls.rbac.EnforceCompany(ctx, gorsk.PermLocationsCreate, req.CompanyID); err != nil {
+		return nil, err
+	}
+	return ls.ldb.Create(db, req)
+}
+
+// List returns list of locations
+func (ls *Location) List(ctx context.Context, p *gorsk.Pagination) ([]gorsk.Location, error) {
+	db := ls.db.WithContext(ctx)
+	if err := ls.rbac.Enforce(ctx, gorsk.PermLocationsRead); err != nil {
+		return nil, err
+	}
+	au := ls.rbac.User(ctx)
+	q, err := query.Location(au)
+	if err != nil {
+		return nil, err
+	}
+	return ls.ldb.List(db, q, p)
+}
+
+// View returns single location
+func (ls *Location) View(ctx context.Context, id int) (*gorsk.Location, error) {
+	db := ls.db.WithContext(ctx)
+	location, err := ls.ldb.View(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ls.enforce(ctx, gorsk.PermLocationsRead, location); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// Delete deletes a location
+func (ls *Location) Delete(ctx context.Context, id int) error {
+	db := ls.db.WithContext(ctx)
+	location, err := ls.ldb.View(db, id)
+	if err != nil {
+		return err
+	}
+	if err := ls.rbac.EnforceCompany(ctx, gorsk.PermLocationsDelete, location.CompanyID); err != nil {
+		return err
+	}
+	return ls.ldb.Delete(db, location)
+}
+
+// Update contains location's information used for updating
+type Update struct {
+	ID      int
+	Name    string
+	Address string
+	Active  *bool
+}
+
+// Update updates location's information
+func (ls *Location) Update(ctx context.Context, r *Update) (*gorsk.Location, error) {
+	db := ls.db.WithContext(ctx)
+	location, err := ls.ldb.View(db, r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ls.enforce(ctx, gorsk.PermLocationsUpdate, location); err != nil {
+		return nil, err
+	}
+
+	// Only company admins and above are allowed to (de)activate a location
+	if r.Active != nil {
+		if err := ls.rbac.EnforceCompany(ctx, gorsk.PermLocationsUpdate, location.CompanyID); err != nil {
+			return nil, err
+		}
+		location.Active = *r.Active
+	}
+
+	if r.Name != "" {
+		location.Name = r.Name
+	}
+	if r.Address != "" {
+		location.Address = r.Address
+	}
+
+	if err := ls.ldb.Update(db, location); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// enforce checks permission p, applied by admins, by admins of the company owning the location
+// and to the admin of the location itself.
+// Company admins are checked against the already loaded location's company,
+// sparing EnforceLocation from looking it up again.
+func (ls *Location) enforce(ctx context.Context, p gorsk.Permission, l *gorsk.Location) error {
+	if ls.rbac.EnforceRole(ctx, gorsk.CompanyAdminRole) == nil {
+		return ls.rbac.EnforceCompany(ctx, p, l.CompanyID)
+	}
+	return ls.rbac.EnforceLocation(ctx, p, l.ID)
+}
@@ -0,0 +1,330 @@
+package location_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/api/location"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      gorsk.Location
+		wantErr  bool
+		wantData *gorsk.Location
+		ldb      *mockdb.Location
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			req:  gorsk.Location{Name: "HQ", CompanyID: 2},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			req:  gorsk.Location{Name: "HQ", Address: "Main St", CompanyID: 2},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(c context.Context, _ gorsk.Permission, id int) error {
+					if id != 2 {
+						return echo.ErrForbidden
+					}
+					return nil
+				}},
+			ldb: &mockdb.Location{
+				CreateFn: func(db orm.DB, l gorsk.Location) (*gorsk.Location, error) {
+					l.ID = 1
+					return &l, nil
+				}},
+			wantData: &gorsk.Location{Base: gorsk.Base{ID: 1}, Name: "HQ", Address: "Main St", CompanyID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := location.New(mockdb.DB{}, tt.ldb, tt.rbac)
+			loc, err := s.Create(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantData, loc)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		pgn      *gorsk.Pagination
+		wantData []gorsk.Location
+		wantErr  bool
+		ldb      *mockdb.Location
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on query Location",
+			pgn:     &gorsk.Pagination{Limit: 100},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, Role: gorsk.UserRole}
+				}},
+		},
+		{
+			name: "Success",
+			pgn:  &gorsk.Pagination{Limit: 100},
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, LocationID: 3, Role: gorsk.LocationAdminRole}
+				}},
+			ldb: &mockdb.Location{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Location, error) {
+					if q.Query != "id = ?" || q.ID != 3 {
+						return nil, gorsk.ErrGeneric
+					}
+					return []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ"}}, nil
+				}},
+			wantData: []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ"}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := location.New(mockdb.DB{}, tt.ldb, tt.rbac)
+			locs, err := s.List(context.Background(), tt.pgn)
+			assert.Equal(t, tt.wantData, locs)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       int
+		wantData *gorsk.Location
+		wantErr  error
+		ldb      *mockdb.Location
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on View",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(orm.DB, int) (*gorsk.Location, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Fail on company admin of another company",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return nil
+				},
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on location admin of another location",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, Name: "HQ", CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			wantData: &gorsk.Location{Base: gorsk.Base{ID: 1}, Name: "HQ", CompanyID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := location.New(mockdb.DB{}, tt.ldb, tt.rbac)
+			loc, err := s.View(context.Background(), tt.id)
+			assert.Equal(t, tt.wantData, loc)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      int
+		wantErr error
+		ldb     *mockdb.Location
+		rbac    *mock.RBAC
+	}{
+		{
+			name: "Fail on View",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(orm.DB, int) (*gorsk.Location, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			id:   1,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Location) error {
+					return nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := location.New(mockdb.DB{}, tt.ldb, tt.rbac)
+			err := s.Delete(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	inactive := false
+	cases := []struct {
+		name     string
+		upd      *location.Update
+		wantData *gorsk.Location
+		wantErr  error
+		ldb      *mockdb.Location
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			upd:  &location.Update{ID: 1},
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on changing active status as location admin",
+			upd:  &location.Update{ID: 1, Active: &inactive},
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			upd:  &location.Update{ID: 1, Name: "Warehouse", Address: "Dock 4"},
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, Name: "HQ", Address: "Main St", Active: true, CompanyID: 2}, nil
+				},
+				UpdateFn: func(db orm.DB, l *gorsk.Location) error {
+					l.UpdatedAt = mock.TestTime(2010)
+					return nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			wantData: &gorsk.Location{
+				Base:      gorsk.Base{ID: 1, UpdatedAt: mock.TestTime(2010)},
+				Name:      "Warehouse",
+				Address:   "Dock 4",
+				Active:    true,
+				CompanyID: 2,
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := location.New(mockdb.DB{}, tt.ldb, tt.rbac)
+			loc, err := s.Update(context.Background(), tt.upd)
+			assert.Equal(t, tt.wantData, loc)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	l := location.Initialize(nil, nil)
+	if l == nil {
+		t.Error("Location service not initialized")
+	}
+}
@@ -0,0 +1,104 @@
+package location
+
+import (
+	"context"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/location"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// New creates new location logging service
+func New(svc location.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents location logging service
+type LogService struct {
+	location.Service
+	logger gorsk.Logger
+}
+
+const name = "location"
+
+// Create logging
+func (ls *LogService) Create(ctx context.Context, req gorsk.Location) (resp *gorsk.Location, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Create location request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Create(ctx, req)
+}
+
+// List logging
+func (ls *LogService) List(ctx context.Context, req *gorsk.Pagination) (resp []gorsk.Location, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "List location request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(ctx, req)
+}
+
+// View logging
+func (ls *LogService) View(ctx context.Context, req int) (resp *gorsk.Location, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "View location request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.View(ctx, req)
+}
+
+// Delete logging
+func (ls *LogService) Delete(ctx context.Context, req int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Delete location request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Delete(ctx, req)
+}
+
+// Update logging
+func (ls *LogService) Update(ctx context.Context, req *location.Update) (resp *gorsk.Location, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Update location request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Update(ctx, req)
+}
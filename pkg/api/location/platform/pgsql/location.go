@@ -0,0 +1,73 @@
+package pgsql
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewLocation returns a new location database instance
+func NewLocation() *Location {
+	return &Location{}
+}
+
+// Location represents the client for location table
+type Location struct{}
+
+// Custom errors
+var (
+	ErrAlreadyExists = echo.NewHTTPError(http.StatusInternalServerError, "Location name already exists for this company.")
+)
+
+// Create creates a new location on database
+func (l *Location) Create(db orm.DB, loc gorsk.Location) (*gorsk.Location, error) {
+	var location = new(gorsk.Location)
+	err := db.Model(location).Where("company_id = ? and lower(name) = ?",
+		loc.CompanyID, strings.ToLower(loc.Name)).Select()
+	if (err == nil) || (err != nil && err != pg.ErrNoRows) {
+		return nil, ErrAlreadyExists
+	}
+
+	if err := db.Insert(&loc); err != nil {
+		return nil, err
+	}
+
+	return &loc, nil
+}
+
+// View returns single location by ID
+func (l *Location) View(db orm.DB, id int) (*gorsk.Location, error) {
+	var location = &gorsk.Location{Base: gorsk.Base{ID: id}}
+	if err := db.Model(location).WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// Update updates location's info
+func (l *Location) Update(db orm.DB, location *gorsk.Location) error {
+	return db.Update(location)
+}
+
+// List returns list of all locations retrievable for the current user, depending on role
+func (l *Location) List(db orm.DB, qp *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Location, error) {
+	var locations []gorsk.Location
+	q := db.Model(&locations).Limit(p.Limit).Offset(p.Offset).Order("location.id desc")
+	if qp != nil {
+		q.Where(qp.Query, qp.ID)
+	}
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Delete sets deleted_at for a location
+func (l *Location) Delete(db orm.DB, location *gorsk.Location) error {
+	return db.Delete(location)
+}
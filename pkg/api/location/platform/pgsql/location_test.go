@@ -0,0 +1,221 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/location/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		req      gorsk.Location
+		wantData *gorsk.Location
+	}{
+		{
+			name:    "Fail on insert duplicate ID",
+			wantErr: true,
+			req:     gorsk.Location{Name: "Warehouse", CompanyID: 1, Base: gorsk.Base{ID: 1}},
+		},
+		{
+			name: "Success",
+			req:  gorsk.Location{Name: "Warehouse", Address: "Dock 4", CompanyID: 1, Base: gorsk.Base{ID: 2}},
+			wantData: &gorsk.Location{
+				Name:      "Warehouse",
+				Address:   "Dock 4",
+				CompanyID: 1,
+				Base:      gorsk.Base{ID: 2},
+			},
+		},
+		{
+			name:    "Location already exists in company",
+			wantErr: true,
+			req:     gorsk.Location{Name: "hq", CompanyID: 1},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Location{
+		Name:      "HQ",
+		CompanyID: 1,
+		Base:      gorsk.Base{ID: 1},
+	}); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.NewLocation()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := ldb.Create(db, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				if resp == nil {
+					t.Error("Expected data, but received nil.")
+					return
+				}
+				tt.wantData.CreatedAt = resp.CreatedAt
+				tt.wantData.UpdatedAt = resp.UpdatedAt
+				assert.Equal(t, tt.wantData, resp)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		id       int
+		wantData *gorsk.Location
+	}{
+		{
+			name:    "Location does not exist",
+			wantErr: true,
+			id:      1000,
+		},
+		{
+			name: "Success",
+			id:   2,
+			wantData: &gorsk.Location{
+				Name:      "HQ",
+				Address:   "Main St",
+				Active:    true,
+				CompanyID: 1,
+				Base:      gorsk.Base{ID: 2},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, cases[1].wantData); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.NewLocation()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := ldb.View(db, tt.id)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				if location == nil {
+					t.Errorf("response was nil due to: %v", err)
+					return
+				}
+				tt.wantData.CreatedAt = location.CreatedAt
+				tt.wantData.UpdatedAt = location.UpdatedAt
+				assert.Equal(t, tt.wantData, location)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		qp       *gorsk.ListQuery
+		pg       *gorsk.Pagination
+		wantData []gorsk.Location
+	}{
+		{
+			name:    "Invalid pagination values",
+			wantErr: true,
+			pg:      &gorsk.Pagination{Limit: -100},
+		},
+		{
+			name: "Success",
+			pg:   &gorsk.Pagination{Limit: 100},
+			qp:   &gorsk.ListQuery{ID: 2, Query: "company_id = ?"},
+			wantData: []gorsk.Location{
+				{Name: "Warehouse", CompanyID: 2, Base: gorsk.Base{ID: 3}},
+				{Name: "HQ", CompanyID: 2, Base: gorsk.Base{ID: 2}},
+			},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db,
+		&gorsk.Location{Name: "Elsewhere", CompanyID: 1, Base: gorsk.Base{ID: 1}},
+		&cases[1].wantData[1], &cases[1].wantData[0]); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.NewLocation()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			locations, err := ldb.List(db, tt.qp, tt.pg)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				for i, v := range locations {
+					tt.wantData[i].CreatedAt = v.CreatedAt
+					tt.wantData[i].UpdatedAt = v.UpdatedAt
+				}
+				assert.Equal(t, tt.wantData, locations)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	loc := &gorsk.Location{Name: "HQ", Address: "Main St", CompanyID: 1, Base: gorsk.Base{ID: 1}}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, loc); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.NewLocation()
+
+	loc.Address = "Dock 4"
+	assert.Nil(t, ldb.Update(db, loc))
+
+	location := &gorsk.Location{Base: gorsk.Base{ID: 1}}
+	if err := db.Select(location); err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, "Dock 4", location.Address)
+}
+
+func TestDelete(t *testing.T) {
+	loc := &gorsk.Location{Name: "HQ", CompanyID: 1, Base: gorsk.Base{ID: 1}}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, loc); err != nil {
+		t.Error(err)
+	}
+
+	ldb := pgsql.NewLocation()
+
+	assert.Nil(t, ldb.Delete(db, loc))
+
+	_, err := ldb.View(db, 1)
+	assert.NotNil(t, err)
+}
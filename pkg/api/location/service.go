@@ -0,0 +1,55 @@
+package location
+
+import (
+	"context"
+
+	"github.com/figassis/goduck/pkg/api/location/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Service represents location application interface
+type Service interface {
+	Create(context.Context, gorsk.Location) (*gorsk.Location, error)
+	List(context.Context, *gorsk.Pagination) ([]gorsk.Location, error)
+	View(context.Context, int) (*gorsk.Location, error)
+	Delete(context.Context, int) error
+	Update(context.Context, *Update) (*gorsk.Location, error)
+}
+
+// New creates new location application service
+func New(db postgres.DB, ldb LDB, rbac RBAC) *Location {
+	return &Location{db: db, ldb: ldb, rbac: rbac}
+}
+
+// Initialize initalizes Location application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) *Location {
+	return New(postgres.Wrap(db), pgsql.NewLocation(), rbac)
+}
+
+// Location represents location application service
+type Location struct {
+	db   postgres.DB
+	ldb  LDB
+	rbac RBAC
+}
+
+// LDB represents location repository interface
+type LDB interface {
+	Create(orm.DB, gorsk.Location) (*gorsk.Location, error)
+	View(orm.DB, int) (*gorsk.Location, error)
+	List(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Location, error)
+	Update(orm.DB, *gorsk.Location) error
+	Delete(orm.DB, *gorsk.Location) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(context.Context) *gorsk.AuthUser
+	Enforce(context.Context, gorsk.Permission) error
+	EnforceRole(context.Context, gorsk.AccessRole) error
+	EnforceCompany(context.Context, gorsk.Permission, int) error
+	EnforceLocation(context.Context, gorsk.Permission, int) error
+}
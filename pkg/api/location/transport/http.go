@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/figassis/goduck/pkg/api/location"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents location http service
+type HTTP struct {
+	svc location.Service
+}
+
+// NewHTTP creates new location http service
+// Routes are authorized by require, which returns middleware checking user's permissions.
+func NewHTTP(svc location.Service, er *echo.Group, require func(...gorsk.Permission) echo.MiddlewareFunc) {
+	h := HTTP{svc}
+	lr := er.Group("/locations")
+	// swagger:route POST /v1/locations locations locationCreate
+	// Creates new company location.
+	// responses:
+	//  200: locationResp
+	//  400: errMsg
+	//  401: err
+	//  403: errMsg
+	//  500: err
+	lr.POST("", h.create, require(gorsk.PermLocationsCreate))
+
+	// swagger:operation GET /v1/locations locations listLocations
+	// ---
+	// summary: Returns list of locations.
+	// description: Returns list of locations. Depending on the user role requesting it, it may return all locations for SuperAdmin/Admin users, all company locations for Company admins, the user's own location for Location admins, and an error for non-admin users.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/locationListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	lr.GET("", h.list, require(gorsk.PermLocationsRead))
+
+	// swagger:operation GET /v1/locations/{id} locations getLocation
+	// ---
+	// summary: Returns a single location.
+	// description: Returns a single location by its ID.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of location
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/locationResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	lr.GET("/:id", h.view, require(gorsk.PermLocationsRead))
+
+	// swagger:operation PATCH /v1/locations/{id} locations locationUpdate
+	// ---
+	// summary: Updates location's information
+	// description: Updates location's name, address and active status. Only company admins and above can change the active status.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of location
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/locationUpdate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/locationResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	lr.PATCH("/:id", h.update, require(gorsk.PermLocationsUpdate))
+
+	// swagger:operation DELETE /v1/locations/{id} locations locationDelete
+	// ---
+	// summary: Deletes a location
+	// description: Deletes a location with requested ID.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of location
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	lr.DELETE("/:id", h.delete, require(gorsk.PermLocationsDelete))
+}
+
+// Location create request
+// swagger:model locationCreate
+type createReq struct {
+	Name      string `json:"name" validate:"required,min=2"`
+	Address   string `json:"address" validate:"required"`
+	Active    bool   `json:"active"`
+	CompanyID int    `json:"company_id" validate:"required"`
+}
+
+func (h *HTTP) create(c echo.Context) error {
+	r := new(createReq)
+
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	loc, err := h.svc.Create(c.Request().Context(), gorsk.Location{
+		Name:      r.Name,
+		Address:   r.Address,
+		Active:    r.Active,
+		CompanyID: r.CompanyID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, loc)
+}
+
+type listResponse struct {
+	Locations []gorsk.Location `json:"locations"`
+	Page      int              `json:"page"`
+}
+
+func (h *HTTP) list(c echo.Context) error {
+	p := new(gorsk.PaginationReq)
+	if err := c.Bind(p); err != nil {
+		return err
+	}
+
+	result, err := h.svc.List(c.Request().Context(), p.Transform())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, p.Page})
+}
+
+func (h *HTTP) view(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	result, err := h.svc.View(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Location update request
+// swagger:model locationUpdate
+type updateReq struct {
+	Name    string `json:"name,omitempty" validate:"omitempty,min=2"`
+	Address string `json:"address,omitempty"`
+	Active  *bool  `json:"active,omitempty"`
+}
+
+func (h *HTTP) update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	req := new(updateReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	loc, err := h.svc.Update(c.Request().Context(), &location.Update{
+		ID:      id,
+		Name:    req.Name,
+		Address: req.Address,
+		Active:  req.Active,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, loc)
+}
+
+func (h *HTTP) delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
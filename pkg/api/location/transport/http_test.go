@@ -0,0 +1,407 @@
+package transport_test
+
+import (
+	"context"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/location"
+	"github.com/figassis/goduck/pkg/api/location/transport"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/server"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Location
+		ldb        *mockdb.Location
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"name":"HQ","address":"Main St"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"name":"HQ","address":"Main St","company_id":2}`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"name":"HQ","address":"Main St","company_id":2,"active":true}`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			ldb: &mockdb.Location{
+				CreateFn: func(db orm.DB, l gorsk.Location) (*gorsk.Location, error) {
+					l.ID = 1
+					l.CreatedAt = mock.TestTime(2018)
+					l.UpdatedAt = mock.TestTime(2018)
+					return &l, nil
+				},
+			},
+			wantResp: &gorsk.Location{
+				Base: gorsk.Base{
+					ID:        1,
+					CreatedAt: mock.TestTime(2018),
+					UpdatedAt: mock.TestTime(2018),
+				},
+				Name:      "HQ",
+				Address:   "Main St",
+				Active:    true,
+				CompanyID: 2,
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(location.New(mockdb.DB{}, tt.ldb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/locations"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Location)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	type listResponse struct {
+		Locations []gorsk.Location `json:"locations"`
+		Page      int              `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		ldb        *mockdb.Location
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on query list",
+			req:  `?limit=100&page=1`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, Role: gorsk.UserRole}
+				}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `?limit=100&page=0`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.CompanyAdminRole}
+				}},
+			ldb: &mockdb.Location{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Location, error) {
+					if q.Query == "company_id = ?" && q.ID == 2 {
+						return []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ", CompanyID: 2}}, nil
+					}
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Locations: []gorsk.Location{{Base: gorsk.Base{ID: 3}, Name: "HQ", CompanyID: 2}},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(location.New(mockdb.DB{}, tt.ldb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/locations" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Location
+		ldb        *mockdb.Location
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `1`,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `1`,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, Name: "HQ", CompanyID: 2}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return nil
+				},
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp:   &gorsk.Location{Base: gorsk.Base{ID: 1}, Name: "HQ", CompanyID: 2},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(location.New(mockdb.DB{}, tt.ldb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/locations/" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Location)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		id         string
+		wantStatus int
+		wantResp   *gorsk.Location
+		ldb        *mockdb.Location
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on validation",
+			id:         `1`,
+			req:        `{"name":"h"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			req:  `{"name":"Warehouse","address":"Dock 4"}`,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, Name: "HQ", Address: "Main St", CompanyID: 2}, nil
+				},
+				UpdateFn: func(db orm.DB, l *gorsk.Location) error {
+					l.UpdatedAt = mock.TestTime(2010)
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				},
+				EnforceLocationFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &gorsk.Location{
+				Base:      gorsk.Base{ID: 1, UpdatedAt: mock.TestTime(2010)},
+				Name:      "Warehouse",
+				Address:   "Dock 4",
+				CompanyID: 2,
+			},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(location.New(mockdb.DB{}, tt.ldb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/locations/" + tt.id
+			req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Location)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		ldb        *mockdb.Location
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   `1`,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   `1`,
+			ldb: &mockdb.Location{
+				ViewFn: func(db orm.DB, id int) (*gorsk.Location, error) {
+					return &gorsk.Location{Base: gorsk.Base{ID: id}, CompanyID: 2}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Location) error {
+					return nil
+				},
+			},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(location.New(mockdb.DB{}, tt.ldb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/locations/" + tt.id
+			req, _ := http.NewRequest("DELETE", path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
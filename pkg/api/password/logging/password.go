@@ -1,9 +1,9 @@
 package password
 
 import (
+	"context"
 	"time"
 
-	"github.com/labstack/echo"
 	"github.com/figassis/goduck/pkg/api/password"
 	"github.com/figassis/goduck/pkg/utl/model"
 )
@@ -25,10 +25,10 @@ type LogService struct {
 const name = "password"
 
 // Change logging
-func (ls *LogService) Change(c echo.Context, id int, oldPass, newPass string) (err error) {
+func (ls *LogService) Change(ctx context.Context, id int, oldPass, newPass string) (err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Change password request", err,
 			map[string]interface{}{
 				"req":  id,
@@ -36,5 +36,49 @@ func (ls *LogService) Change(c echo.Context, id int, oldPass, newPass string) (e
 			},
 		)
 	}(time.Now())
-	return ls.Service.Change(c, id, oldPass, newPass)
+	return ls.Service.Change(ctx, id, oldPass, newPass)
+}
+
+// Forgot logging
+func (ls *LogService) Forgot(ctx context.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Forgot password request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Forgot(ctx, email)
+}
+
+// Reset logging
+func (ls *LogService) Reset(ctx context.Context, token, newPass string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Reset password request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Reset(ctx, token, newPass)
+}
+
+// Expire logging
+func (ls *LogService) Expire(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Expire password request", err,
+			map[string]interface{}{
+				"req":  id,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Expire(ctx, id)
 }
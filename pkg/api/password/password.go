@@ -1,24 +1,37 @@
 package password
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
+	"github.com/figassis/goduck/pkg/utl/mail"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/secure"
 )
 
 // Custom errors
 var (
 	ErrIncorrectPassword = echo.NewHTTPError(http.StatusBadRequest, "incorrect old password")
 	ErrInsecurePassword  = echo.NewHTTPError(http.StatusBadRequest, "insecure password")
+	ErrBreachedPassword  = echo.NewHTTPError(http.StatusBadRequest, "password found in breach")
+	ErrInvalidToken      = echo.NewHTTPError(http.StatusBadRequest, "invalid or expired reset token")
+	ErrPasswordReused    = echo.NewHTTPError(http.StatusBadRequest, "password was used recently")
 )
 
 // Change changes user's password
-func (p *Password) Change(c echo.Context, userID int, oldPass, newPass string) error {
-	if err := p.rbac.EnforceUser(c, userID); err != nil {
+func (p *Password) Change(ctx context.Context, userID int, oldPass, newPass string) error {
+	db := p.db.WithContext(ctx)
+	if err := p.rbac.EnforceUser(ctx, gorsk.PermUsersUpdate, userID); err != nil {
 		return err
 	}
 
-	u, err := p.udb.View(p.db, userID)
+	u, err := p.udb.View(db, userID)
 	if err != nil {
 		return err
 	}
@@ -27,11 +40,153 @@ func (p *Password) Change(c echo.Context, userID int, oldPass, newPass string) e
 		return ErrIncorrectPassword
 	}
 
+	if err := p.check(ctx, u, newPass); err != nil {
+		return err
+	}
+
+	return p.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		return p.change(ctx, tx, u, newPass)
+	})
+}
+
+// Forgot issues a password reset token and emails it to the user.
+// Unknown and inactive accounts are silently ignored, so the endpoint can't be used to discover emails.
+func (p *Password) Forgot(ctx context.Context, email string) error {
+	db := p.db.WithContext(ctx)
+	u, err := p.udb.FindByEmail(db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !u.Active {
+		return nil
+	}
+
+	token, err := p.sec.RandomToken(secure.ResetTokenPrefix)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.rdb.Create(db, gorsk.PasswordReset{
+		UserID:    u.ID,
+		TokenHash: p.sec.TokenHash(token),
+		ExpiresAt: time.Now().Add(p.cfg.ResetDuration),
+	}); err != nil {
+		return err
+	}
+
+	return p.mailer.Send(mail.Message{
+		To:      u.Email,
+		Subject: "Password reset",
+		Body:    p.resetBody(token),
+	})
+}
+
+// Reset sets user's password using a previously issued reset token.
+// All user's sessions are terminated and issued access tokens revoked, as whoever knew the old password may be using them.
+func (p *Password) Reset(ctx context.Context, token, newPass string) error {
+	db := p.db.WithContext(ctx)
+	r, err := p.rdb.FindByToken(db, p.sec.TokenHash(token))
+	if err == pg.ErrNoRows {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if !r.Valid(time.Now()) {
+		return ErrInvalidToken
+	}
+
+	u, err := p.udb.View(db, r.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.check(ctx, u, newPass); err != nil {
+		return err
+	}
+
+	if err := p.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		if err := p.rdb.Use(tx, r); err != nil {
+			if err == pg.ErrNoRows {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if err := p.change(ctx, tx, u, newPass); err != nil {
+			return err
+		}
+		return p.sdb.DeleteByUser(tx, u.ID)
+	}); err != nil {
+		return err
+	}
+
+	return p.tr.RevokeUser(ctx, u.ID)
+}
+
+// Expire forces user to change password on next login.
+// Only users with a more privileged role than the user's may expire its password.
+func (p *Password) Expire(ctx context.Context, userID int) error {
+	db := p.db.WithContext(ctx)
+	if err := p.rbac.EnforceUser(ctx, gorsk.PermPasswordsExpire, userID); err != nil {
+		return err
+	}
+
+	u, err := p.udb.View(db, userID)
+	if err != nil {
+		return err
+	}
+	if err := p.rbac.IsLowerRole(ctx, u.Role.AccessLevel); err != nil {
+		return err
+	}
+
+	u.PasswordChangeRequired = true
+	return p.udb.Update(db, u)
+}
+
+// check enforces password policy on user's new password
+func (p *Password) check(ctx context.Context, u *gorsk.User, newPass string) error {
 	if !p.sec.Password(newPass, u.FirstName, u.LastName, u.Username, u.Email) {
 		return ErrInsecurePassword
 	}
+	breached, err := p.sec.Breached(newPass)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return ErrBreachedPassword
+	}
+	reused, err := p.hist.Reused(ctx, u, newPass)
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrPasswordReused
+	}
+	return nil
+}
 
-	u.ChangePassword(p.sec.Hash(newPass))
+// change replaces user's password, keeping the previous one in the history
+func (p *Password) change(ctx context.Context, db orm.DB, u *gorsk.User, newPass string) error {
+	hash, err := p.sec.Hash(newPass)
+	if err != nil {
+		return err
+	}
+	if err := p.hist.Add(ctx, db, u); err != nil {
+		return err
+	}
+	u.ChangePassword(hash)
+
+	return p.udb.Update(db, u)
+}
 
-	return p.udb.Update(p.db, u)
+func (p *Password) resetBody(token string) string {
+	if p.cfg.ResetURL == "" {
+		return fmt.Sprintf("Use the following token to reset your password: %s", token)
+	}
+	return fmt.Sprintf("Visit the following link to reset your password: %s?token=%s", p.cfg.ResetURL, url.QueryEscape(token))
 }
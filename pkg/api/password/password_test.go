@@ -1,20 +1,27 @@
 package password_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/figassis/goduck/pkg/api/password"
+	"github.com/figassis/goduck/pkg/utl/mail"
 
 	"github.com/figassis/goduck/pkg/utl/mock"
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
 
 	"github.com/stretchr/testify/assert"
 )
 
+var noHistory = pwhistory.New(pwhistory.NewMemory(), 0, nil)
+
 func TestChange(t *testing.T) {
 	type args struct {
 		oldpass string
@@ -33,7 +40,7 @@ func TestChange(t *testing.T) {
 			name: "Fail on EnforceUser",
 			args: args{id: 1},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: true,
@@ -43,7 +50,7 @@ func TestChange(t *testing.T) {
 			args:    args{id: 1},
 			wantErr: true,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			udb: &mockdb.User{
@@ -59,7 +66,7 @@ func TestChange(t *testing.T) {
 			name: "Fail on PasswordMatch",
 			args: args{id: 1, oldpass: "hunter123"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			wantErr: true,
@@ -80,7 +87,7 @@ func TestChange(t *testing.T) {
 			name: "Fail on InsecurePassword",
 			args: args{id: 1, oldpass: "hunter123"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			wantErr: true,
@@ -104,7 +111,7 @@ func TestChange(t *testing.T) {
 			name: "Success",
 			args: args{id: 1, oldpass: "hunter123", newpass: "password"},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			udb: &mockdb.User{
@@ -124,24 +131,387 @@ func TestChange(t *testing.T) {
 				PasswordFn: func(string, ...string) bool {
 					return true
 				},
-				HashFn: func(string) string {
-					return "hash3d"
+				BreachedFn: func(string) (bool, error) {
+					return false, nil
+				},
+				HashFn: func(string) (string, error) {
+					return "hash3d", nil
 				},
 			},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := password.New(nil, tt.udb, tt.rbac, tt.sec)
-			err := s.Change(nil, tt.args.id, tt.args.oldpass, tt.args.newpass)
+			s := password.New(mockdb.DB{}, tt.udb, nil, nil, noHistory, nil, tt.rbac, tt.sec, nil, nil)
+			err := s.Change(context.Background(), tt.args.id, tt.args.oldpass, tt.args.newpass)
 			assert.Equal(t, tt.wantErr, err != nil)
 			// Check whether password was changed
 		})
 	}
 }
 
+func TestForgot(t *testing.T) {
+	cases := []struct {
+		name     string
+		email    string
+		wantErr  bool
+		wantSent bool
+		udb      *mockdb.User
+		rdb      *mockdb.PasswordReset
+		sec      *mock.Secure
+		mailer   *mock.Mailer
+	}{
+		{
+			name:  "Unknown email",
+			email: "unknown@mail.com",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Fail on FindByEmail",
+			email:   "johndoe@mail.com",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:  "Inactive user",
+			email: "johndoe@mail.com",
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com"}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on Create",
+			email:   "johndoe@mail.com",
+			wantErr: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true}, nil
+				},
+			},
+			rdb: &mockdb.PasswordReset{
+				CreateFn: func(orm.DB, gorsk.PasswordReset) (*gorsk.PasswordReset, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			sec: &mock.Secure{
+				RandomTokenFn: func(string) (string, error) {
+					return "token", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+		},
+		{
+			name:     "Success",
+			email:    "johndoe@mail.com",
+			wantSent: true,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true}, nil
+				},
+			},
+			rdb: &mockdb.PasswordReset{
+				CreateFn: func(db orm.DB, pr gorsk.PasswordReset) (*gorsk.PasswordReset, error) {
+					if pr.UserID != 1 || pr.TokenHash != "hashedtoken" || !pr.Valid(time.Now()) {
+						return nil, gorsk.ErrGeneric
+					}
+					return &pr, nil
+				},
+			},
+			sec: &mock.Secure{
+				RandomTokenFn: func(prefix string) (string, error) {
+					return prefix + "token", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := mail.NewMemory("noreply@mail.com")
+			s := password.New(mockdb.DB{}, tt.udb, tt.rdb, nil, noHistory, nil, nil, tt.sec, mailer, &password.Config{ResetURL: "https://example.com/reset"})
+			err := s.Forgot(context.Background(), tt.email)
+			assert.Equal(t, tt.wantErr, err != nil)
+			msgs := mailer.Messages()
+			assert.Equal(t, tt.wantSent, len(msgs) == 1)
+			if tt.wantSent {
+				assert.Equal(t, tt.email, msgs[0].To)
+				assert.Contains(t, msgs[0].Body, "https://example.com/reset?token=pr_token")
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	validReset := func(orm.DB, string) (*gorsk.PasswordReset, error) {
+		return &gorsk.PasswordReset{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+	sec := &mock.Secure{
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+		PasswordFn: func(pass string, _ ...string) bool {
+			return pass != "insecure"
+		},
+		BreachedFn: func(pass string) (bool, error) {
+			return pass == "breached", nil
+		},
+		HashFn: func(string) (string, error) {
+			return "hash3d", nil
+		},
+	}
+	hist := pwhistory.New(pwhistory.NewMemory(), 3, func(hash, pass string) bool {
+		return hash == "hash:"+pass
+	})
+	cases := []struct {
+		name        string
+		newpass     string
+		wantErr     error
+		wantUpdated bool
+		udb         *mockdb.User
+		rdb         *mockdb.PasswordReset
+	}{
+		{
+			name:    "Unknown token",
+			wantErr: password.ErrInvalidToken,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.PasswordReset, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+		},
+		{
+			name:    "Expired token",
+			wantErr: password.ErrInvalidToken,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.PasswordReset, error) {
+					return &gorsk.PasswordReset{UserID: 1, ExpiresAt: mock.TestTime(2000)}, nil
+				},
+			},
+		},
+		{
+			name:    "Used token",
+			wantErr: password.ErrInvalidToken,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.PasswordReset, error) {
+					return &gorsk.PasswordReset{UserID: 1, ExpiresAt: time.Now().Add(time.Hour), UsedAt: time.Now()}, nil
+				},
+			},
+		},
+		{
+			name:    "Fail on View",
+			wantErr: gorsk.ErrGeneric,
+			rdb:     &mockdb.PasswordReset{FindByTokenFn: validReset},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Insecure password",
+			newpass: "insecure",
+			wantErr: password.ErrInsecurePassword,
+			rdb:     &mockdb.PasswordReset{FindByTokenFn: validReset},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}}, nil
+				},
+			},
+		},
+		{
+			name:    "Breached password",
+			newpass: "breached",
+			wantErr: password.ErrBreachedPassword,
+			rdb:     &mockdb.PasswordReset{FindByTokenFn: validReset},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}}, nil
+				},
+			},
+		},
+		{
+			name:    "Reused password",
+			newpass: "oldpassword",
+			wantErr: password.ErrPasswordReused,
+			rdb:     &mockdb.PasswordReset{FindByTokenFn: validReset},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Password: "hash:oldpassword"}, nil
+				},
+			},
+		},
+		{
+			name:    "Token used concurrently",
+			newpass: "newpassword",
+			wantErr: password.ErrInvalidToken,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: validReset,
+				UseFn: func(orm.DB, *gorsk.PasswordReset) error {
+					return pg.ErrNoRows
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}}, nil
+				},
+			},
+		},
+		{
+			name:        "Success",
+			newpass:     "newpassword",
+			wantUpdated: true,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: validReset,
+				UseFn: func(orm.DB, *gorsk.PasswordReset) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Password: "oldhash"}, nil
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			if tt.udb != nil {
+				tt.udb.UpdateFn = func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				}
+			}
+			var ended, revoked int
+			sdb := &mockdb.Session{
+				DeleteByUserFn: func(db orm.DB, userID int) error {
+					ended = userID
+					return nil
+				},
+			}
+			jwt := &mock.JWT{
+				RevokeUserFn: func(ctx context.Context, userID int) error {
+					revoked = userID
+					return nil
+				},
+			}
+			s := password.New(mockdb.DB{}, tt.udb, tt.rdb, sdb, hist, jwt, nil, sec, nil, nil)
+			err := s.Reset(context.Background(), "token", tt.newpass)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantUpdated, updated != nil)
+			if tt.wantUpdated {
+				assert.Equal(t, "hash3d", updated.Password)
+				assert.Equal(t, 1, ended)
+				assert.Equal(t, 1, revoked)
+			} else {
+				assert.Zero(t, ended)
+				assert.Zero(t, revoked)
+			}
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  error
+		wantData *gorsk.User
+		rbac     *mock.RBAC
+		udb      *mockdb.User
+	}{
+		{
+			name:    "Fail on EnforceUser",
+			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return gorsk.ErrGeneric
+				}},
+		},
+		{
+			name:    "Fail on View",
+			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return nil, gorsk.ErrGeneric
+				},
+			},
+		},
+		{
+			name:    "Fail on IsLowerRole",
+			wantErr: echo.ErrForbidden,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				IsLowerRoleFn: func(_ context.Context, r gorsk.AccessRole) error {
+					if r != gorsk.AdminRole {
+						return gorsk.ErrGeneric
+					}
+					return echo.ErrForbidden
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Role: &gorsk.Role{AccessLevel: gorsk.AdminRole}}, nil
+				},
+			},
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(_ context.Context, p gorsk.Permission, _ int) error {
+					if p != gorsk.PermPasswordsExpire {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return nil
+				}},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 1}, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}, nil
+				},
+			},
+			wantData: &gorsk.User{Base: gorsk.Base{ID: 1}, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}, PasswordChangeRequired: true},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			if tt.udb != nil {
+				tt.udb.UpdateFn = func(db orm.DB, u *gorsk.User) error {
+					updated = u
+					return nil
+				}
+			}
+			s := password.New(mockdb.DB{}, tt.udb, nil, nil, noHistory, nil, tt.rbac, nil, nil, nil)
+			err := s.Expire(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, updated)
+		})
+	}
+}
+
 func TestInitialize(t *testing.T) {
-	p := password.Initialize(nil, nil, nil)
+	p := password.Initialize(nil, nil, nil, nil, nil, nil, nil)
 	if p == nil {
 		t.Error("password service not initialized")
 	}
@@ -0,0 +1,48 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewReset returns a new password reset database instance
+func NewReset() *Reset {
+	return &Reset{}
+}
+
+// Reset represents the client for password_resets table
+type Reset struct{}
+
+// Create creates a new password reset on database
+func (r *Reset) Create(db orm.DB, pr gorsk.PasswordReset) (*gorsk.PasswordReset, error) {
+	if err := db.Insert(&pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// FindByToken queries for single password reset by token hash
+func (r *Reset) FindByToken(db orm.DB, hash string) (*gorsk.PasswordReset, error) {
+	var pr = new(gorsk.PasswordReset)
+	if err := db.Model(pr).Where("token_hash = ?", hash).Select(); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// Use marks password reset as used.
+// Returns pg.ErrNoRows if it has already been used.
+func (r *Reset) Use(db orm.DB, pr *gorsk.PasswordReset) error {
+	pr.UsedAt = time.Now()
+	res, err := db.Model(pr).Column("used_at", "updated_at").WherePK().Where("used_at IS NULL").Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
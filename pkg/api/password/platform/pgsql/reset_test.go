@@ -0,0 +1,51 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/password/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReset(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.PasswordReset{})
+
+	rdb := pgsql.NewReset()
+
+	created, err := rdb.Create(db, gorsk.PasswordReset{
+		UserID:    1,
+		TokenHash: "hashedtoken",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Token does not exist", func(t *testing.T) {
+		_, err := rdb.FindByToken(db, "unknown")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Find and use token", func(t *testing.T) {
+		pr, err := rdb.FindByToken(db, "hashedtoken")
+		assert.Nil(t, err)
+		assert.Equal(t, created.ID, pr.ID)
+		assert.Equal(t, 1, pr.UserID)
+		assert.Nil(t, rdb.Use(db, pr))
+	})
+
+	t.Run("Token already used", func(t *testing.T) {
+		pr, err := rdb.FindByToken(db, "hashedtoken")
+		assert.Nil(t, err)
+		assert.False(t, pr.Valid(time.Now()))
+		assert.Equal(t, pg.ErrNoRows, rdb.Use(db, &gorsk.PasswordReset{Base: gorsk.Base{ID: pr.ID}}))
+	})
+}
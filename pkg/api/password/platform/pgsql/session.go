@@ -0,0 +1,23 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewSession returns a new session database instance
+func NewSession() *Session {
+	return &Session{}
+}
+
+// Session represents the client for session table
+type Session struct{}
+
+// DeleteByUser terminates all user's sessions
+func (s *Session) DeleteByUser(db orm.DB, userID int) error {
+	_, err := db.Model(&gorsk.Session{}).Set("deleted_at = ?", time.Now()).
+		Where("user_id = ?", userID).Where("deleted_at is null").Update()
+	return err
+}
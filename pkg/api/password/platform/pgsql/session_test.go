@@ -0,0 +1,35 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/password/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Session{})
+
+	for _, s := range []gorsk.Session{
+		{UserID: 1, TokenHash: "laptophash", LastUsed: mock.TestTime(2018)},
+		{UserID: 1, TokenHash: "phonehash", LastUsed: mock.TestTime(2019)},
+		{UserID: 2, TokenHash: "otherhash", LastUsed: mock.TestTime(2019)},
+	} {
+		if err := db.Insert(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Nil(t, pgsql.NewSession().DeleteByUser(db, 1))
+
+	var left []gorsk.Session
+	assert.Nil(t, db.Model(&left).Select())
+	assert.Len(t, left, 1)
+	assert.Equal(t, 2, left[0].UserID)
+}
@@ -13,11 +13,19 @@ func NewUser() *User {
 // User represents the client for user table
 type User struct{}
 
-// View returns single user by ID
+// View returns single user by ID, with its role
 func (u *User) View(db orm.DB, id int) (*gorsk.User, error) {
 	user := &gorsk.User{Base: gorsk.Base{ID: id}}
-	err := db.Select(user)
-	if err != nil {
+	if err := db.Model(user).Column("user.*", "Role").WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// FindByEmail queries for single user by email
+func (u *User) FindByEmail(db orm.DB, email string) (*gorsk.User, error) {
+	var user = new(gorsk.User)
+	if err := db.Model(user).Where("lower(email) = lower(?)", email).Select(); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -52,6 +52,7 @@ func TestView(t *testing.T) {
 		Name:        "SUPER_ADMIN"}, cases[1].wantData); err != nil {
 		t.Error(err)
 	}
+	cases[1].wantData.Role = &gorsk.Role{ID: 1, AccessLevel: 1, Name: "SUPER_ADMIN"}
 
 	udb := pgsql.NewUser()
 
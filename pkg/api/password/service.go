@@ -1,55 +1,124 @@
 package password
 
 import (
+	"context"
+	"time"
+
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
-	"github.com/labstack/echo"
 	"github.com/figassis/goduck/pkg/api/password/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mail"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
 )
 
 // Service represents password application interface
 type Service interface {
-	Change(echo.Context, int, string, string) error
+	Change(context.Context, int, string, string) error
+	Forgot(context.Context, string) error
+	Reset(context.Context, string, string) error
+	Expire(context.Context, int) error
+}
+
+// Config represents password reset configuration
+type Config struct {
+	// ResetDuration is the lifetime of an issued reset token
+	ResetDuration time.Duration
+	// ResetURL is the address included in reset emails, token is appended as a query parameter
+	ResetURL string
 }
 
+// DefaultResetDuration is used when reset token lifetime is not configured
+const DefaultResetDuration = time.Hour
+
 // New creates new password application service
-func New(db *pg.DB, udb UserDB, rbac RBAC, sec Securer) *Password {
+func New(db postgres.DB, udb UserDB, rdb ResetDB, sdb SessionDB, hist History, tr TokenRevoker, rbac RBAC, sec Securer, mailer Mailer, cfg *Config) *Password {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.ResetDuration == 0 {
+		cfg.ResetDuration = DefaultResetDuration
+	}
 	return &Password{
-		db:   db,
-		udb:  udb,
-		rbac: rbac,
-		sec:  sec,
+		db:     db,
+		udb:    udb,
+		rdb:    rdb,
+		sdb:    sdb,
+		hist:   hist,
+		tr:     tr,
+		rbac:   rbac,
+		sec:    sec,
+		mailer: mailer,
+		cfg:    cfg,
 	}
 }
 
 // Initialize initalizes password application service with defaults
-func Initialize(db *pg.DB, rbac RBAC, sec Securer) *Password {
-	return New(db, pgsql.NewUser(), rbac, sec)
+func Initialize(db *pg.DB, hist History, tr TokenRevoker, rbac RBAC, sec Securer, mailer Mailer, cfg *Config) *Password {
+	return New(postgres.Wrap(db), pgsql.NewUser(), pgsql.NewReset(), pgsql.NewSession(), hist, tr, rbac, sec, mailer, cfg)
 }
 
 // Password represents password application service
 type Password struct {
-	db   *pg.DB
-	udb  UserDB
-	rbac RBAC
-	sec  Securer
+	db     postgres.DB
+	udb    UserDB
+	rdb    ResetDB
+	sdb    SessionDB
+	hist   History
+	tr     TokenRevoker
+	rbac   RBAC
+	sec    Securer
+	mailer Mailer
+	cfg    *Config
 }
 
 // UserDB represents user repository interface
 type UserDB interface {
 	View(orm.DB, int) (*gorsk.User, error)
+	FindByEmail(orm.DB, string) (*gorsk.User, error)
 	Update(orm.DB, *gorsk.User) error
 }
 
+// ResetDB represents password reset repository interface
+type ResetDB interface {
+	Create(orm.DB, gorsk.PasswordReset) (*gorsk.PasswordReset, error)
+	FindByToken(orm.DB, string) (*gorsk.PasswordReset, error)
+	Use(orm.DB, *gorsk.PasswordReset) error
+}
+
+// SessionDB represents session repository interface
+type SessionDB interface {
+	DeleteByUser(orm.DB, int) error
+}
+
+// History represents previous passwords interface
+type History interface {
+	Reused(context.Context, *gorsk.User, string) (bool, error)
+	Add(context.Context, orm.DB, *gorsk.User) error
+}
+
+// TokenRevoker represents access token revocation interface
+type TokenRevoker interface {
+	RevokeUser(context.Context, int) error
+}
+
 // Securer represents security interface
 type Securer interface {
-	Hash(string) string
+	Hash(string) (string, error)
 	HashMatchesPassword(string, string) bool
 	Password(string, ...string) bool
+	Breached(string) (bool, error)
+	RandomToken(string) (string, error)
+	TokenHash(string) string
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
 }
 
 // RBAC represents role-based-access-control interface
 type RBAC interface {
-	EnforceUser(echo.Context, int) error
+	EnforceUser(context.Context, gorsk.Permission, int) error
+	IsLowerRole(context.Context, gorsk.AccessRole) error
 }
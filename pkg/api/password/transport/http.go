@@ -17,8 +17,49 @@ type HTTP struct {
 }
 
 // NewHTTP creates new password http service
-func NewHTTP(svc password.Service, er *echo.Group) {
+func NewHTTP(svc password.Service, e *echo.Echo, er *echo.Group) {
 	h := HTTP{svc}
+
+	// swagger:operation POST /password/forgot password pwForgot
+	// ---
+	// summary: Requests password reset.
+	// description: Sends an email with a one-time reset token if an active user with the given email exists. Always responds with 200.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwForgot"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/password/forgot", h.forgot)
+
+	// swagger:operation POST /password/reset password pwReset
+	// ---
+	// summary: Resets user's password.
+	// description: If reset token is valid and unused, user's password will be replaced with new password.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/pwReset"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/password/reset", h.reset)
+
 	pr := er.Group("/password")
 
 	// swagger:operation PATCH /v1/password/{id} password pwChange
@@ -49,6 +90,29 @@ func NewHTTP(svc password.Service, er *echo.Group) {
 	//   "500":
 	//     "$ref": "#/responses/err"
 	pr.PATCH("/:id", h.change)
+
+	// swagger:operation POST /v1/password/{id}/expire password pwExpire
+	// ---
+	// summary: Forces user to change password.
+	// description: User will have to change password on next login before receiving tokens.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of user
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	pr.POST("/:id/expire", h.expire)
 }
 
 // Custom errors
@@ -60,8 +124,8 @@ var (
 // swagger:model pwChange
 type changeReq struct {
 	ID                 int    `json:"-"`
-	OldPassword        string `json:"old_password" validate:"required,min=8"`
-	NewPassword        string `json:"new_password" validate:"required,min=8"`
+	OldPassword        string `json:"old_password" validate:"required"`
+	NewPassword        string `json:"new_password" validate:"required"`
 	NewPasswordConfirm string `json:"new_password_confirm" validate:"required"`
 }
 
@@ -80,7 +144,64 @@ func (h *HTTP) change(c echo.Context) error {
 		return ErrPasswordsNotMaching
 	}
 
-	if err := h.svc.Change(c, id, p.OldPassword, p.NewPassword); err != nil {
+	if err := h.svc.Change(c.Request().Context(), id, p.OldPassword, p.NewPassword); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *HTTP) expire(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Expire(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Password forgot request
+// swagger:model pwForgot
+type forgotReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *HTTP) forgot(c echo.Context) error {
+	r := new(forgotReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.Forgot(c.Request().Context(), r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Password reset request
+// swagger:model pwReset
+type resetReq struct {
+	Token              string `json:"token" validate:"required"`
+	NewPassword        string `json:"new_password" validate:"required"`
+	NewPasswordConfirm string `json:"new_password_confirm" validate:"required"`
+}
+
+func (h *HTTP) reset(c echo.Context) error {
+	r := new(resetReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.NewPassword != r.NewPasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+
+	if err := h.svc.Reset(c.Request().Context(), r.Token, r.NewPassword); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package transport_test
 
 import (
+	"context"
 	"bytes"
 	"net/http"
 	"net/http/httptest"
@@ -12,13 +13,17 @@ import (
 	"github.com/figassis/goduck/pkg/utl/mock"
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
 	"github.com/figassis/goduck/pkg/utl/server"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 )
 
+var hist = pwhistory.New(pwhistory.NewMemory(), 0, nil)
+
 func TestChangePassword(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -36,7 +41,7 @@ func TestChangePassword(t *testing.T) {
 		},
 		{
 			name:       "Fail on Bind",
-			req:        `{"new_password":"new","old_password":"my_old_password", "new_password_confirm":"new"}`,
+			req:        `{"new_password":"","old_password":"my_old_password", "new_password_confirm":""}`,
 			wantStatus: http.StatusBadRequest,
 			id:         "1",
 		},
@@ -50,7 +55,7 @@ func TestChangePassword(t *testing.T) {
 			name: "Fail on RBAC",
 			req:  `{"new_password":"newpassw","old_password":"oldpassw", "new_password_confirm":"newpassw"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -61,7 +66,7 @@ func TestChangePassword(t *testing.T) {
 			name: "Success",
 			req:  `{"new_password":"newpassw","old_password":"oldpassw", "new_password_confirm":"newpassw"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				},
 			},
@@ -83,8 +88,11 @@ func TestChangePassword(t *testing.T) {
 				PasswordFn: func(string, ...string) bool {
 					return true
 				},
-				HashFn: func(string) string {
-					return "hashedPassword"
+				BreachedFn: func(string) (bool, error) {
+					return false, nil
+				},
+				HashFn: func(string) (string, error) {
+					return "hashedPassword", nil
 				},
 			},
 			wantStatus: http.StatusOK,
@@ -97,7 +105,7 @@ func TestChangePassword(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(password.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(password.New(mockdb.DB{}, tt.udb, nil, nil, hist, nil, tt.rbac, tt.sec, nil, nil), r, rg)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/password/" + tt.id
@@ -115,3 +123,173 @@ func TestChangePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestExpirePassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		udb        *mockdb.User
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "NaN",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			id:   "1",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			id:   "1",
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return nil
+				},
+			},
+			udb: &mockdb.User{
+				ViewFn: func(orm.DB, int) (*gorsk.User, error) {
+					return &gorsk.User{Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.User) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(password.New(mockdb.DB{}, tt.udb, nil, nil, hist, nil, tt.rbac, nil, nil, nil), r, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/password/"+tt.id+"/expire", "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"notanemail"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Unknown email",
+			req:  `{"email":"unknown@mail.com"}`,
+			udb: &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(password.New(mockdb.DB{}, tt.udb, nil, nil, hist, nil, nil, nil, nil, nil), r, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/password/forgot", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		rdb        *mockdb.PasswordReset
+		sec        *mock.Secure
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"token":"token","new_password":"","new_password_confirm":""}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Different passwords",
+			req:        `{"token":"token","new_password":"newpassw","new_password_confirm":"newpassx"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			req:  `{"token":"token","new_password":"newpassw","new_password_confirm":"newpassw"}`,
+			rdb: &mockdb.PasswordReset{
+				FindByTokenFn: func(orm.DB, string) (*gorsk.PasswordReset, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+			sec: &mock.Secure{
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(password.New(mockdb.DB{}, nil, tt.rdb, nil, hist, nil, nil, tt.sec, nil, nil), r, rg)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/password/reset", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
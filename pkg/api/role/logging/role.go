@@ -0,0 +1,104 @@
+package role
+
+import (
+	"context"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/role"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// New creates new role logging service
+func New(svc role.Service, logger gorsk.Logger) *LogService {
+	return &LogService{
+		Service: svc,
+		logger:  logger,
+	}
+}
+
+// LogService represents role logging service
+type LogService struct {
+	role.Service
+	logger gorsk.Logger
+}
+
+const name = "role"
+
+// Create logging
+func (ls *LogService) Create(ctx context.Context, req gorsk.Role) (resp *gorsk.Role, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Create role request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Create(ctx, req)
+}
+
+// List logging
+func (ls *LogService) List(ctx context.Context, req *gorsk.Pagination) (resp []gorsk.Role, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "List role request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.List(ctx, req)
+}
+
+// View logging
+func (ls *LogService) View(ctx context.Context, req gorsk.AccessRole) (resp *gorsk.Role, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "View role request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.View(ctx, req)
+}
+
+// Delete logging
+func (ls *LogService) Delete(ctx context.Context, req gorsk.AccessRole) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Delete role request", err,
+			map[string]interface{}{
+				"req":  req,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Delete(ctx, req)
+}
+
+// Update logging
+func (ls *LogService) Update(ctx context.Context, req *role.Update) (resp *gorsk.Role, err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Update role request", err,
+			map[string]interface{}{
+				"req":  req,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Update(ctx, req)
+}
@@ -0,0 +1,139 @@
+package pgsql
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewRole returns a new role database instance
+func NewRole() *Role {
+	return &Role{}
+}
+
+// Role represents the client for roles table
+type Role struct{}
+
+// Custom errors
+var (
+	ErrAlreadyExists = echo.NewHTTPError(http.StatusConflict, "Role name already exists.")
+	ErrInUse         = echo.NewHTTPError(http.StatusConflict, "Role is assigned to users.")
+)
+
+// Create creates a new role with its permissions, db should be a transaction.
+// Role names are unique among global roles and roles of the same company.
+func (r *Role) Create(db orm.DB, role gorsk.Role) (*gorsk.Role, error) {
+	exists, err := db.Model((*gorsk.Role)(nil)).Where("lower(name) = ?", strings.ToLower(role.Name)).
+		Where("company_id IS NULL OR company_id = ?", role.CompanyID).Exists()
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAlreadyExists
+	}
+
+	if err := db.Insert(&role); err != nil {
+		return nil, err
+	}
+	if err := setPermissions(db, &role); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// View returns single role by ID, including its permissions
+func (r *Role) View(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+	var role = &gorsk.Role{ID: id}
+	if err := db.Model(role).WherePK().Select(); err != nil {
+		return nil, err
+	}
+	if err := permissions(db, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// Update updates role's info and replaces its permissions, db should be a transaction
+func (r *Role) Update(db orm.DB, role *gorsk.Role) error {
+	if err := db.Update(role); err != nil {
+		return err
+	}
+	return setPermissions(db, role)
+}
+
+// List returns list of all roles retrievable for the current user, including their permissions
+func (r *Role) List(db orm.DB, qp *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Role, error) {
+	var roles []gorsk.Role
+	q := db.Model(&roles).Limit(p.Limit).Offset(p.Offset).Order("role.id asc")
+	if qp != nil {
+		q.Where(qp.Query, qp.ID)
+	}
+	if err := q.Select(); err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return roles, nil
+	}
+
+	ids := make([]gorsk.AccessRole, len(roles))
+	for i, role := range roles {
+		ids[i] = role.ID
+	}
+	var grants []gorsk.RolePermission
+	if err := db.Model(&grants).Where("role_id IN (?)", pg.In(ids)).Order("permission").Select(); err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		for _, g := range grants {
+			if g.RoleID == roles[i].ID {
+				roles[i].Permissions = append(roles[i].Permissions, g.Permission)
+			}
+		}
+	}
+	return roles, nil
+}
+
+// Delete deletes a role along with its permissions, unless it is assigned to users
+func (r *Role) Delete(db orm.DB, role *gorsk.Role) error {
+	// Deleted users still reference their role, so the table is queried without the soft deleting model
+	inUse, err := db.Model().Table("users").Where("role_id = ?", role.ID).Exists()
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrInUse
+	}
+	return db.Delete(role)
+}
+
+func permissions(db orm.DB, role *gorsk.Role) error {
+	var grants []gorsk.RolePermission
+	if err := db.Model(&grants).Where("role_id = ?", role.ID).Order("permission").Select(); err != nil {
+		return err
+	}
+	role.Permissions = nil
+	for _, g := range grants {
+		role.Permissions = append(role.Permissions, g.Permission)
+	}
+	return nil
+}
+
+func setPermissions(db orm.DB, role *gorsk.Role) error {
+	if _, err := db.Model((*gorsk.RolePermission)(nil)).Where("role_id = ?", role.ID).Delete(); err != nil {
+		return err
+	}
+	if len(role.Permissions) == 0 {
+		return nil
+	}
+	grants := make([]gorsk.RolePermission, len(role.Permissions))
+	for i, p := range role.Permissions {
+		grants[i] = gorsk.RolePermission{RoleID: role.ID, Permission: p}
+	}
+	return db.Insert(&grants)
+}
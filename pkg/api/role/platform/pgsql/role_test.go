@@ -0,0 +1,139 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/role/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		wantErr  bool
+		req      gorsk.Role
+		wantData *gorsk.Role
+	}{
+		{
+			name:    "Fail on name of global role",
+			wantErr: true,
+			req:     gorsk.Role{Name: "user", AccessLevel: gorsk.UserRole, CompanyID: 2},
+		},
+		{
+			name: "Success",
+			req:  gorsk.Role{ID: 201, Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 2, Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+			wantData: &gorsk.Role{ID: 201, Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 2,
+				Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+		},
+		{
+			name:    "Fail on name of company role",
+			wantErr: true,
+			req:     gorsk.Role{Name: "AUDITOR", AccessLevel: gorsk.UserRole, CompanyID: 2},
+		},
+		{
+			name:     "Same name in another company",
+			req:      gorsk.Role{ID: 202, Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 3},
+			wantData: &gorsk.Role{ID: 202, Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 3},
+		},
+	}
+
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.RolePermission{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Role{ID: 200, AccessLevel: 200, Name: "USER"}); err != nil {
+		t.Error(err)
+	}
+
+	rdb := pgsql.NewRole()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := rdb.Create(db, tt.req)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantData != nil {
+				assert.Equal(t, tt.wantData, resp)
+				role, err := rdb.View(db, resp.ID)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.wantData, role)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.RolePermission{})
+
+	if err := mock.InsertMultiple(db,
+		&gorsk.Role{ID: 200, AccessLevel: 200, Name: "USER"},
+		&gorsk.Role{ID: 201, AccessLevel: 200, Name: "Auditor", CompanyID: 2},
+		&gorsk.Role{ID: 202, AccessLevel: 200, Name: "Shift Lead", CompanyID: 3},
+		&[]gorsk.RolePermission{{RoleID: 200, Permission: gorsk.PermUsersUpdate}, {RoleID: 200, Permission: gorsk.PermUsersRead}},
+	); err != nil {
+		t.Error(err)
+	}
+
+	rdb := pgsql.NewRole()
+
+	roles, err := rdb.List(db, &gorsk.ListQuery{Query: "company_id IS NULL OR company_id = ?", ID: 2}, &gorsk.Pagination{Limit: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, []gorsk.Role{
+		{ID: 200, AccessLevel: 200, Name: "USER", Permissions: []gorsk.Permission{gorsk.PermUsersRead, gorsk.PermUsersUpdate}},
+		{ID: 201, AccessLevel: 200, Name: "Auditor", CompanyID: 2},
+	}, roles)
+}
+
+func TestUpdate(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.RolePermission{})
+
+	rdb := pgsql.NewRole()
+
+	role, err := rdb.Create(db, gorsk.Role{ID: 201, AccessLevel: 200, Name: "Auditor", Permissions: []gorsk.Permission{gorsk.PermUsersRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role.Name = "Shift Lead"
+	role.AccessLevel = gorsk.LocationAdminRole
+	role.Permissions = []gorsk.Permission{gorsk.PermUsersUpdate}
+	assert.Nil(t, rdb.Update(db, role))
+
+	resp, err := rdb.View(db, 201)
+	assert.Nil(t, err)
+	assert.Equal(t, role, resp)
+}
+
+func TestDelete(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.RolePermission{}, &gorsk.User{})
+
+	rdb := pgsql.NewRole()
+
+	assigned := &gorsk.Role{ID: 201, AccessLevel: 200, Name: "Auditor"}
+	unused := &gorsk.Role{ID: 202, AccessLevel: 200, Name: "Shift Lead"}
+	formerlyAssigned := &gorsk.Role{ID: 203, AccessLevel: 200, Name: "Cashier"}
+	if err := mock.InsertMultiple(db, assigned, unused, formerlyAssigned,
+		&gorsk.User{RoleID: 201, Username: "auditor"},
+		&gorsk.User{Base: gorsk.Base{DeletedAt: mock.TestTime(2018)}, RoleID: 203, Username: "cashier"}); err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, pgsql.ErrInUse, rdb.Delete(db, assigned))
+	assert.Equal(t, pgsql.ErrInUse, rdb.Delete(db, formerlyAssigned))
+	assert.Nil(t, rdb.Delete(db, unused))
+
+	_, err := rdb.View(db, 202)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,154 @@
+// Package role contains role application services
+package role
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-pg/pg/orm"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/query"
+
+	"github.com/labstack/echo"
+)
+
+// Custom errors
+var (
+	ErrBuiltInRole = echo.NewHTTPError(http.StatusBadRequest, "built-in roles cannot be deleted or change access level")
+)
+
+// Create creates a new role
+func (r *Role) Create(ctx context.Context, req gorsk.Role) (*gorsk.Role, error) {
+	if err := r.manage(ctx, gorsk.PermRolesCreate, &req); err != nil {
+		return nil, err
+	}
+	var role *gorsk.Role
+	if err := r.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		var err error
+		role, err = r.rdb.Create(tx, req)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	r.refresh(ctx)
+	return role, nil
+}
+
+// List returns list of roles
+func (r *Role) List(ctx context.Context, p *gorsk.Pagination) ([]gorsk.Role, error) {
+	db := r.db.WithContext(ctx)
+	if err := r.rbac.Enforce(ctx, gorsk.PermRolesRead); err != nil {
+		return nil, err
+	}
+	q, err := query.Role(r.rbac.User(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return r.rdb.List(db, q, p)
+}
+
+// View returns single role
+func (r *Role) View(ctx context.Context, id gorsk.AccessRole) (*gorsk.Role, error) {
+	db := r.db.WithContext(ctx)
+	if err := r.rbac.Enforce(ctx, gorsk.PermRolesRead); err != nil {
+		return nil, err
+	}
+	role, err := r.rdb.View(db, id)
+	if err != nil {
+		return nil, err
+	}
+	// Roles of other companies are visible to admins only
+	if role.CompanyID != 0 && role.CompanyID != r.rbac.User(ctx).CompanyID {
+		if err := r.rbac.EnforceRole(ctx, gorsk.AdminRole); err != nil {
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+// Delete deletes a role
+func (r *Role) Delete(ctx context.Context, id gorsk.AccessRole) error {
+	db := r.db.WithContext(ctx)
+	role, err := r.rdb.View(db, id)
+	if err != nil {
+		return err
+	}
+	if err := r.manage(ctx, gorsk.PermRolesDelete, role); err != nil {
+		return err
+	}
+	if role.BuiltIn() {
+		return ErrBuiltInRole
+	}
+	if err := r.rdb.Delete(db, role); err != nil {
+		return err
+	}
+	r.refresh(ctx)
+	return nil
+}
+
+// Update contains role's information used for updating
+type Update struct {
+	ID          gorsk.AccessRole
+	Name        string
+	AccessLevel gorsk.AccessRole
+	// Permissions replace role's permissions unless nil
+	Permissions []gorsk.Permission
+}
+
+// Update updates role's name, access level and permissions
+func (r *Role) Update(ctx context.Context, req *Update) (*gorsk.Role, error) {
+	db := r.db.WithContext(ctx)
+	role, err := r.rdb.View(db, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.manage(ctx, gorsk.PermRolesUpdate, role); err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		role.Name = req.Name
+	}
+	if req.AccessLevel != 0 && req.AccessLevel != role.AccessLevel {
+		if role.BuiltIn() {
+			return nil, ErrBuiltInRole
+		}
+		role.AccessLevel = req.AccessLevel
+	}
+	if req.Permissions != nil {
+		role.Permissions = req.Permissions
+	}
+	if err := r.rbac.EnforceGrant(ctx, role.AccessLevel, role.Permissions); err != nil {
+		return nil, err
+	}
+
+	if err := r.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		return r.rdb.Update(tx, role)
+	}); err != nil {
+		return nil, err
+	}
+	r.refresh(ctx)
+	return role, nil
+}
+
+// manage checks whether user has the permission on the role and the role isn't more privileged
+// than user's own. Global roles are managed by super admins, company roles by admins of the company.
+func (r *Role) manage(ctx context.Context, p gorsk.Permission, role *gorsk.Role) error {
+	if role.CompanyID == 0 {
+		if err := r.rbac.EnforceRole(ctx, gorsk.SuperAdminRole); err != nil {
+			return err
+		}
+		if err := r.rbac.Enforce(ctx, p); err != nil {
+			return err
+		}
+	} else if err := r.rbac.EnforceCompany(ctx, p, role.CompanyID); err != nil {
+		return err
+	}
+	return r.rbac.EnforceGrant(ctx, role.AccessLevel, role.Permissions)
+}
+
+// refresh applies changed roles to authorization right away.
+// If it fails, changes apply once cached permissions expire.
+func (r *Role) refresh(ctx context.Context) {
+	_ = r.rbac.Refresh(ctx)
+}
@@ -0,0 +1,319 @@
+package role_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/api/role"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name     string
+		req      gorsk.Role
+		wantErr  error
+		wantData *gorsk.Role
+		rdb      *mockdb.Role
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on global role by non super admin",
+			req:  gorsk.Role{Name: "Auditor", AccessLevel: gorsk.UserRole},
+			rbac: &mock.RBAC{
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on company RBAC",
+			req:  gorsk.Role{Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 2},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on more privileged role",
+			req:  gorsk.Role{Name: "Auditor", AccessLevel: gorsk.AdminRole, CompanyID: 2},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceGrantFn: func(context.Context, gorsk.AccessRole, []gorsk.Permission) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			req:  gorsk.Role{Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 2, Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(_ context.Context, p gorsk.Permission, id int) error {
+					if p != gorsk.PermRolesCreate || id != 2 {
+						return gorsk.ErrGeneric
+					}
+					return nil
+				},
+				EnforceGrantFn: func(context.Context, gorsk.AccessRole, []gorsk.Permission) error {
+					return nil
+				},
+				RefreshFn: func(context.Context) error {
+					return gorsk.ErrGeneric
+				}},
+			rdb: &mockdb.Role{
+				CreateFn: func(db orm.DB, r gorsk.Role) (*gorsk.Role, error) {
+					r.ID = 201
+					return &r, nil
+				},
+			},
+			wantData: &gorsk.Role{ID: 201, Name: "Auditor", AccessLevel: gorsk.UserRole, CompanyID: 2, Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := role.New(mockdb.DB{}, tt.rdb, tt.rbac)
+			r, err := s.Create(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, r)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cases := []struct {
+		name     string
+		pgn      *gorsk.Pagination
+		wantData []gorsk.Role
+		wantErr  bool
+		rdb      *mockdb.Role
+		rbac     *mock.RBAC
+	}{
+		{
+			name:    "Fail on RBAC",
+			pgn:     &gorsk.Pagination{Limit: 100},
+			wantErr: true,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return echo.ErrForbidden },
+			},
+		},
+		{
+			name: "Success",
+			pgn:  &gorsk.Pagination{Limit: 100},
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.CompanyAdminRole}
+				}},
+			rdb: &mockdb.Role{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Role, error) {
+					if q.ID != 2 {
+						return nil, gorsk.ErrGeneric
+					}
+					return []gorsk.Role{{ID: 201, Name: "Auditor", CompanyID: 2}}, nil
+				}},
+			wantData: []gorsk.Role{{ID: 201, Name: "Auditor", CompanyID: 2}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := role.New(mockdb.DB{}, tt.rdb, tt.rbac)
+			roles, err := s.List(context.Background(), tt.pgn)
+			assert.Equal(t, tt.wantData, roles)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	user := func(context.Context) *gorsk.AuthUser {
+		return &gorsk.AuthUser{CompanyID: 2, Role: gorsk.LocationAdminRole}
+	}
+	cases := []struct {
+		name     string
+		id       gorsk.AccessRole
+		wantData *gorsk.Role
+		wantErr  error
+		rdb      *mockdb.Role
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			id:   201,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return echo.ErrForbidden },
+			},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on role of another company",
+			id:   201,
+			rbac: &mock.RBAC{
+				EnforceFn:     func(context.Context, gorsk.Permission) error { return nil },
+				EnforceRoleFn: func(context.Context, gorsk.AccessRole) error { return echo.ErrForbidden },
+				UserFn:        user,
+			},
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, Name: "Auditor", CompanyID: 3}, nil
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			id:   201,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    user,
+			},
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, Name: "Auditor", CompanyID: 2}, nil
+				}},
+			wantData: &gorsk.Role{ID: 201, Name: "Auditor", CompanyID: 2},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := role.New(mockdb.DB{}, tt.rdb, tt.rbac)
+			r, err := s.View(context.Background(), tt.id)
+			assert.Equal(t, tt.wantData, r)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	allow := &mock.RBAC{
+		EnforceRoleFn:  func(context.Context, gorsk.AccessRole) error { return nil },
+		EnforceFn:      func(context.Context, gorsk.Permission) error { return nil },
+		EnforceGrantFn: func(context.Context, gorsk.AccessRole, []gorsk.Permission) error { return nil },
+		RefreshFn:      func(context.Context) error { return nil },
+	}
+	cases := []struct {
+		name    string
+		id      gorsk.AccessRole
+		wantErr error
+		rdb     *mockdb.Role
+		rbac    *mock.RBAC
+	}{
+		{
+			name: "Fail on View",
+			id:   201,
+			rdb: &mockdb.Role{
+				ViewFn: func(orm.DB, gorsk.AccessRole) (*gorsk.Role, error) {
+					return nil, gorsk.ErrGeneric
+				}},
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name: "Fail on built-in role",
+			id:   gorsk.UserRole,
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: id, Name: "USER"}, nil
+				}},
+			rbac:    allow,
+			wantErr: role.ErrBuiltInRole,
+		},
+		{
+			name: "Success",
+			id:   201,
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole, Name: "Auditor"}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Role) error {
+					return nil
+				}},
+			rbac: allow,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := role.New(mockdb.DB{}, tt.rdb, tt.rbac)
+			err := s.Delete(context.Background(), tt.id)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	view := func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+		return &gorsk.Role{ID: id, AccessLevel: id, Name: "Role", CompanyID: 2, Permissions: []gorsk.Permission{gorsk.PermUsersRead}}, nil
+	}
+	cases := []struct {
+		name     string
+		req      *role.Update
+		wantData *gorsk.Role
+		wantErr  error
+		rdb      *mockdb.Role
+		rbac     *mock.RBAC
+	}{
+		{
+			name: "Fail on RBAC",
+			req:  &role.Update{ID: 201, Name: "Auditor"},
+			rdb:  &mockdb.Role{ViewFn: view},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Fail on granting more permissions",
+			req:  &role.Update{ID: 201, Permissions: []gorsk.Permission{gorsk.PermCompaniesDelete}},
+			rdb:  &mockdb.Role{ViewFn: view},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceGrantFn: func(_ context.Context, _ gorsk.AccessRole, perms []gorsk.Permission) error {
+					for _, p := range perms {
+						if p == gorsk.PermCompaniesDelete {
+							return echo.ErrForbidden
+						}
+					}
+					return nil
+				}},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			req:  &role.Update{ID: 201, Name: "Auditor", AccessLevel: gorsk.UserRole, Permissions: []gorsk.Permission{}},
+			rdb: &mockdb.Role{
+				ViewFn: view,
+				UpdateFn: func(orm.DB, *gorsk.Role) error {
+					return nil
+				}},
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return nil
+				},
+				EnforceGrantFn: func(context.Context, gorsk.AccessRole, []gorsk.Permission) error {
+					return nil
+				},
+				RefreshFn: func(context.Context) error {
+					return nil
+				}},
+			wantData: &gorsk.Role{ID: 201, AccessLevel: gorsk.UserRole, Name: "Auditor", CompanyID: 2, Permissions: []gorsk.Permission{}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := role.New(mockdb.DB{}, tt.rdb, tt.rbac)
+			r, err := s.Update(context.Background(), tt.req)
+			assert.Equal(t, tt.wantData, r)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package role
+
+import (
+	"context"
+
+	"github.com/figassis/goduck/pkg/api/role/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Service represents role application interface
+type Service interface {
+	Create(context.Context, gorsk.Role) (*gorsk.Role, error)
+	List(context.Context, *gorsk.Pagination) ([]gorsk.Role, error)
+	View(context.Context, gorsk.AccessRole) (*gorsk.Role, error)
+	Delete(context.Context, gorsk.AccessRole) error
+	Update(context.Context, *Update) (*gorsk.Role, error)
+}
+
+// New creates new role application service
+func New(db postgres.DB, rdb RDB, rbac RBAC) *Role {
+	return &Role{db: db, rdb: rdb, rbac: rbac}
+}
+
+// Initialize initalizes Role application service with defaults
+func Initialize(db *pg.DB, rbac RBAC) *Role {
+	return New(postgres.Wrap(db), pgsql.NewRole(), rbac)
+}
+
+// Role represents role application service
+type Role struct {
+	db   postgres.DB
+	rdb  RDB
+	rbac RBAC
+}
+
+// RDB represents role repository interface
+type RDB interface {
+	Create(orm.DB, gorsk.Role) (*gorsk.Role, error)
+	View(orm.DB, gorsk.AccessRole) (*gorsk.Role, error)
+	List(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Role, error)
+	Update(orm.DB, *gorsk.Role) error
+	Delete(orm.DB, *gorsk.Role) error
+}
+
+// RBAC represents role-based-access-control interface
+type RBAC interface {
+	User(context.Context) *gorsk.AuthUser
+	Enforce(context.Context, gorsk.Permission) error
+	EnforceRole(context.Context, gorsk.AccessRole) error
+	EnforceCompany(context.Context, gorsk.Permission, int) error
+	EnforceGrant(context.Context, gorsk.AccessRole, []gorsk.Permission) error
+	Refresh(context.Context) error
+}
@@ -0,0 +1,288 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/figassis/goduck/pkg/api/role"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
+)
+
+// HTTP represents role http service
+type HTTP struct {
+	svc role.Service
+}
+
+// NewHTTP creates new role http service
+// Routes are authorized by require, which returns middleware checking user's permissions.
+func NewHTTP(svc role.Service, er *echo.Group, require func(...gorsk.Permission) echo.MiddlewareFunc) {
+	h := HTTP{svc}
+	rr := er.Group("/roles")
+	// swagger:operation POST /v1/roles roles roleCreate
+	// ---
+	// summary: Creates new role.
+	// description: Creates a global role when company_id is omitted, which only super admins can do, or a role scoped to the company. The role can't be more privileged than the requesting user's own role.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/roleCreate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/roleResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	rr.POST("", h.create, require(gorsk.PermRolesCreate))
+
+	// swagger:operation GET /v1/roles roles listRoles
+	// ---
+	// summary: Returns list of roles.
+	// description: Returns list of roles with their permissions. Admins see all roles, other users global roles and roles of their company.
+	// parameters:
+	// - name: limit
+	//   in: query
+	//   description: number of results
+	//   type: int
+	//   required: false
+	// - name: page
+	//   in: query
+	//   description: page number
+	//   type: int
+	//   required: false
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/roleListResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	rr.GET("", h.list, require(gorsk.PermRolesRead))
+
+	// swagger:operation GET /v1/roles/{id} roles getRole
+	// ---
+	// summary: Returns a single role.
+	// description: Returns a single role by its ID, including its permissions.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of role
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/roleResp"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "404":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	rr.GET("/:id", h.view, require(gorsk.PermRolesRead))
+
+	// swagger:operation PATCH /v1/roles/{id} roles roleUpdate
+	// ---
+	// summary: Updates role's information
+	// description: Updates role's name, access level and permissions. Permissions, if present, replace the current ones. Access level of built-in roles can't be changed.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of role
+	//   type: int
+	//   required: true
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/roleUpdate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/roleResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	rr.PATCH("/:id", h.update, require(gorsk.PermRolesUpdate))
+
+	// swagger:operation DELETE /v1/roles/{id} roles roleDelete
+	// ---
+	// summary: Deletes a role
+	// description: Deletes a role with requested ID. Built-in roles and roles assigned to users can't be deleted.
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of role
+	//   type: int
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/err"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	rr.DELETE("/:id", h.delete, require(gorsk.PermRolesDelete))
+}
+
+// Custom errors
+var (
+	ErrUnknownPermission = echo.NewHTTPError(http.StatusBadRequest, "unknown permission")
+)
+
+// Role create request
+// swagger:model roleCreate
+type createReq struct {
+	Name        string             `json:"name" validate:"required,min=2"`
+	AccessLevel gorsk.AccessRole   `json:"access_level" validate:"required,oneof=100 110 120 130 200"`
+	CompanyID   int                `json:"company_id"`
+	Permissions []gorsk.Permission `json:"permissions"`
+}
+
+func (h *HTTP) create(c echo.Context) error {
+	r := new(createReq)
+
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := validPermissions(r.Permissions); err != nil {
+		return err
+	}
+
+	result, err := h.svc.Create(c.Request().Context(), gorsk.Role{
+		Name:        r.Name,
+		AccessLevel: r.AccessLevel,
+		CompanyID:   r.CompanyID,
+		Permissions: r.Permissions,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+type listResponse struct {
+	Roles []gorsk.Role `json:"roles"`
+	Page  int          `json:"page"`
+}
+
+func (h *HTTP) list(c echo.Context) error {
+	p := new(gorsk.PaginationReq)
+	if err := c.Bind(p); err != nil {
+		return err
+	}
+
+	result, err := h.svc.List(c.Request().Context(), p.Transform())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, p.Page})
+}
+
+func (h *HTTP) view(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	result, err := h.svc.View(c.Request().Context(), gorsk.AccessRole(id))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// Role update request
+// swagger:model roleUpdate
+type updateReq struct {
+	Name        string             `json:"name,omitempty" validate:"omitempty,min=2"`
+	AccessLevel gorsk.AccessRole   `json:"access_level,omitempty" validate:"omitempty,oneof=100 110 120 130 200"`
+	Permissions []gorsk.Permission `json:"permissions,omitempty"`
+}
+
+func (h *HTTP) update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	req := new(updateReq)
+	if err := c.Bind(req); err != nil {
+		return err
+	}
+
+	if err := validPermissions(req.Permissions); err != nil {
+		return err
+	}
+
+	result, err := h.svc.Update(c.Request().Context(), &role.Update{
+		ID:          gorsk.AccessRole(id),
+		Name:        req.Name,
+		AccessLevel: req.AccessLevel,
+		Permissions: req.Permissions,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *HTTP) delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return gorsk.ErrBadRequest
+	}
+
+	if err := h.svc.Delete(c.Request().Context(), gorsk.AccessRole(id)); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func validPermissions(perms []gorsk.Permission) error {
+	for _, p := range perms {
+		if !p.Valid() {
+			return ErrUnknownPermission
+		}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/figassis/goduck/pkg/api/role"
+	"github.com/figassis/goduck/pkg/api/role/transport"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/server"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func allow() *mock.RBAC {
+	return &mock.RBAC{
+		EnforceFn:        func(context.Context, gorsk.Permission) error { return nil },
+		EnforceRoleFn:    func(context.Context, gorsk.AccessRole) error { return nil },
+		EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error { return nil },
+		EnforceGrantFn:   func(context.Context, gorsk.AccessRole, []gorsk.Permission) error { return nil },
+		RefreshFn:        func(context.Context) error { return nil },
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1, CompanyID: 2, Role: gorsk.CompanyAdminRole}
+		},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Role
+		rdb        *mockdb.Role
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"name":"Auditor","access_level":50}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on access level between roles",
+			req:        `{"name":"Auditor","access_level":150}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on unknown permission",
+			req:        `{"name":"Auditor","access_level":200,"permissions":["users:read","users:fly"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			req:  `{"name":"Auditor","access_level":200,"company_id":2,"permissions":["users:read"]}`,
+			rbac: &mock.RBAC{
+				EnforceCompanyFn: func(context.Context, gorsk.Permission, int) error {
+					return echo.ErrForbidden
+				},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "Success",
+			req:  `{"name":"Auditor","access_level":200,"company_id":2,"permissions":["users:read"]}`,
+			rbac: allow(),
+			rdb: &mockdb.Role{
+				CreateFn: func(db orm.DB, r gorsk.Role) (*gorsk.Role, error) {
+					r.ID = 201
+					return &r, nil
+				},
+			},
+			wantResp:   &gorsk.Role{ID: 201, Name: "Auditor", AccessLevel: 200, CompanyID: 2, Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(role.New(mockdb.DB{}, tt.rdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/roles"
+			res, err := http.Post(path, "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Role)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	type listResponse struct {
+		Roles []gorsk.Role `json:"roles"`
+		Page  int          `json:"page"`
+	}
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *listResponse
+		rdb        *mockdb.Role
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `?limit=2222&page=-1`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `?limit=100&page=1`,
+			rbac: allow(),
+			rdb: &mockdb.Role{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Role, error) {
+					if p.Limit == 100 && p.Offset == 100 {
+						return []gorsk.Role{
+							{ID: 200, AccessLevel: 200, Name: "USER"},
+							{ID: 201, AccessLevel: 200, Name: "Auditor", CompanyID: 2},
+						}, nil
+					}
+					return nil, gorsk.ErrGeneric
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &listResponse{
+				Roles: []gorsk.Role{
+					{ID: 200, AccessLevel: 200, Name: "USER"},
+					{ID: 201, AccessLevel: 200, Name: "Auditor", CompanyID: 2},
+				},
+				Page: 1,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(role.New(mockdb.DB{}, tt.rdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/roles" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(listResponse)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		wantResp   *gorsk.Role
+		rdb        *mockdb.Role
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			req:        `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `201`,
+			rbac: allow(),
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: 200, Name: "Auditor", Permissions: []gorsk.Permission{gorsk.PermUsersRead}}, nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp:   &gorsk.Role{ID: 201, AccessLevel: 200, Name: "Auditor", Permissions: []gorsk.Permission{gorsk.PermUsersRead}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(role.New(mockdb.DB{}, tt.rdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/roles/" + tt.req
+			res, err := http.Get(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Role)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		id         string
+		wantStatus int
+		wantResp   *gorsk.Role
+		rdb        *mockdb.Role
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on unknown permission",
+			id:         `201`,
+			req:        `{"permissions":["users:fly"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on built-in role",
+			id:   `200`,
+			req:  `{"access_level":130}`,
+			rbac: allow(),
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: id, Name: "USER"}, nil
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			id:   `201`,
+			req:  `{"name":"Shift Lead","permissions":["users:read","users:update"]}`,
+			rbac: allow(),
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: 200, Name: "Auditor", CompanyID: 2}, nil
+				},
+				UpdateFn: func(orm.DB, *gorsk.Role) error {
+					return nil
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantResp: &gorsk.Role{ID: 201, AccessLevel: 200, Name: "Shift Lead", CompanyID: 2,
+				Permissions: []gorsk.Permission{gorsk.PermUsersRead, gorsk.PermUsersUpdate}},
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(role.New(mockdb.DB{}, tt.rdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/roles/" + tt.id
+			req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(tt.req))
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Role)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		rdb        *mockdb.Role
+		rbac       *mock.RBAC
+	}{
+		{
+			name:       "Invalid request",
+			id:         `a`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			id:   `201`,
+			rdb: &mockdb.Role{
+				ViewFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: 200, CompanyID: 2}, nil
+				},
+				DeleteFn: func(orm.DB, *gorsk.Role) error {
+					return nil
+				},
+			},
+			rbac:       allow(),
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	client := http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(role.New(mockdb.DB{}, tt.rdb, tt.rbac), rg, mock.RequirePermission)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			path := ts.URL + "/roles/" + tt.id
+			req, _ := http.NewRequest("DELETE", path, nil)
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package transport
+
+import (
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Role model response
+// swagger:response roleResp
+type swaggRoleResponse struct {
+	// in:body
+	Body struct {
+		*gorsk.Role
+	}
+}
+
+// Roles model response
+// swagger:response roleListResp
+type swaggRoleListResponse struct {
+	// in:body
+	Body struct {
+		Roles []gorsk.Role `json:"roles"`
+		Page  int          `json:"page"`
+	}
+}
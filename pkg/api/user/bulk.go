@@ -0,0 +1,121 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/figassis/goduck/pkg/utl/query"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// ImportRow holds a user to import, or the error found while parsing or validating it
+type ImportRow struct {
+	User gorsk.User
+	Err  error
+}
+
+// ImportResult reports the outcome of importing a row
+type ImportResult struct {
+	// Row is the position of the row in the import, starting with 1
+	Row   int    `json:"row"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportOptions decide what is created by an import
+type ImportOptions struct {
+	// DryRun checks all rows without creating any user
+	DryRun bool
+	// BestEffort creates users of valid rows even if other rows fail.
+	// Otherwise no user is created unless all rows are valid.
+	BestEffort bool
+}
+
+// errRollback rolls back imports which mustn't create users
+var errRollback = errors.New("rollback")
+
+// Import creates users of all rows in a single transaction, checking each like Create does.
+// Errors of rows which users can fix are reported in their results, other errors abort the import.
+func (u *User) Import(ctx context.Context, rows []ImportRow, opt ImportOptions) ([]ImportResult, error) {
+	if err := u.rbac.Enforce(ctx, gorsk.PermUsersCreate); err != nil {
+		return nil, err
+	}
+	db := u.db.WithContext(ctx)
+	results := make([]ImportResult, len(rows))
+	var created []*gorsk.User
+	err := u.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		failed := false
+		for i, row := range rows {
+			results[i].Row = i + 1
+			err := row.Err
+			if err == nil {
+				err = postgres.Savepoint(tx, func() error {
+					usr, err := u.create(ctx, tx, row.User)
+					if err == nil {
+						results[i].ID = usr.ID
+						created = append(created, usr)
+					}
+					return err
+				})
+			}
+			if err != nil {
+				msg, ok := rowError(err)
+				if !ok {
+					return err
+				}
+				results[i].Error, failed = msg, true
+			}
+		}
+		if opt.DryRun || (failed && !opt.BestEffort) {
+			return errRollback
+		}
+		return nil
+	})
+	if err == errRollback {
+		// IDs of users which weren't created would mislead
+		for i := range results {
+			results[i].ID = 0
+		}
+		created, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Verification is emailed only once users were committed
+	for _, usr := range created {
+		u.verifyCreated(db, usr)
+	}
+	return results, nil
+}
+
+// rowError returns message of errors caused by row's data
+func rowError(err error) (string, bool) {
+	switch e := err.(type) {
+	case *echo.HTTPError:
+		return fmt.Sprint(e.Message), true
+	case pg.Error:
+		if e.IntegrityViolation() {
+			return "violates constraint " + e.Field('n'), true
+		}
+	}
+	return "", false
+}
+
+// Export calls fn for each user matching the filter which the user may access, without loading all of them at once
+func (u *User) Export(ctx context.Context, f *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+	db := u.db.WithContext(ctx)
+	if err := u.rbac.Enforce(ctx, gorsk.PermUsersRead); err != nil {
+		return err
+	}
+	q, err := query.List(u.rbac.User(ctx))
+	if err != nil {
+		return err
+	}
+	return u.udb.Export(db, q, f, fn)
+}
@@ -0,0 +1,199 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/mail"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/secure"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// Custom errors
+var (
+	ErrInvalidVerificationToken = echo.NewHTTPError(http.StatusBadRequest, "invalid or expired verification token")
+	ErrIncorrectPassword        = echo.NewHTTPError(http.StatusBadRequest, "incorrect password")
+	ErrEmailUnchanged           = echo.NewHTTPError(http.StatusBadRequest, "email is unchanged")
+	ErrEmailTaken               = echo.NewHTTPError(http.StatusBadRequest, "email is already in use")
+	ErrVerificationThrottled    = echo.NewHTTPError(http.StatusTooManyRequests, "verification email was sent recently, try again later")
+)
+
+// VerifyEmail confirms with verification token that user controls the email, which replaces user's email if it was changed
+func (u *User) VerifyEmail(ctx context.Context, token string) error {
+	db := u.db.WithContext(ctx)
+	ev, err := u.udb.FindEmailVerification(db, u.sec.TokenHash(token))
+	if err == pg.ErrNoRows {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ev.Valid(time.Now()) {
+		return ErrInvalidVerificationToken
+	}
+
+	usr, err := u.udb.View(db, ev.UserID)
+	if err == pg.ErrNoRows {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(usr.Email, ev.Email) {
+		if err := u.checkEmailFree(db, usr.ID, ev.Email); err != nil {
+			return err
+		}
+	}
+
+	return u.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		if err := u.udb.UseEmailVerification(tx, ev); err != nil {
+			if err == pg.ErrNoRows {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		usr.VerifyEmail(ev.Email)
+		return u.udb.UpdateEmail(tx, usr)
+	})
+}
+
+// ResendVerification emails a new verification token to the user with given email, if it isn't verified yet.
+// Unknown, inactive and verified accounts are silently ignored, so the endpoint can't be used to discover emails.
+// Emails are resent to the same address at most once per configured interval.
+func (u *User) ResendVerification(ctx context.Context, email string) error {
+	db := u.db.WithContext(ctx)
+	now := time.Now()
+	key := "verify:" + strings.ToLower(email)
+	at, err := u.lim.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if at.Locked(now) {
+		return ErrVerificationThrottled
+	}
+	if err := u.lim.Lock(ctx, key, now.Add(u.cfg.ResendInterval)); err != nil {
+		return err
+	}
+
+	usr, err := u.udb.FindByEmail(db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !usr.Active || usr.EmailVerified() {
+		return nil
+	}
+
+	return u.sendVerification(db, usr.ID, usr.Email, "Verify your email")
+}
+
+// ChangeEmail starts changing email of the current user, after checking user's password.
+// The email is changed once confirmed with the token sent to the new address, and the current address is notified.
+// Addresses of other users get the same response, but no token, so the endpoint can't be used to discover emails.
+func (u *User) ChangeEmail(ctx context.Context, password, email string) error {
+	db := u.db.WithContext(ctx)
+	now := time.Now()
+	key := "email-change:" + strconv.Itoa(u.rbac.User(ctx).ID)
+	at, err := u.lim.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if at.Locked(now) {
+		return ErrVerificationThrottled
+	}
+
+	usr, err := u.udb.View(db, u.rbac.User(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	if !u.sec.HashMatchesPassword(usr.Password, password) {
+		return ErrIncorrectPassword
+	}
+
+	if strings.EqualFold(usr.Email, email) {
+		return ErrEmailUnchanged
+	}
+
+	if err := u.lim.Lock(ctx, key, now.Add(u.cfg.ResendInterval)); err != nil {
+		return err
+	}
+
+	switch err := u.checkEmailFree(db, usr.ID, email); err {
+	case nil:
+		if err := u.sendVerification(db, usr.ID, email, "Confirm your new email"); err != nil {
+			return err
+		}
+	case ErrEmailTaken:
+	default:
+		return err
+	}
+
+	if usr.Email == "" {
+		return nil
+	}
+	return u.mailer.Send(mail.Message{
+		To:      usr.Email,
+		Subject: "Email change requested",
+		Body:    fmt.Sprintf("Change of your account's email to %s was requested. It takes effect once confirmed from the new address. If you didn't request it, change your password.", email),
+	})
+}
+
+// checkEmailFree checks that email doesn't belong to another user
+func (u *User) checkEmailFree(db orm.DB, userID int, email string) error {
+	other, err := u.udb.FindByEmail(db, email)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.ID != userID {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// sendVerification issues a verification token for user's email and emails it to the address
+func (u *User) sendVerification(db orm.DB, userID int, email, subject string) error {
+	token, err := u.sec.RandomToken(secure.EmailTokenPrefix)
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.udb.CreateEmailVerification(db, gorsk.EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: u.sec.TokenHash(token),
+		ExpiresAt: time.Now().Add(u.cfg.VerifyDuration),
+	}); err != nil {
+		return err
+	}
+
+	return u.mailer.Send(mail.Message{
+		To:      email,
+		Subject: subject,
+		Body:    u.verifyBody(token),
+	})
+}
+
+func (u *User) verifyBody(token string) string {
+	if u.cfg.VerifyURL == "" {
+		return fmt.Sprintf("Use the following token to verify your email: %s", token)
+	}
+	return fmt.Sprintf("Visit the following link to verify your email: %s?token=%s", u.cfg.VerifyURL, url.QueryEscape(token))
+}
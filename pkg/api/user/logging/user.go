@@ -1,9 +1,9 @@
 package user
 
 import (
+	"context"
 	"time"
 
-	"github.com/labstack/echo"
 	"github.com/figassis/goduck/pkg/api/user"
 	"github.com/figassis/goduck/pkg/utl/model"
 )
@@ -25,11 +25,11 @@ type LogService struct {
 const name = "user"
 
 // Create logging
-func (ls *LogService) Create(c echo.Context, req gorsk.User) (resp *gorsk.User, err error) {
+func (ls *LogService) Create(ctx context.Context, req gorsk.User) (resp *gorsk.User, err error) {
 	defer func(begin time.Time) {
 		req.Password = "xxx-redacted-xxx"
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Create user request", err,
 			map[string]interface{}{
 				"req":  req,
@@ -38,30 +38,32 @@ func (ls *LogService) Create(c echo.Context, req gorsk.User) (resp *gorsk.User,
 			},
 		)
 	}(time.Now())
-	return ls.Service.Create(c, req)
+	return ls.Service.Create(ctx, req)
 }
 
 // List logging
-func (ls *LogService) List(c echo.Context, req *gorsk.Pagination) (resp []gorsk.User, err error) {
+func (ls *LogService) List(ctx context.Context, filter *gorsk.UserFilter, req *gorsk.Pagination) (resp []gorsk.User, page *gorsk.Page, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "List user request", err,
 			map[string]interface{}{
-				"req":  req,
-				"resp": resp,
-				"took": time.Since(begin),
+				"filter": filter,
+				"req":    req,
+				"resp":   resp,
+				"page":   page,
+				"took":   time.Since(begin),
 			},
 		)
 	}(time.Now())
-	return ls.Service.List(c, req)
+	return ls.Service.List(ctx, filter, req)
 }
 
 // View logging
-func (ls *LogService) View(c echo.Context, req int) (resp *gorsk.User, err error) {
+func (ls *LogService) View(ctx context.Context, req int) (resp *gorsk.User, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "View user request", err,
 			map[string]interface{}{
 				"req":  req,
@@ -70,14 +72,14 @@ func (ls *LogService) View(c echo.Context, req int) (resp *gorsk.User, err error
 			},
 		)
 	}(time.Now())
-	return ls.Service.View(c, req)
+	return ls.Service.View(ctx, req)
 }
 
 // Delete logging
-func (ls *LogService) Delete(c echo.Context, req int) (err error) {
+func (ls *LogService) Delete(ctx context.Context, req int) (err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Delete user request", err,
 			map[string]interface{}{
 				"req":  req,
@@ -85,14 +87,14 @@ func (ls *LogService) Delete(c echo.Context, req int) (err error) {
 			},
 		)
 	}(time.Now())
-	return ls.Service.Delete(c, req)
+	return ls.Service.Delete(ctx, req)
 }
 
 // Update logging
-func (ls *LogService) Update(c echo.Context, req *user.Update) (resp *gorsk.User, err error) {
+func (ls *LogService) Update(ctx context.Context, req *user.Update) (resp *gorsk.User, err error) {
 	defer func(begin time.Time) {
 		ls.logger.Log(
-			c,
+			ctx,
 			name, "Update user request", err,
 			map[string]interface{}{
 				"req":  req,
@@ -101,5 +103,100 @@ func (ls *LogService) Update(c echo.Context, req *user.Update) (resp *gorsk.User
 			},
 		)
 	}(time.Now())
-	return ls.Service.Update(c, req)
+	return ls.Service.Update(ctx, req)
+}
+
+// Import logging
+func (ls *LogService) Import(ctx context.Context, rows []user.ImportRow, opt user.ImportOptions) (resp []user.ImportResult, err error) {
+	defer func(begin time.Time) {
+		// Rows are not logged, as they hold passwords
+		ls.logger.Log(
+			ctx,
+			name, "Import users request", err,
+			map[string]interface{}{
+				"rows": len(rows),
+				"opt":  opt,
+				"resp": resp,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Import(ctx, rows, opt)
+}
+
+// Export logging
+func (ls *LogService) Export(ctx context.Context, filter *gorsk.UserFilter, fn func(*gorsk.User) error) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Export users request", err,
+			map[string]interface{}{
+				"filter": filter,
+				"took":   time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Export(ctx, filter, fn)
+}
+
+// VerifyEmail logging
+func (ls *LogService) VerifyEmail(ctx context.Context, token string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Verify email request", err,
+			map[string]interface{}{
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.VerifyEmail(ctx, token)
+}
+
+// ResendVerification logging
+func (ls *LogService) ResendVerification(ctx context.Context, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Resend email verification request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ResendVerification(ctx, email)
+}
+
+// ChangeEmail logging
+func (ls *LogService) ChangeEmail(ctx context.Context, password, email string) (err error) {
+	defer func(begin time.Time) {
+		ls.logger.Log(
+			ctx,
+			name, "Change email request", err,
+			map[string]interface{}{
+				"req":  email,
+				"took": time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.ChangeEmail(ctx, password, email)
+}
+
+// Signup logging
+func (ls *LogService) Signup(ctx context.Context, company string, req gorsk.User) (resp *gorsk.Company, err error) {
+	defer func(begin time.Time) {
+		req.Password = "xxx-redacted-xxx"
+		ls.logger.Log(
+			ctx,
+			name, "Signup request", err,
+			map[string]interface{}{
+				"company": company,
+				"req":     req,
+				"resp":    resp,
+				"took":    time.Since(begin),
+			},
+		)
+	}(time.Now())
+	return ls.Service.Signup(ctx, company, req)
 }
@@ -0,0 +1,60 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+)
+
+// FindByEmail queries for single user by email, case-insensitively
+func (u *User) FindByEmail(db orm.DB, email string) (*gorsk.User, error) {
+	var user = new(gorsk.User)
+	if err := db.Model(user).Where("lower(email) = lower(?)", email).Select(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateEmail updates user's email and the time it was verified
+func (u *User) UpdateEmail(db orm.DB, user *gorsk.User) error {
+	_, err := db.Model(user).Column("email", "email_verified_at", "updated_at").WherePK().Update()
+	return err
+}
+
+// CreateEmailVerification creates a new email verification on database,
+// discarding user's previous unused ones so only the latest email can be confirmed
+func (u *User) CreateEmailVerification(db orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+	if _, err := db.Model((*gorsk.EmailVerification)(nil)).
+		Where("user_id = ?", ev.UserID).Where("used_at IS NULL").Delete(); err != nil {
+		return nil, err
+	}
+	if err := db.Insert(&ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// FindEmailVerification queries for single email verification by token hash
+func (u *User) FindEmailVerification(db orm.DB, hash string) (*gorsk.EmailVerification, error) {
+	var ev = new(gorsk.EmailVerification)
+	if err := db.Model(ev).Where("token_hash = ?", hash).Select(); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// UseEmailVerification marks email verification as used.
+// Returns pg.ErrNoRows if it has already been used.
+func (u *User) UseEmailVerification(db orm.DB, ev *gorsk.EmailVerification) error {
+	ev.UsedAt = time.Now()
+	res, err := db.Model(ev).Column("used_at", "updated_at").WherePK().Where("used_at IS NULL").Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
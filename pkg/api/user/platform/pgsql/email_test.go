@@ -0,0 +1,98 @@
+package pgsql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/user/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindByEmail(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db, &gorsk.User{Email: "TomJones@mail.com", Username: "tomjones"}); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.NewUser()
+
+	t.Run("User does not exist", func(t *testing.T) {
+		_, err := udb.FindByEmail(db, "johndoe@mail.com")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Case-insensitive match", func(t *testing.T) {
+		user, err := udb.FindByEmail(db, "tomjones@MAIL.com")
+		assert.Nil(t, err)
+		assert.Equal(t, "tomjones", user.Username)
+	})
+
+	t.Run("Update email", func(t *testing.T) {
+		user, err := udb.FindByEmail(db, "tomjones@mail.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.VerifyEmail("tom.jones@mail.com")
+		assert.Nil(t, udb.UpdateEmail(db, &gorsk.User{Base: user.Base, Email: user.Email, EmailVerifiedAt: user.EmailVerifiedAt}))
+		updated, err := udb.FindByEmail(db, "tom.jones@mail.com")
+		assert.Nil(t, err)
+		assert.True(t, updated.EmailVerified())
+		assert.Equal(t, "tomjones", updated.Username)
+	})
+}
+
+func TestEmailVerification(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.EmailVerification{})
+
+	udb := pgsql.NewUser()
+
+	create := func(hash string) *gorsk.EmailVerification {
+		ev, err := udb.CreateEmailVerification(db, gorsk.EmailVerification{
+			UserID:    1,
+			Email:     "johndoe@mail.com",
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ev
+	}
+	create("previoustoken")
+	created := create("hashedtoken")
+
+	t.Run("Token does not exist", func(t *testing.T) {
+		_, err := udb.FindEmailVerification(db, "unknown")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Previous token discarded", func(t *testing.T) {
+		_, err := udb.FindEmailVerification(db, "previoustoken")
+		assert.Equal(t, pg.ErrNoRows, err)
+	})
+
+	t.Run("Find and use token", func(t *testing.T) {
+		ev, err := udb.FindEmailVerification(db, "hashedtoken")
+		assert.Nil(t, err)
+		assert.Equal(t, created.ID, ev.ID)
+		assert.Equal(t, "johndoe@mail.com", ev.Email)
+		assert.Nil(t, udb.UseEmailVerification(db, ev))
+	})
+
+	t.Run("Token already used", func(t *testing.T) {
+		ev, err := udb.FindEmailVerification(db, "hashedtoken")
+		assert.Nil(t, err)
+		assert.False(t, ev.Valid(time.Now()))
+		assert.Equal(t, pg.ErrNoRows, udb.UseEmailVerification(db, &gorsk.EmailVerification{Base: gorsk.Base{ID: ev.ID}}))
+	})
+}
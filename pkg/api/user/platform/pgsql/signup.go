@@ -0,0 +1,48 @@
+package pgsql
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Custom errors
+var (
+	ErrCompanyExists = echo.NewHTTPError(http.StatusConflict, "Company name already exists.")
+)
+
+// CreateCompany creates a new company on database, unless one with the same name exists
+func (u *User) CreateCompany(db orm.DB, cmp gorsk.Company) (*gorsk.Company, error) {
+	var company = new(gorsk.Company)
+	err := db.Model(company).Where("lower(name) = ?", strings.ToLower(cmp.Name)).Select()
+	if err != pg.ErrNoRows {
+		if err == nil {
+			return nil, ErrCompanyExists
+		}
+		return nil, err
+	}
+
+	if err := db.Insert(&cmp); err != nil {
+		return nil, err
+	}
+
+	return &cmp, nil
+}
+
+// CreateLocation creates a new location on database
+func (u *User) CreateLocation(db orm.DB, loc gorsk.Location) (*gorsk.Location, error) {
+	if err := db.Insert(&loc); err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// UpdateCompanyOwner updates company's owner
+func (u *User) UpdateCompanyOwner(db orm.DB, cmp *gorsk.Company) error {
+	_, err := db.Model(cmp).Column("owner_id", "updated_at").WherePK().Update()
+	return err
+}
@@ -0,0 +1,45 @@
+package pgsql_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/api/user/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignup(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Company{}, &gorsk.Location{})
+
+	if err := mock.InsertMultiple(db, &gorsk.Company{Name: "Acme"}); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.NewUser()
+
+	t.Run("Company name exists", func(t *testing.T) {
+		_, err := udb.CreateCompany(db, gorsk.Company{Name: "ACME"})
+		assert.Equal(t, pgsql.ErrCompanyExists, err)
+	})
+
+	t.Run("Create company with location and owner", func(t *testing.T) {
+		cmp, err := udb.CreateCompany(db, gorsk.Company{Name: "Initech", Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loc, err := udb.CreateLocation(db, gorsk.Location{Name: "Headquarters", CompanyID: cmp.ID})
+		assert.Nil(t, err)
+		assert.NotZero(t, loc.ID)
+
+		cmp.OwnerID = 7
+		assert.Nil(t, udb.UpdateCompanyOwner(db, cmp))
+		updated := &gorsk.Company{Base: gorsk.Base{ID: cmp.ID}}
+		assert.Nil(t, db.Select(updated))
+		assert.Equal(t, 7, updated.OwnerID)
+		assert.Equal(t, "Initech", updated.Name)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/paging"
 )
 
 // NewUser returns a new user database instance
@@ -16,6 +17,9 @@ func NewUser() *User {
 	return &User{}
 }
 
+// likeEscaper escapes LIKE wildcards, so search matches them literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // User represents the client for user table
 type User struct{}
 
@@ -54,23 +58,116 @@ func (u *User) View(db orm.DB, id int) (*gorsk.User, error) {
 	return user, nil
 }
 
+// ViewRole returns role by ID
+func (u *User) ViewRole(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+	var role = &gorsk.Role{ID: id}
+	if err := db.Model(role).WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
 // Update updates user's contact info
 func (u *User) Update(db orm.DB, user *gorsk.User) error {
 	_, err := db.Model(user).UpdateNotNull()
 	return err
 }
 
-// List returns list of all users retrievable for the current user, depending on role
-func (u *User) List(db orm.DB, qp *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.User, error) {
+// List returns page of users retrievable for the current user, depending on role, matching filter f.
+// Filters are ANDed with the role's scope, so they can only narrow it.
+func (u *User) List(db orm.DB, qp *gorsk.ListQuery, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
 	var users []gorsk.User
-	q := db.Model(&users).Column("user.*", "Role").Limit(p.Limit).Offset(p.Offset).Where("deleted_at is null").Order("user.id desc")
+	q := db.Model(&users).Column("user.*", "Role")
+	sort := filter(q, qp, f)
+	cols := sortColumns(sort)
+	page, err := paging.Select(q, &users, cols, p, func(i int) []interface{} {
+		vals := make([]interface{}, 0, len(cols))
+		for _, s := range sort {
+			vals = append(vals, sortValue(&users[i], s.Field))
+		}
+		return append(vals, users[i].ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return users, page, nil
+}
+
+// sortValue returns value of user's field listed in gorsk.UserSortFields
+func sortValue(u *gorsk.User, field string) interface{} {
+	switch field {
+	case "first_name":
+		return u.FirstName
+	case "last_name":
+		return u.LastName
+	case "username":
+		return u.Username
+	case "email":
+		return u.Email
+	case "created_at":
+		return u.CreatedAt
+	case "last_login":
+		return u.LastLogin
+	default:
+		return u.ID
+	}
+}
+
+// Export calls fn for each user retrievable for the current user matching filter f, in the order of List,
+// without loading all of them into memory. Users' roles aren't loaded.
+func (u *User) Export(db orm.DB, qp *gorsk.ListQuery, f *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+	q := db.Model((*gorsk.User)(nil)).Column("user.*")
+	for _, c := range sortColumns(filter(q, qp, f)) {
+		dir := "ASC"
+		if c.Desc {
+			dir = "DESC"
+		}
+		q.OrderExpr("? "+dir+" NULLS LAST", pg.F(c.Name))
+	}
+	return q.ForEach(fn)
+}
+
+// sortColumns returns columns users are sorted by, ending with ID which makes the order unique
+func sortColumns(sort []gorsk.Sort) []paging.Column {
+	cols := make([]paging.Column, 0, len(sort)+1)
+	for _, s := range sort {
+		cols = append(cols, paging.Column{Name: "user." + s.Field, Desc: s.Desc})
+	}
+	return append(cols, paging.Column{Name: "user.id", Desc: true})
+}
+
+// filter restricts q to users within scope qp matching filter f, returning their sort order
+func filter(q *orm.Query, qp *gorsk.ListQuery, f *gorsk.UserFilter) []gorsk.Sort {
+	q.Where("deleted_at is null")
 	if qp != nil {
 		q.Where(qp.Query, qp.ID)
 	}
-	if err := q.Select(); err != nil {
-		return nil, err
+	if f == nil {
+		return nil
+	}
+	if f.Active != nil {
+		q.Where(`"user".active = ?`, *f.Active)
+	}
+	if f.RoleID != 0 {
+		q.Where(`"user".role_id = ?`, f.RoleID)
+	}
+	if f.CompanyID != 0 {
+		q.Where(`"user".company_id = ?`, f.CompanyID)
+	}
+	if f.LocationID != 0 {
+		q.Where(`"user".location_id = ?`, f.LocationID)
+	}
+	if !f.CreatedAfter.IsZero() {
+		q.Where(`"user".created_at > ?`, f.CreatedAfter)
+	}
+	if !f.LastLoginBefore.IsZero() {
+		q.Where(`"user".last_login < ?`, f.LastLoginBefore)
+	}
+	if f.Search != "" {
+		q.Where(`"user".first_name ILIKE ?0 OR "user".last_name ILIKE ?0 OR "user".username ILIKE ?0 OR "user".email ILIKE ?0`,
+			"%"+likeEscaper.Replace(f.Search)+"%")
 	}
-	return users, nil
+	return f.Sort
 }
 
 // Delete sets deleted_at for a user
@@ -259,11 +259,14 @@ func TestUpdate(t *testing.T) {
 
 func TestList(t *testing.T) {
 	cases := []struct {
-		name     string
-		wantErr  bool
-		qp       *gorsk.ListQuery
-		pg       *gorsk.Pagination
-		wantData []gorsk.User
+		name      string
+		wantErr   bool
+		qp        *gorsk.ListQuery
+		filter    *gorsk.UserFilter
+		pg        *gorsk.Pagination
+		wantData  []gorsk.User
+		wantEmpty bool
+		wantPage  *gorsk.Page
 	}{
 		{
 			name:    "Invalid pagination values",
@@ -280,7 +283,7 @@ func TestList(t *testing.T) {
 			},
 			qp: &gorsk.ListQuery{
 				ID:    1,
-				Query: "company_id = ?",
+				Query: `"user".company_id = ?`,
 			},
 			wantData: []gorsk.User{
 				{
@@ -318,10 +321,79 @@ func TestList(t *testing.T) {
 						AccessLevel: 1,
 						Name:        "SUPER_ADMIN",
 					},
-					Token: "loginrefresh",
 				},
 			},
 		},
+		{
+			name:     "Search and sort",
+			pg:       &gorsk.Pagination{Limit: 100},
+			wantPage: &gorsk.Page{Total: 1},
+			filter:   &gorsk.UserFilter{Search: "DOE", Sort: []gorsk.Sort{{Field: "last_name"}}},
+			wantData: []gorsk.User{
+				{
+					Email:      "johndoe@mail.com",
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "johndoe",
+					RoleID:     1,
+					CompanyID:  1,
+					LocationID: 1,
+					Password:   "hunter2",
+					Base: gorsk.Base{
+						ID: 1,
+					},
+					Role: &gorsk.Role{
+						ID:          1,
+						AccessLevel: 1,
+						Name:        "SUPER_ADMIN",
+					},
+				},
+			},
+		},
+		{
+			name:      "Wildcards are matched literally",
+			pg:        &gorsk.Pagination{Limit: 100},
+			filter:    &gorsk.UserFilter{Search: "%"},
+			wantEmpty: true,
+		},
+		{
+			name: "Page after cursor",
+			pg:   &gorsk.Pagination{Limit: 1, Cursor: &gorsk.Cursor{Values: []interface{}{"2"}}},
+			wantData: []gorsk.User{
+				{
+					Email:      "johndoe@mail.com",
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "johndoe",
+					RoleID:     1,
+					CompanyID:  1,
+					LocationID: 1,
+					Password:   "hunter2",
+					Base: gorsk.Base{
+						ID: 1,
+					},
+					Role: &gorsk.Role{
+						ID:          1,
+						AccessLevel: 1,
+						Name:        "SUPER_ADMIN",
+					},
+				},
+			},
+			wantPage: &gorsk.Page{Total: 2, Prev: &gorsk.Cursor{Values: []interface{}{1}, Before: true}},
+		},
+		{
+			name:      "Page before cursor",
+			pg:        &gorsk.Pagination{Limit: 1, Cursor: &gorsk.Cursor{Values: []interface{}{"2"}, Before: true}},
+			wantEmpty: true,
+			wantPage:  &gorsk.Page{Total: 2},
+		},
+		{
+			name:      "Filter doesn't widen scope",
+			pg:        &gorsk.Pagination{Limit: 100},
+			qp:        &gorsk.ListQuery{ID: 1, Query: `"user".company_id = ?`},
+			filter:    &gorsk.UserFilter{CompanyID: 2},
+			wantEmpty: true,
+		},
 	}
 
 	dbCon := mock.NewPGContainer(t)
@@ -340,7 +412,7 @@ func TestList(t *testing.T) {
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			users, err := udb.List(db, tt.qp, tt.pg)
+			users, page, err := udb.List(db, tt.qp, tt.filter, tt.pg)
 			assert.Equal(t, tt.wantErr, err != nil)
 			if tt.wantData != nil {
 				for i, v := range users {
@@ -349,6 +421,12 @@ func TestList(t *testing.T) {
 				}
 				assert.Equal(t, tt.wantData, users)
 			}
+			if tt.wantEmpty {
+				assert.Empty(t, users)
+			}
+			if tt.wantPage != nil {
+				assert.Equal(t, tt.wantPage, page)
+			}
 		})
 	}
 }
@@ -408,3 +486,53 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestViewRole(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{})
+
+	role := &gorsk.Role{ID: 201, AccessLevel: gorsk.UserRole, Name: "Auditor", CompanyID: 2}
+	if err := mock.InsertMultiple(db, role); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.NewUser()
+
+	resp, err := udb.ViewRole(db, 201)
+	assert.Nil(t, err)
+	assert.Equal(t, role, resp)
+
+	_, err = udb.ViewRole(db, 202)
+	assert.NotNil(t, err)
+}
+
+func TestExport(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.Role{}, &gorsk.User{})
+
+	if err := mock.InsertMultiple(db,
+		&gorsk.Role{ID: 1, AccessLevel: 1, Name: "SUPER_ADMIN"},
+		&gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Email: "johndoe@mail.com", LastName: "Doe", CompanyID: 1, LocationID: 1, RoleID: 1},
+		&gorsk.User{Base: gorsk.Base{ID: 2}, Username: "janedoe", Email: "janedoe@mail.com", LastName: "Doe", CompanyID: 1, LocationID: 1, RoleID: 1},
+		&gorsk.User{Base: gorsk.Base{ID: 3}, Username: "tomjones", Email: "tomjones@mail.com", LastName: "Jones", CompanyID: 2, LocationID: 1, RoleID: 1},
+	); err != nil {
+		t.Error(err)
+	}
+
+	udb := pgsql.NewUser()
+
+	var ids []int
+	err := udb.Export(db, &gorsk.ListQuery{ID: 1, Query: `"user".company_id = ?`}, &gorsk.UserFilter{Search: "doe"}, func(u *gorsk.User) error {
+		ids = append(ids, u.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, ids)
+
+	err = udb.Export(db, nil, nil, func(*gorsk.User) error { return gorsk.ErrGeneric })
+	assert.Equal(t, gorsk.ErrGeneric, err)
+}
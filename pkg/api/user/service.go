@@ -1,58 +1,127 @@
 package user
 
 import (
-	"github.com/go-pg/pg"
-	"github.com/go-pg/pg/orm"
-	"github.com/labstack/echo"
+	"context"
+	"time"
+
 	"github.com/figassis/goduck/pkg/api/user/platform/pgsql"
+	"github.com/figassis/goduck/pkg/utl/mail"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/postgres"
+	"github.com/figassis/goduck/pkg/utl/throttle"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 )
 
 // Service represents user application interface
 type Service interface {
-	Create(echo.Context, gorsk.User) (*gorsk.User, error)
-	List(echo.Context, *gorsk.Pagination) ([]gorsk.User, error)
-	View(echo.Context, int) (*gorsk.User, error)
-	Delete(echo.Context, int) error
-	Update(echo.Context, *Update) (*gorsk.User, error)
+	Create(context.Context, gorsk.User) (*gorsk.User, error)
+	List(context.Context, *gorsk.UserFilter, *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error)
+	View(context.Context, int) (*gorsk.User, error)
+	Delete(context.Context, int) error
+	Update(context.Context, *Update) (*gorsk.User, error)
+	Import(context.Context, []ImportRow, ImportOptions) ([]ImportResult, error)
+	Export(context.Context, *gorsk.UserFilter, func(*gorsk.User) error) error
+	VerifyEmail(context.Context, string) error
+	ResendVerification(context.Context, string) error
+	ChangeEmail(context.Context, string, string) error
+	Signup(context.Context, string, gorsk.User) (*gorsk.Company, error)
+}
+
+// Config represents email verification and signup configuration
+type Config struct {
+	// VerifyDuration is the lifetime of an issued verification token
+	VerifyDuration time.Duration
+	// VerifyURL is the address included in verification emails, token is appended as a query parameter
+	VerifyURL string
+	// ResendInterval is the minimal time between verification emails resent to the same address,
+	// and between email changes of the same user
+	ResendInterval time.Duration
+	// DisableSignup refuses self-service signups, leaving account creation to admins
+	DisableSignup bool
 }
 
+// Defaults used when email verification is not configured
+const (
+	DefaultVerifyDuration = 24 * time.Hour
+	DefaultResendInterval = time.Minute
+)
+
 // New creates new user application service
-func New(db *pg.DB, udb UDB, rbac RBAC, sec Securer) *User {
-	return &User{db: db, udb: udb, rbac: rbac, sec: sec}
+func New(db postgres.DB, udb UDB, lim Limiter, rbac RBAC, sec Securer, mailer Mailer, cfg *Config) *User {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.VerifyDuration == 0 {
+		cfg.VerifyDuration = DefaultVerifyDuration
+	}
+	if cfg.ResendInterval == 0 {
+		cfg.ResendInterval = DefaultResendInterval
+	}
+	return &User{db: db, udb: udb, lim: lim, rbac: rbac, sec: sec, mailer: mailer, cfg: cfg}
 }
 
 // Initialize initalizes User application service with defaults
-func Initialize(db *pg.DB, rbac RBAC, sec Securer) *User {
-	return New(db, pgsql.NewUser(), rbac, sec)
+func Initialize(db *pg.DB, rbac RBAC, sec Securer, mailer Mailer, cfg *Config) *User {
+	return New(postgres.Wrap(db), pgsql.NewUser(), throttle.NewPG(db), rbac, sec, mailer, cfg)
 }
 
 // User represents user application service
 type User struct {
-	db   *pg.DB
-	udb  UDB
-	rbac RBAC
-	sec  Securer
+	db     postgres.DB
+	udb    UDB
+	lim    Limiter
+	rbac   RBAC
+	sec    Securer
+	mailer Mailer
+	cfg    *Config
 }
 
 // Securer represents security interface
 type Securer interface {
-	Hash(string) string
+	Hash(string) (string, error)
+	HashMatchesPassword(string, string) bool
+	Password(string, ...string) bool
+	Breached(string) (bool, error)
+	RandomToken(string) (string, error)
+	TokenHash(string) string
+}
+
+// Limiter represents store of throttled actions interface
+type Limiter interface {
+	Get(context.Context, string) (*gorsk.LoginAttempt, error)
+	Lock(context.Context, string, time.Time) error
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(mail.Message) error
 }
 
 // UDB represents user repository interface
 type UDB interface {
 	Create(orm.DB, gorsk.User) (*gorsk.User, error)
 	View(orm.DB, int) (*gorsk.User, error)
-	List(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.User, error)
+	List(orm.DB, *gorsk.ListQuery, *gorsk.UserFilter, *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error)
+	Export(orm.DB, *gorsk.ListQuery, *gorsk.UserFilter, func(*gorsk.User) error) error
 	Update(orm.DB, *gorsk.User) error
 	Delete(orm.DB, *gorsk.User) error
+	ViewRole(orm.DB, gorsk.AccessRole) (*gorsk.Role, error)
+	FindByEmail(orm.DB, string) (*gorsk.User, error)
+	UpdateEmail(orm.DB, *gorsk.User) error
+	CreateEmailVerification(orm.DB, gorsk.EmailVerification) (*gorsk.EmailVerification, error)
+	FindEmailVerification(orm.DB, string) (*gorsk.EmailVerification, error)
+	UseEmailVerification(orm.DB, *gorsk.EmailVerification) error
+	CreateCompany(orm.DB, gorsk.Company) (*gorsk.Company, error)
+	CreateLocation(orm.DB, gorsk.Location) (*gorsk.Location, error)
+	UpdateCompanyOwner(orm.DB, *gorsk.Company) error
 }
 
 // RBAC represents role-based-access-control interface
 type RBAC interface {
-	User(echo.Context) *gorsk.AuthUser
-	EnforceUser(echo.Context, int) error
-	AccountCreate(echo.Context, gorsk.AccessRole, int, int) error
-	IsLowerRole(echo.Context, gorsk.AccessRole) error
+	User(context.Context) *gorsk.AuthUser
+	Enforce(context.Context, gorsk.Permission) error
+	EnforceUser(context.Context, gorsk.Permission, int) error
+	AccountCreate(context.Context, *gorsk.Role, int, int) error
+	IsLowerRole(context.Context, gorsk.AccessRole) error
 }
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"net/http"
+
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
+)
+
+// Custom errors
+var (
+	ErrSignupDisabled = echo.NewHTTPError(http.StatusForbidden, "signup is disabled")
+)
+
+// SignupLocation is the name of the location created with each signed up company
+const SignupLocation = "Headquarters"
+
+// Signup creates a new company with its default location, and the user as the company's admin and owner, in one transaction.
+// A verification token is emailed to the user once the company is created.
+func (u *User) Signup(ctx context.Context, company string, req gorsk.User) (*gorsk.Company, error) {
+	if u.cfg.DisableSignup {
+		return nil, ErrSignupDisabled
+	}
+
+	db := u.db.WithContext(ctx)
+	var cmp *gorsk.Company
+	err := u.db.RunInTransaction(ctx, func(tx orm.DB) error {
+		var err error
+		cmp, err = u.udb.CreateCompany(tx, gorsk.Company{Name: company, Active: true})
+		if err != nil {
+			return err
+		}
+
+		loc, err := u.udb.CreateLocation(tx, gorsk.Location{Name: SignupLocation, Active: true, CompanyID: cmp.ID})
+		if err != nil {
+			return err
+		}
+
+		req.CompanyID = cmp.ID
+		req.LocationID = loc.ID
+		req.RoleID = gorsk.CompanyAdminRole
+		req.Active = true
+		usr, err := u.insert(tx, req)
+		if err != nil {
+			return err
+		}
+
+		cmp.OwnerID = usr.ID
+		if err := u.udb.UpdateCompanyOwner(tx, cmp); err != nil {
+			return err
+		}
+		cmp.Locations = []gorsk.Location{*loc}
+		cmp.Owner = *usr
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.verifyCreated(db, &cmp.Owner)
+	return cmp, nil
+}
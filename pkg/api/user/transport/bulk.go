@@ -0,0 +1,321 @@
+package transport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/figassis/goduck/pkg/api/user"
+	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/server"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+)
+
+// maxImportRows limits the number of users created by a single import
+const maxImportRows = 1000
+
+// Custom errors
+var (
+	ErrTooManyRows      = echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("import is limited to %d rows", maxImportRows))
+	ErrUnknownMode      = echo.NewHTTPError(http.StatusBadRequest, "mode must be atomic or best_effort")
+	ErrUnknownFormat    = echo.NewHTTPError(http.StatusBadRequest, "format must be csv or ndjson")
+	ErrMissingCSVHeader = echo.NewHTTPError(http.StatusBadRequest, "CSV must start with a header row")
+)
+
+// importColumns are CSV columns of imported users, named like fields of JSON import rows
+var importColumns = map[string]func(r *userReq, v string) error{
+	"first_name":  func(r *userReq, v string) error { r.FirstName = v; return nil },
+	"last_name":   func(r *userReq, v string) error { r.LastName = v; return nil },
+	"username":    func(r *userReq, v string) error { r.Username = v; return nil },
+	"password":    func(r *userReq, v string) error { r.Password = v; return nil },
+	"email":       func(r *userReq, v string) error { r.Email = v; return nil },
+	"company_id":  func(r *userReq, v string) (err error) { r.CompanyID, err = atoi(v); return },
+	"location_id": func(r *userReq, v string) (err error) { r.LocationID, err = atoi(v); return },
+	"role_id": func(r *userReq, v string) error {
+		id, err := atoi(v)
+		r.RoleID = gorsk.AccessRole(id)
+		return err
+	},
+}
+
+// exportColumns are CSV columns of exported users
+var exportColumns = []string{"id", "first_name", "last_name", "username", "email", "mobile", "phone", "address",
+	"active", "company_id", "location_id", "role_id", "last_login", "created_at", "updated_at"}
+
+func atoi(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
+
+// Users import response
+type importResp struct {
+	DryRun bool `json:"dry_run"`
+	// Valid rows, whose users are created unless it is a dry run or an atomic import with invalid rows
+	Valid   int                 `json:"valid"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+	Rows    []user.ImportResult `json:"rows"`
+}
+
+func (h *HTTP) importUsers(c echo.Context) error {
+	var opt user.ImportOptions
+	if v := c.QueryParam("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return gorsk.ErrBadRequest
+		}
+		opt.DryRun = dryRun
+	}
+	switch c.QueryParam("mode") {
+	case "", "atomic":
+	case "best_effort":
+		opt.BestEffort = true
+	default:
+		return ErrUnknownMode
+	}
+
+	var (
+		reqs []userReq
+		errs []error
+		err  error
+	)
+	switch ctype := c.Request().Header.Get(echo.HeaderContentType); {
+	case strings.HasPrefix(ctype, "text/csv"):
+		reqs, errs, err = parseCSV(c.Request().Body)
+	case strings.HasPrefix(ctype, echo.MIMEApplicationJSON):
+		reqs, errs, err = parseJSON(c.Request().Body)
+	default:
+		return echo.ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return err
+	}
+
+	rows := make([]user.ImportRow, len(reqs))
+	for i := range reqs {
+		rows[i] = user.ImportRow{User: reqs[i].user(), Err: errs[i]}
+		if errs[i] != nil {
+			continue
+		}
+		if err := c.Validate(&reqs[i]); err != nil {
+			verrs, ok := err.(validator.ValidationErrors)
+			if !ok {
+				return err
+			}
+			rows[i].Err = echo.NewHTTPError(http.StatusBadRequest, strings.Join(server.ValidationMessages(verrs), ", "))
+		}
+	}
+
+	results, err := h.svc.Import(c.Request().Context(), rows, opt)
+	if err != nil {
+		return err
+	}
+
+	resp := importResp{DryRun: opt.DryRun, Rows: results}
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			resp.Failed++
+		case r.ID != 0:
+			resp.Valid++
+			resp.Created++
+		default:
+			resp.Valid++
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parseCSV reads users from CSV with a header row naming importColumns.
+// Rows which can't be read are reported by errors at their index.
+func parseCSV(r io.Reader) ([]userReq, []error, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, ErrMissingCSVHeader
+	}
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	for _, col := range header {
+		if importColumns[col] == nil {
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unknown column %q", col))
+		}
+	}
+
+	var (
+		reqs []userReq
+		errs []error
+	)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(reqs) == maxImportRows {
+			return nil, nil, ErrTooManyRows
+		}
+		if perr, ok := err.(*csv.ParseError); ok && perr.Err == csv.ErrFieldCount {
+			reqs, errs = append(reqs, userReq{}), append(errs, echo.NewHTTPError(http.StatusBadRequest, "wrong number of fields"))
+			continue
+		}
+		if err != nil {
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		var req userReq
+		var rerr error
+		for i, v := range record {
+			if err := importColumns[header[i]](&req, v); err != nil {
+				rerr = echo.NewHTTPError(http.StatusBadRequest, header[i]+" must be a number")
+			}
+		}
+		reqs, errs = append(reqs, req), append(errs, rerr)
+	}
+	return reqs, errs, nil
+}
+
+// parseJSON reads users from JSON array of objects.
+// Rows which can't be read are reported by errors at their index.
+func parseJSON(r io.Reader) ([]userReq, []error, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "request must be a JSON array of users")
+	}
+	if len(raw) > maxImportRows {
+		return nil, nil, ErrTooManyRows
+	}
+	reqs, errs := make([]userReq, len(raw)), make([]error, len(raw))
+	for i, m := range raw {
+		if err := json.Unmarshal(m, &reqs[i]); err != nil {
+			errs[i] = echo.NewHTTPError(http.StatusBadRequest, "invalid user: "+err.Error())
+		}
+	}
+	return reqs, errs, nil
+}
+
+// exportedUser includes user's role ID in exported JSON
+type exportedUser struct {
+	*gorsk.User
+	RoleID gorsk.AccessRole `json:"role_id"`
+}
+
+func (h *HTTP) export(c echo.Context) error {
+	r := new(listReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+	f, err := r.filter()
+	if err != nil {
+		return err
+	}
+
+	var (
+		res         = c.Response()
+		contentType string
+		filename    string
+		header      func() error
+		write       func(*gorsk.User) error
+		flush       func() error
+		fail        func()
+	)
+	switch c.QueryParam("format") {
+	case "", "csv":
+		w := csv.NewWriter(res)
+		header = func() error { return w.Write(exportColumns) }
+		write = func(u *gorsk.User) error { return w.Write(exportRecord(u)) }
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		fail = func() { w.Flush() }
+		contentType, filename = "text/csv; charset=utf-8", "users.csv"
+	case "ndjson":
+		enc := json.NewEncoder(res)
+		header = func() error { return nil }
+		write = func(u *gorsk.User) error { return enc.Encode(exportedUser{u, u.RoleID}) }
+		flush = func() error { return nil }
+		// Trailing error line tells apart exports cut short from complete ones
+		fail = func() { enc.Encode(map[string]string{"error": "export failed"}) }
+		contentType, filename = "application/x-ndjson", "users.ndjson"
+	default:
+		return ErrUnknownFormat
+	}
+
+	// Response starts with the first user, so errors before it, like forbidden access, are still reported
+	n := 0
+	start := func() error {
+		res.Header().Set(echo.HeaderContentType, contentType)
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+		res.WriteHeader(http.StatusOK)
+		return header()
+	}
+	err = h.svc.Export(c.Request().Context(), f, func(u *gorsk.User) error {
+		if n == 0 {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		n++
+		if err := write(u); err != nil {
+			return err
+		}
+		// Rows are sent in batches rather than buffered until the end
+		if n%100 == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil && n == 0 {
+		return err
+	}
+	if err != nil {
+		// Status is already sent, so the response is aborted for the client to notice the export is incomplete.
+		// The error is logged by the service.
+		fail()
+		res.Flush()
+		panic(http.ErrAbortHandler)
+	}
+	if n == 0 {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+func exportRecord(u *gorsk.User) []string {
+	return []string{
+		strconv.Itoa(u.ID), csvText(u.FirstName), csvText(u.LastName), csvText(u.Username), csvText(u.Email),
+		csvText(u.Mobile), csvText(u.Phone), csvText(u.Address),
+		strconv.FormatBool(u.Active), strconv.Itoa(u.CompanyID), strconv.Itoa(u.LocationID), strconv.Itoa(int(u.RoleID)),
+		formatTime(u.LastLogin), formatTime(u.CreatedAt), formatTime(u.UpdatedAt),
+	}
+}
+
+// csvText prefixes user supplied values starting like a formula with a quote,
+// so spreadsheets opening the export show them as text instead of evaluating them
+func csvText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
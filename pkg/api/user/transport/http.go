@@ -3,22 +3,118 @@ package transport
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/figassis/goduck/pkg/api/user"
 
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/paging"
 
 	"github.com/labstack/echo"
 )
 
 // HTTP represents user http service
 type HTTP struct {
-	svc user.Service
+	svc   user.Service
+	pager *paging.Pager
 }
 
 // NewHTTP creates new user http service
-func NewHTTP(svc user.Service, er *echo.Group) {
-	h := HTTP{svc}
+// Routes are authorized by require, which returns middleware checking user's permissions.
+func NewHTTP(svc user.Service, e *echo.Echo, er *echo.Group, require func(...gorsk.Permission) echo.MiddlewareFunc, pager *paging.Pager) {
+	h := HTTP{svc, pager}
+
+	// swagger:operation POST /verify-email users verifyEmail
+	// ---
+	// summary: Verifies user's email.
+	// description: If verification token is valid and unused, user's email is marked as verified. Tokens sent when changing email replace user's email with the new one.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/verifyEmail"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/verify-email", h.verifyEmail)
+
+	// swagger:operation POST /verify-email/resend users resendVerification
+	// ---
+	// summary: Resends email verification.
+	// description: Sends an email with a new verification token if an active user with the given unverified email exists. Always responds with 200, unless a verification was sent to the email recently.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/resendVerification"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "429":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/verify-email/resend", h.resendVerification)
+
+	// swagger:operation PATCH /v1/me/email users changeEmail
+	// ---
+	// summary: Changes current user's email.
+	// description: If user's password is correct, a verification token is sent to the new email and the current one is notified. The email is changed once the token is verified. No token is sent if the email belongs to another user, but the response is the same. Changes are throttled per user.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/changeEmail"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ok"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "429":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	er.PATCH("/me/email", h.changeEmail)
+
+	// swagger:operation POST /signup users signup
+	// ---
+	// summary: Signs up a new company.
+	// description: Creates a new company with a default location, and its first user as company admin and owner, in a single transaction. A verification token is emailed to the user. Fails with 403 if signup is disabled.
+	// parameters:
+	// - name: request
+	//   in: body
+	//   description: Request body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/signup"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/companyResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "403":
+	//     "$ref": "#/responses/errMsg"
+	//   "409":
+	//     "$ref": "#/responses/errMsg"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	e.POST("/signup", h.signup)
+
 	ur := er.Group("/users")
 	// swagger:route POST /v1/users users userCreate
 	// Creates new user account.
@@ -28,12 +124,81 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//  401: err
 	//  403: errMsg
 	//  500: err
-	ur.POST("", h.create)
+	ur.POST("", h.create, require(gorsk.PermUsersCreate))
+
+	// swagger:operation POST /v1/users/import users importUsers
+	// ---
+	// summary: Creates users in bulk.
+	// description: Creates users of all rows of a CSV file with a header row, or of a JSON array, in a single transaction. Each row is validated and authorized like a user create request, and errors are reported per row. In atomic mode no user is created if any row fails, in best_effort mode users of valid rows are created anyway. Imports are limited to 1000 rows.
+	// consumes:
+	// - text/csv
+	// - application/json
+	// parameters:
+	// - name: dry_run
+	//   in: query
+	//   description: checks all rows without creating any user
+	//   type: boolean
+	//   required: false
+	// - name: mode
+	//   in: query
+	//   description: atomic (default) or best_effort
+	//   type: string
+	//   required: false
+	// - name: request
+	//   in: body
+	//   description: CSV with columns first_name, last_name, username, password, email, company_id, location_id and role_id, or JSON array of objects with the same fields
+	//   required: true
+	//   schema:
+	//     type: array
+	//     items:
+	//       "$ref": "#/definitions/userCreate"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/userImportResp"
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "413":
+	//     "$ref": "#/responses/errMsg"
+	//   "415":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.POST("/import", h.importUsers, require(gorsk.PermUsersCreate))
+
+	// swagger:operation GET /v1/users/export users exportUsers
+	// ---
+	// summary: Exports users.
+	// description: Streams all users the user may list, accepting the same filters and sort as the list of users.
+	// produces:
+	// - text/csv
+	// - application/x-ndjson
+	// parameters:
+	// - name: format
+	//   in: query
+	//   description: csv (default) or ndjson
+	//   type: string
+	//   required: false
+	// responses:
+	//   "200":
+	//     description: Users as CSV with a header row, or as newline delimited JSON
+	//   "400":
+	//     "$ref": "#/responses/errMsg"
+	//   "401":
+	//     "$ref": "#/responses/err"
+	//   "403":
+	//     "$ref": "#/responses/err"
+	//   "500":
+	//     "$ref": "#/responses/err"
+	ur.GET("/export", h.export, require(gorsk.PermUsersRead))
 
 	// swagger:operation GET /v1/users users listUsers
 	// ---
 	// summary: Returns list of users.
-	// description: Returns list of users. Depending on the user role requesting it, it may return all users for SuperAdmin/Admin users, all company/location users for Company/Location admins, and an error for non-admin users.
+	// description: Returns list of users. Depending on the user role requesting it, it may return all users for SuperAdmin/Admin users, all company/location users for Company/Location admins, and an error for non-admin users. Links to the first, next and previous pages are returned in Link header.
 	// parameters:
 	// - name: limit
 	//   in: query
@@ -45,6 +210,53 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//   description: page number
 	//   type: int
 	//   required: false
+	// - name: cursor
+	//   in: query
+	//   description: next or prev cursor of a previous response with the same sort, replaces page
+	//   type: string
+	//   required: false
+	// - name: active
+	//   in: query
+	//   description: only active or inactive users
+	//   type: boolean
+	//   required: false
+	// - name: role_id
+	//   in: query
+	//   description: only users with the role
+	//   type: int
+	//   required: false
+	// - name: company_id
+	//   in: query
+	//   description: only users of the company
+	//   type: int
+	//   required: false
+	// - name: location_id
+	//   in: query
+	//   description: only users of the location
+	//   type: int
+	//   required: false
+	// - name: created_after
+	//   in: query
+	//   description: only users created after the time, in RFC3339 format
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: last_login_before
+	//   in: query
+	//   description: only users who last logged in before the time, in RFC3339 format
+	//   type: string
+	//   format: date-time
+	//   required: false
+	// - name: q
+	//   in: query
+	//   description: case-insensitive search in first name, last name, username and email
+	//   type: string
+	//   required: false
+	// - name: sort
+	//   in: query
+	//   description: comma separated fields to sort by, prefixed with - for descending order, e.g. -last_login,last_name. One of id, first_name, last_name, username, email, created_at, last_login.
+	//   type: string
+	//   required: false
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/userListResp"
@@ -56,7 +268,7 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	ur.GET("", h.list)
+	ur.GET("", h.list, require(gorsk.PermUsersRead))
 
 	// swagger:operation GET /v1/users/{id} users getUser
 	// ---
@@ -81,7 +293,7 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	ur.GET("/:id", h.view)
+	ur.GET("/:id", h.view, require(gorsk.PermUsersRead))
 
 	// swagger:operation PATCH /v1/users/{id} users userUpdate
 	// ---
@@ -110,7 +322,7 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	ur.PATCH("/:id", h.update)
+	ur.PATCH("/:id", h.update, require(gorsk.PermUsersUpdate))
 
 	// swagger:operation DELETE /v1/users/{id} users userDelete
 	// ---
@@ -133,7 +345,7 @@ func NewHTTP(svc user.Service, er *echo.Group) {
 	//     "$ref": "#/responses/err"
 	//   "500":
 	//     "$ref": "#/responses/err"
-	ur.DELETE("/:id", h.delete)
+	ur.DELETE("/:id", h.delete, require(gorsk.PermUsersDelete))
 }
 
 // Custom errors
@@ -144,16 +356,44 @@ var (
 // User create request
 // swagger:model userCreate
 type createReq struct {
-	FirstName       string `json:"first_name" validate:"required"`
-	LastName        string `json:"last_name" validate:"required"`
-	Username        string `json:"username" validate:"required,min=3,alphanum"`
-	Password        string `json:"password" validate:"required,min=8"`
+	userReq
 	PasswordConfirm string `json:"password_confirm" validate:"required"`
-	Email           string `json:"email" validate:"required,email"`
+}
+
+// New user's fields, shared by create and import requests
+type userReq struct {
+	accountReq
 
 	CompanyID  int              `json:"company_id" validate:"required"`
 	LocationID int              `json:"location_id" validate:"required"`
-	RoleID     gorsk.AccessRole `json:"role_id" validate:"required"`
+	RoleID     gorsk.AccessRole `json:"role_id" validate:"required,min=1"`
+}
+
+func (r *userReq) user() gorsk.User {
+	usr := r.accountReq.user()
+	usr.CompanyID = r.CompanyID
+	usr.LocationID = r.LocationID
+	usr.RoleID = r.RoleID
+	return usr
+}
+
+// New user's account fields, shared by user and signup requests
+type accountReq struct {
+	FirstName string `json:"first_name" validate:"required"`
+	LastName  string `json:"last_name" validate:"required"`
+	Username  string `json:"username" validate:"required,min=3,alphanum"`
+	Password  string `json:"password" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+}
+
+func (r *accountReq) user() gorsk.User {
+	return gorsk.User{
+		Username:  r.Username,
+		Password:  r.Password,
+		Email:     r.Email,
+		FirstName: r.FirstName,
+		LastName:  r.LastName,
+	}
 }
 
 func (h *HTTP) create(c echo.Context) error {
@@ -168,20 +408,7 @@ func (h *HTTP) create(c echo.Context) error {
 		return ErrPasswordsNotMaching
 	}
 
-	if r.RoleID < gorsk.SuperAdminRole || r.RoleID > gorsk.UserRole {
-		return gorsk.ErrBadRequest
-	}
-
-	usr, err := h.svc.Create(c, gorsk.User{
-		Username:   r.Username,
-		Password:   r.Password,
-		Email:      r.Email,
-		FirstName:  r.FirstName,
-		LastName:   r.LastName,
-		CompanyID:  r.CompanyID,
-		LocationID: r.LocationID,
-		RoleID:     r.RoleID,
-	})
+	usr, err := h.svc.Create(c.Request().Context(), r.user())
 
 	if err != nil {
 		return err
@@ -193,21 +420,91 @@ func (h *HTTP) create(c echo.Context) error {
 type listResponse struct {
 	Users []gorsk.User `json:"users"`
 	Page  int          `json:"page"`
+	*paging.Meta
+}
+
+// User list request, filtering users by the fields which are set
+type listReq struct {
+	gorsk.PaginationReq
+	Active          string `query:"active"`
+	RoleID          int    `query:"role_id"`
+	CompanyID       int    `query:"company_id"`
+	LocationID      int    `query:"location_id"`
+	CreatedAfter    string `query:"created_after"`
+	LastLoginBefore string `query:"last_login_before"`
+	Query           string `query:"q"`
+	Sort            string `query:"sort"`
+}
+
+// filter checks and converts list request into user filter
+func (r *listReq) filter() (*gorsk.UserFilter, error) {
+	f := &gorsk.UserFilter{
+		RoleID:     gorsk.AccessRole(r.RoleID),
+		CompanyID:  r.CompanyID,
+		LocationID: r.LocationID,
+		Search:     strings.TrimSpace(r.Query),
+	}
+	var err error
+	if r.Active != "" {
+		active, err := strconv.ParseBool(r.Active)
+		if err != nil {
+			return nil, gorsk.ErrBadRequest
+		}
+		f.Active = &active
+	}
+	if f.CreatedAfter, err = parseTime(r.CreatedAfter); err != nil {
+		return nil, err
+	}
+	if f.LastLoginBefore, err = parseTime(r.LastLoginBefore); err != nil {
+		return nil, err
+	}
+	if f.Sort, err = gorsk.ParseSort(r.Sort, gorsk.UserSortFields...); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, gorsk.ErrBadRequest
+	}
+	return t, nil
 }
 
 func (h *HTTP) list(c echo.Context) error {
-	p := new(gorsk.PaginationReq)
-	if err := c.Bind(p); err != nil {
+	r := new(listReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	f, err := r.filter()
+	if err != nil {
 		return err
 	}
 
-	result, err := h.svc.List(c, p.Transform())
+	// Cursors are valid only for the sort they were issued for
+	list := "users?sort=" + r.Sort
+	p, err := h.pager.Transform(&r.PaginationReq, list)
+	if err != nil {
+		return err
+	}
+
+	result, page, err := h.svc.List(c.Request().Context(), f, p)
 
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, listResponse{result, p.Page})
+	meta, err := h.pager.Respond(c, page, list)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, listResponse{result, r.Page, meta})
 }
 
 func (h *HTTP) view(c echo.Context) error {
@@ -216,7 +513,7 @@ func (h *HTTP) view(c echo.Context) error {
 		return gorsk.ErrBadRequest
 	}
 
-	result, err := h.svc.View(c, id)
+	result, err := h.svc.View(c.Request().Context(), id)
 	if err != nil {
 		return err
 	}
@@ -246,7 +543,7 @@ func (h *HTTP) update(c echo.Context) error {
 		return err
 	}
 
-	usr, err := h.svc.Update(c, &user.Update{
+	usr, err := h.svc.Update(c.Request().Context(), &user.Update{
 		ID:        id,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
@@ -268,9 +565,93 @@ func (h *HTTP) delete(c echo.Context) error {
 		return gorsk.ErrBadRequest
 	}
 
-	if err := h.svc.Delete(c, id); err != nil {
+	if err := h.svc.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Email verification request
+// swagger:model verifyEmail
+type verifyEmailReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (h *HTTP) verifyEmail(c echo.Context) error {
+	r := new(verifyEmailReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.VerifyEmail(c.Request().Context(), r.Token); err != nil {
 		return err
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+// Email verification resend request
+// swagger:model resendVerification
+type resendVerificationReq struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *HTTP) resendVerification(c echo.Context) error {
+	r := new(resendVerificationReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.ResendVerification(c.Request().Context(), r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Email change request
+// swagger:model changeEmail
+type changeEmailReq struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (h *HTTP) changeEmail(c echo.Context) error {
+	r := new(changeEmailReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if err := h.svc.ChangeEmail(c.Request().Context(), r.Password, r.Email); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// Signup request
+// swagger:model signup
+type signupReq struct {
+	accountReq
+	PasswordConfirm string `json:"password_confirm" validate:"required"`
+	CompanyName     string `json:"company_name" validate:"required"`
+}
+
+func (h *HTTP) signup(c echo.Context) error {
+	r := new(signupReq)
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+
+	if r.Password != r.PasswordConfirm {
+		return ErrPasswordsNotMaching
+	}
+
+	cmp, err := h.svc.Signup(c.Request().Context(), r.CompanyName, r.user())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, cmp)
+}
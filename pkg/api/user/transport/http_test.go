@@ -2,25 +2,35 @@ package transport_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/figassis/goduck/pkg/api/user"
 	"github.com/figassis/goduck/pkg/api/user/transport"
 
+	"github.com/figassis/goduck/pkg/utl/mail"
 	"github.com/figassis/goduck/pkg/utl/mock"
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
+	"github.com/figassis/goduck/pkg/utl/paging"
 	"github.com/figassis/goduck/pkg/utl/server"
+	"github.com/figassis/goduck/pkg/utl/throttle"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
 )
 
+var pager = paging.New([]byte("secret"), 0)
+
 func TestCreate(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -44,9 +54,9 @@ func TestCreate(t *testing.T) {
 		{
 			name: "Fail on invalid role",
 			req:  `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_id":1,"location_id":2,"role_id":50}`,
-			rbac: &mock.RBAC{
-				AccountCreateFn: func(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
-					return echo.ErrForbidden
+			udb: &mockdb.User{
+				ViewRoleFn: func(orm.DB, gorsk.AccessRole) (*gorsk.Role, error) {
+					return nil, pg.ErrNoRows
 				},
 			},
 			wantStatus: http.StatusBadRequest,
@@ -54,8 +64,13 @@ func TestCreate(t *testing.T) {
 		{
 			name: "Fail on RBAC",
 			req:  `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_id":1,"location_id":2,"role_id":200}`,
+			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: id}, nil
+				},
+			},
 			rbac: &mock.RBAC{
-				AccountCreateFn: func(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -66,21 +81,39 @@ func TestCreate(t *testing.T) {
 			name: "Success",
 			req:  `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_id":1,"location_id":2,"role_id":200}`,
 			rbac: &mock.RBAC{
-				AccountCreateFn: func(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
 					return nil
 				},
 			},
 			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: id}, nil
+				},
 				CreateFn: func(db orm.DB, usr gorsk.User) (*gorsk.User, error) {
 					usr.ID = 1
 					usr.CreatedAt = mock.TestTime(2018)
 					usr.UpdatedAt = mock.TestTime(2018)
 					return &usr, nil
 				},
+				CreateEmailVerificationFn: func(db orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+					return &ev, nil
+				},
 			},
 			sec: &mock.Secure{
-				HashFn: func(string) string {
-					return "h4$h3d"
+				PasswordFn: func(string, ...string) bool {
+					return true
+				},
+				BreachedFn: func(string) (bool, error) {
+					return false, nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+				RandomTokenFn: func(prefix string) (string, error) {
+					return prefix + "token", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
 			wantResp: &gorsk.User{
@@ -104,7 +137,7 @@ func TestCreate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, mail.NewMemory("noreply@mail.com"), nil), r, rg, mock.RequirePermission, pager)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users"
@@ -129,12 +162,20 @@ func TestList(t *testing.T) {
 	type listResponse struct {
 		Users []gorsk.User `json:"users"`
 		Page  int          `json:"page"`
+		Total int          `json:"total"`
+		Next  string       `json:"next"`
+		Prev  string       `json:"prev"`
+	}
+	cursor, err := pager.Encode(&gorsk.Cursor{Values: []interface{}{11}, Before: true}, "users?sort=")
+	if err != nil {
+		t.Fatal(err)
 	}
 	cases := []struct {
 		name       string
 		req        string
 		wantStatus int
 		wantResp   *listResponse
+		wantLinks  []string
 		udb        *mockdb.User
 		rbac       *mock.RBAC
 		sec        *mock.Secure
@@ -144,11 +185,55 @@ func TestList(t *testing.T) {
 			req:        `?limit=2222&page=-1`,
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name:       "Invalid active filter",
+			req:        `?active=maybe`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid created_after filter",
+			req:        `?created_after=2018-01-01`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid sort field",
+			req:        `?sort=-password`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Filters",
+			req:  `?active=false&role_id=200&company_id=2&location_id=3&created_after=2018-01-01T00:00:00Z&last_login_before=2019-01-01T00:00:00Z&q=+Doe+&sort=-last_login,last_name`,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{Role: gorsk.AdminRole}
+				}},
+			udb: &mockdb.User{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
+					active := false
+					if !reflect.DeepEqual(f, &gorsk.UserFilter{
+						Active:          &active,
+						RoleID:          gorsk.UserRole,
+						CompanyID:       2,
+						LocationID:      3,
+						CreatedAfter:    time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+						LastLoginBefore: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+						Search:          "Doe",
+						Sort:            []gorsk.Sort{{Field: "last_login", Desc: true}, {Field: "last_name"}},
+					}) {
+						return nil, nil, gorsk.ErrGeneric
+					}
+					return []gorsk.User{}, &gorsk.Page{}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Users: []gorsk.User{}},
+		},
 		{
 			name: "Fail on query list",
 			req:  `?limit=100&page=1`,
 			rbac: &mock.RBAC{
-				UserFn: func(c echo.Context) *gorsk.AuthUser {
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{
 						ID:         1,
 						CompanyID:  2,
@@ -163,7 +248,8 @@ func TestList(t *testing.T) {
 			name: "Success",
 			req:  `?limit=100&page=1`,
 			rbac: &mock.RBAC{
-				UserFn: func(c echo.Context) *gorsk.AuthUser {
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{
 						ID:         1,
 						CompanyID:  2,
@@ -173,7 +259,7 @@ func TestList(t *testing.T) {
 					}
 				}},
 			udb: &mockdb.User{
-				ListFn: func(db orm.DB, q *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.User, error) {
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
 					if p.Limit == 100 && p.Offset == 100 {
 						return []gorsk.User{
 							{
@@ -210,9 +296,9 @@ func TestList(t *testing.T) {
 									Name:        "ADMIN",
 								},
 							},
-						}, nil
+						}, &gorsk.Page{Total: 202, Prev: &gorsk.Cursor{Values: []interface{}{10}, Before: true}, Next: &gorsk.Cursor{Values: []interface{}{11}}}, nil
 					}
-					return nil, gorsk.ErrGeneric
+					return nil, nil, gorsk.ErrGeneric
 				},
 			},
 			wantStatus: http.StatusOK,
@@ -252,7 +338,37 @@ func TestList(t *testing.T) {
 							Name:        "ADMIN",
 						},
 					},
-				}, Page: 1},
+				}, Page: 1, Total: 202, Next: "next", Prev: "prev"},
+			wantLinks: []string{`rel="first"`, `rel="prev"`, `rel="next"`},
+		},
+		{
+			name:       "Invalid cursor",
+			req:        `?cursor=abc`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Cursor of another sort",
+			req:        `?sort=last_name&cursor=` + cursor,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Cursor",
+			req:  `?limit=20&page=3&cursor=` + cursor,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{Role: gorsk.AdminRole}
+				}},
+			udb: &mockdb.User{
+				ListFn: func(db orm.DB, q *gorsk.ListQuery, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
+					if !reflect.DeepEqual(p, &gorsk.Pagination{Limit: 20, Cursor: &gorsk.Cursor{Values: []interface{}{json.Number("11")}, Before: true}}) {
+						return nil, nil, gorsk.ErrGeneric
+					}
+					return []gorsk.User{}, &gorsk.Page{Total: 40}, nil
+				}},
+			wantStatus: http.StatusOK,
+			wantResp:   &listResponse{Users: []gorsk.User{}, Page: 3, Total: 40},
+			wantLinks:  []string{`rel="first"`},
 		},
 	}
 
@@ -260,7 +376,7 @@ func TestList(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, nil, nil), r, rg, mock.RequirePermission, pager)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users" + tt.req
@@ -274,8 +390,18 @@ func TestList(t *testing.T) {
 				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
 					t.Fatal(err)
 				}
+				// Cursors are encrypted with random nonces, so only their presence is compared
+				if response.Next != "" {
+					response.Next = "next"
+				}
+				if response.Prev != "" {
+					response.Prev = "prev"
+				}
 				assert.Equal(t, tt.wantResp, response)
 			}
+			for _, l := range tt.wantLinks {
+				assert.Contains(t, res.Header.Get("Link"), l)
+			}
 			assert.Equal(t, tt.wantStatus, res.StatusCode)
 		})
 	}
@@ -300,7 +426,7 @@ func TestView(t *testing.T) {
 			name: "Fail on RBAC",
 			req:  `1`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -310,7 +436,7 @@ func TestView(t *testing.T) {
 			name: "Success",
 			req:  `1`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
 					return nil
 				},
 			},
@@ -346,7 +472,7 @@ func TestView(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, nil, nil), r, rg, mock.RequirePermission, pager)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.req
@@ -394,7 +520,7 @@ func TestUpdate(t *testing.T) {
 			id:   `1`,
 			req:  `{"first_name":"jj","last_name":"okocha","mobile":"123456","phone":"321321","address":"home"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -405,7 +531,7 @@ func TestUpdate(t *testing.T) {
 			id:   `1`,
 			req:  `{"first_name":"jj","last_name":"okocha","phone":"321321","address":"home"}`,
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(echo.Context, int) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error {
 					return nil
 				},
 			},
@@ -454,7 +580,7 @@ func TestUpdate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, nil, nil), r, rg, mock.RequirePermission, pager)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.id
@@ -504,7 +630,8 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error { return nil },
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
 					return echo.ErrForbidden
 				},
 			},
@@ -526,7 +653,8 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error { return nil },
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
 					return nil
 				},
 			},
@@ -540,7 +668,7 @@ func TestDelete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			r := server.New()
 			rg := r.Group("")
-			transport.NewHTTP(user.New(nil, tt.udb, tt.rbac, tt.sec), rg)
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, nil, nil), r, rg, mock.RequirePermission, pager)
 			ts := httptest.NewServer(r)
 			defer ts.Close()
 			path := ts.URL + "/users/" + tt.id
@@ -554,3 +682,569 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestImport(t *testing.T) {
+	type importResp struct {
+		DryRun  bool                `json:"dry_run"`
+		Valid   int                 `json:"valid"`
+		Created int                 `json:"created"`
+		Failed  int                 `json:"failed"`
+		Rows    []user.ImportResult `json:"rows"`
+	}
+	rbac := &mock.RBAC{
+		EnforceFn:       func(context.Context, gorsk.Permission) error { return nil },
+		AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error { return nil },
+	}
+	udb := &mockdb.User{
+		ViewRoleFn: func(_ orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+			return &gorsk.Role{ID: id, AccessLevel: id}, nil
+		},
+		CreateFn: func(_ orm.DB, u gorsk.User) (*gorsk.User, error) {
+			if u.Password != "h4sh3d" || u.RoleID != gorsk.UserRole || u.CompanyID != 1 || u.LocationID != 2 {
+				return nil, gorsk.ErrGeneric
+			}
+			u.ID = len(u.Username)
+			return &u, nil
+		},
+		CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+			return &ev, nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn:    func(pass string, _ ...string) bool { return len(pass) >= 8 },
+		BreachedFn:    func(string) (bool, error) { return false, nil },
+		HashFn:        func(string) (string, error) { return "h4sh3d", nil },
+		RandomTokenFn: func(prefix string) (string, error) { return prefix + "token", nil },
+		TokenHashFn:   func(string) string { return "hashedtoken" },
+	}
+	cases := []struct {
+		name        string
+		query       string
+		contentType string
+		req         string
+		wantStatus  int
+		wantResp    *importResp
+	}{
+		{
+			name:        "Unsupported media type",
+			contentType: "text/plain",
+			req:         "johndoe",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Invalid mode",
+			query:       "?mode=some",
+			contentType: "text/csv",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "Unknown CSV column",
+			contentType: "text/csv",
+			req:         "username,admin\njohndoe,true\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid JSON",
+			contentType: "application/json",
+			req:         `{"username":"johndoe"}`,
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "CSV best effort",
+			query:       "?mode=best_effort",
+			contentType: "text/csv",
+			req: "first_name,last_name,username,password,email,company_id,location_id,role_id\n" +
+				"John,Doe,johndoe,hunter123,johndoe@mail.com,1,2,200\n" +
+				"Jane,Doe,janedoe,hunter123,janedoe@mail.com,one,2,200\n" +
+				"Joe,Doe,joedoe,hunter,joedoe@mail.com,1,2,200\n" +
+				"Joe,Doe\n" +
+				"Tom,Jones,tomjones,hunter123,tomjones@mail.com,1,2,200\n",
+			wantStatus: http.StatusOK,
+			wantResp: &importResp{Valid: 2, Created: 2, Failed: 3, Rows: []user.ImportResult{
+				{Row: 1, ID: 7},
+				{Row: 2, Error: "company_id must be a number"},
+				{Row: 3, Error: "insecure password"},
+				{Row: 4, Error: "wrong number of fields"},
+				{Row: 5, ID: 8},
+			}},
+		},
+		{
+			name:        "JSON dry run",
+			query:       "?dry_run=true",
+			contentType: "application/json",
+			req: `[{"first_name":"John","last_name":"Doe","username":"johndoe","password":"hunter123","email":"johndoe@mail.com","company_id":1,"location_id":2,"role_id":200},` +
+				`{"first_name":"Jane","last_name":"Doe","username":"janedoe","password":"hunter123","email":"janedoe@mail.com","company_id":"1","location_id":2,"role_id":200}]`,
+			wantStatus: http.StatusOK,
+			wantResp: &importResp{DryRun: true, Valid: 1, Failed: 1, Rows: []user.ImportResult{
+				{Row: 1},
+				{Row: 2, Error: "invalid user: json: cannot unmarshal string into Go struct field userReq.company_id of type int"},
+			}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(mockdb.DB{}, udb, nil, rbac, sec, mail.NewMemory("noreply@mail.com"), nil), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/users/import"+tt.query, tt.contentType, bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(importResp)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	users := []gorsk.User{
+		{
+			Base:       gorsk.Base{ID: 1, CreatedAt: mock.TestTime(2018)},
+			FirstName:  "John",
+			LastName:   "Doe, Jr.",
+			Username:   "johndoe",
+			Email:      "johndoe@mail.com",
+			Active:     true,
+			CompanyID:  1,
+			LocationID: 2,
+			RoleID:     gorsk.UserRole,
+		},
+	}
+	cases := []struct {
+		name            string
+		req             string
+		rbac            *mock.RBAC
+		udb             *mockdb.User
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantAborted     bool
+	}{
+		{
+			name:       "Invalid format",
+			req:        "?format=xml",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Fail on RBAC",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return echo.ErrForbidden },
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "CSV",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.AdminRole} },
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,first_name,last_name,username,email,mobile,phone,address,active,company_id,location_id,role_id,last_login,created_at,updated_at\n" +
+				`1,John,"Doe, Jr.",johndoe,johndoe@mail.com,,,,true,1,2,200,,2018-05-19T01:02:03Z,` + "\n",
+		},
+		{
+			name: "CSV with formulas",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.AdminRole} },
+			},
+			udb: &mockdb.User{
+				ExportFn: func(_ orm.DB, _ *gorsk.ListQuery, _ *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+					return fn(&gorsk.User{Base: gorsk.Base{ID: 2}, FirstName: "=HYPERLINK(\"http://evil\")", LastName: "-2+3",
+						Username: "@sum", Phone: "+1 555 0100", Address: "Main St. 1", RoleID: gorsk.UserRole})
+				},
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,first_name,last_name,username,email,mobile,phone,address,active,company_id,location_id,role_id,last_login,created_at,updated_at\n" +
+				`2,"'=HYPERLINK(""http://evil"")",'-2+3,'@sum,,,'+1 555 0100,Main St. 1,false,0,0,200,,,` + "\n",
+		},
+		{
+			name: "CSV aborted on failure",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.AdminRole} },
+			},
+			udb: &mockdb.User{
+				ExportFn: func(_ orm.DB, _ *gorsk.ListQuery, _ *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+					if err := fn(&users[0]); err != nil {
+						return err
+					}
+					return gorsk.ErrGeneric
+				},
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody: "id,first_name,last_name,username,email,mobile,phone,address,active,company_id,location_id,role_id,last_login,created_at,updated_at\n" +
+				`1,John,"Doe, Jr.",johndoe,johndoe@mail.com,,,,true,1,2,200,,2018-05-19T01:02:03Z,` + "\n",
+			wantAborted: true,
+		},
+		{
+			name: "NDJSON aborted on failure",
+			req:  "?format=ndjson",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.AdminRole} },
+			},
+			udb: &mockdb.User{
+				ExportFn: func(_ orm.DB, _ *gorsk.ListQuery, _ *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+					if err := fn(&users[0]); err != nil {
+						return err
+					}
+					return gorsk.ErrGeneric
+				},
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody: `{"id":1,"created_at":"2018-05-19T01:02:03.000000004Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":"0001-01-01T00:00:00Z","first_name":"John","last_name":"Doe, Jr.",` +
+				`"username":"johndoe","email":"johndoe@mail.com","email_verified_at":"0001-01-01T00:00:00Z","active":true,"last_login":"0001-01-01T00:00:00Z","last_password_change":"0001-01-01T00:00:00Z",` +
+				`"password_change_required":false,"mfa_enabled":false,"company_id":1,"location_id":2,"role_id":200}` + "\n" +
+				`{"error":"export failed"}` + "\n",
+			wantAborted: true,
+		},
+		{
+			name: "NDJSON",
+			req:  "?format=ndjson&q=doe",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.AdminRole} },
+			},
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody: `{"id":1,"created_at":"2018-05-19T01:02:03.000000004Z","updated_at":"0001-01-01T00:00:00Z","deleted_at":"0001-01-01T00:00:00Z","first_name":"John","last_name":"Doe, Jr.",` +
+				`"username":"johndoe","email":"johndoe@mail.com","email_verified_at":"0001-01-01T00:00:00Z","active":true,"last_login":"0001-01-01T00:00:00Z","last_password_change":"0001-01-01T00:00:00Z",` +
+				`"password_change_required":false,"mfa_enabled":false,"company_id":1,"location_id":2,"role_id":200}` + "\n",
+		},
+	}
+	udb := &mockdb.User{
+		ExportFn: func(_ orm.DB, _ *gorsk.ListQuery, _ *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+			for i := range users {
+				if err := fn(&users[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			db := udb
+			if tt.udb != nil {
+				db = tt.udb
+			}
+			transport.NewHTTP(user.New(mockdb.DB{}, db, nil, tt.rbac, nil, nil, nil), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Get(ts.URL + "/users/export" + tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantBody != "" {
+				body, err := ioutil.ReadAll(res.Body)
+				assert.Equal(t, tt.wantAborted, err != nil)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Equal(t, tt.wantContentType, res.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		udb        *mockdb.User
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"token":""}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid token",
+			req:  `{"token":"ev_token"}`,
+			udb: &mockdb.User{
+				FindEmailVerificationFn: func(orm.DB, string) (*gorsk.EmailVerification, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			sec := &mock.Secure{TokenHashFn: func(string) string { return "hashedtoken" }}
+			transport.NewHTTP(user.New(mockdb.DB{}, tt.udb, nil, nil, sec, nil, nil), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/verify-email", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"notanemail"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown email",
+			req:        `{"email":"unknown@mail.com"}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	udb := &mockdb.User{
+		FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+			return nil, pg.ErrNoRows
+		},
+	}
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(mockdb.DB{}, udb, throttle.NewMemory(), nil, nil, nil, nil), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("POST", ts.URL+"/verify-email/resend", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		wantStatus int
+		sec        *mock.Secure
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"email":"notanemail","password":"hunter123"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on missing password",
+			req:        `{"email":"janedoe@mail.com"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Incorrect password",
+			req:  `{"email":"janedoe@mail.com","password":"hunter123"}`,
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return false
+				},
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Success",
+			req:  `{"email":"janedoe@mail.com","password":"hunter123"}`,
+			sec: &mock.Secure{
+				HashMatchesPasswordFn: func(string, string) bool {
+					return true
+				},
+				RandomTokenFn: func(prefix string) (string, error) {
+					return prefix + "token", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
+				},
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	udb := &mockdb.User{
+		ViewFn: func(_ orm.DB, id int) (*gorsk.User, error) {
+			return &gorsk.User{Base: gorsk.Base{ID: id}, Email: "johndoe@mail.com", Password: "h4sh3d"}, nil
+		},
+		FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+			return nil, pg.ErrNoRows
+		},
+		CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+			return &ev, nil
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1}
+		},
+	}
+	client := &http.Client{}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(mockdb.DB{}, udb, throttle.NewMemory(), rbac, tt.sec, mail.NewMemory("noreply@mail.com"), nil), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			req, err := http.NewRequest("PATCH", ts.URL+"/me/email", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestSignup(t *testing.T) {
+	cases := []struct {
+		name       string
+		req        string
+		cfg        *user.Config
+		wantStatus int
+		wantResp   *gorsk.Company
+	}{
+		{
+			name:       "Fail on validation",
+			req:        `{"first_name":"John","last_name":"Doe","username":"ju","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_name":"Acme"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on missing company name",
+			req:        `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Fail on non-matching passwords",
+			req:        `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter1234","email":"johndoe@gmail.com","company_name":"Acme"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Signup disabled",
+			req:        `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_name":"Acme"}`,
+			cfg:        &user.Config{DisableSignup: true},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Success",
+			req:        `{"first_name":"John","last_name":"Doe","username":"juzernejm","password":"hunter123","password_confirm":"hunter123","email":"johndoe@gmail.com","company_name":"Acme"}`,
+			wantStatus: http.StatusOK,
+			wantResp: &gorsk.Company{
+				Base:      gorsk.Base{ID: 1},
+				Name:      "Acme",
+				Active:    true,
+				Locations: []gorsk.Location{{Base: gorsk.Base{ID: 2}, Name: user.SignupLocation, Active: true, CompanyID: 1}},
+				OwnerID:   3,
+				Owner: gorsk.User{
+					Base:       gorsk.Base{ID: 3},
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "juzernejm",
+					Email:      "johndoe@gmail.com",
+					Active:     true,
+					CompanyID:  1,
+					LocationID: 2,
+				},
+			},
+		},
+	}
+
+	udb := &mockdb.User{
+		CreateCompanyFn: func(_ orm.DB, c gorsk.Company) (*gorsk.Company, error) {
+			c.ID = 1
+			return &c, nil
+		},
+		CreateLocationFn: func(_ orm.DB, l gorsk.Location) (*gorsk.Location, error) {
+			l.ID = 2
+			return &l, nil
+		},
+		CreateFn: func(_ orm.DB, u gorsk.User) (*gorsk.User, error) {
+			u.ID = 3
+			return &u, nil
+		},
+		UpdateCompanyOwnerFn: func(orm.DB, *gorsk.Company) error {
+			return nil
+		},
+		CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+			return &ev, nil
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn:    func(string, ...string) bool { return true },
+		BreachedFn:    func(string) (bool, error) { return false, nil },
+		HashFn:        func(string) (string, error) { return "h4$h3d", nil },
+		RandomTokenFn: func(prefix string) (string, error) { return prefix + "token", nil },
+		TokenHashFn:   func(string) string { return "hashedtoken" },
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := server.New()
+			rg := r.Group("")
+			transport.NewHTTP(user.New(mockdb.DB{}, udb, nil, nil, sec, mail.NewMemory("noreply@mail.com"), tt.cfg), r, rg, mock.RequirePermission, pager)
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+			res, err := http.Post(ts.URL+"/signup", "application/json", bytes.NewBufferString(tt.req))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if tt.wantResp != nil {
+				response := new(gorsk.Company)
+				if err := json.NewDecoder(res.Body).Decode(response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tt.wantResp, response)
+			}
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package transport
 
 import (
+	"github.com/figassis/goduck/pkg/api/user"
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/paging"
 )
 
 // User model response
@@ -16,9 +18,25 @@ type swaggUserResponse struct {
 // Users model response
 // swagger:response userListResp
 type swaggUserListResponse struct {
+	// Links to the first, next and previous pages, as described in RFC 8288
+	Link string
 	// in:body
 	Body struct {
 		Users []gorsk.User `json:"users"`
 		Page  int          `json:"page"`
+		paging.Meta
+	}
+}
+
+// Users import response
+// swagger:response userImportResp
+type swaggUserImportResponse struct {
+	// in:body
+	Body struct {
+		DryRun  bool                `json:"dry_run"`
+		Valid   int                 `json:"valid"`
+		Created int                 `json:"created"`
+		Failed  int                 `json:"failed"`
+		Rows    []user.ImportResult `json:"rows"`
 	}
 }
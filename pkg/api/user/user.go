@@ -2,48 +2,114 @@
 package user
 
 import (
-	"github.com/labstack/echo"
+	"context"
+	"net/http"
+
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
 	"github.com/figassis/goduck/pkg/utl/query"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/labstack/echo"
 )
 
-// Create creates a new user account
-func (u *User) Create(c echo.Context, req gorsk.User) (*gorsk.User, error) {
-	if err := u.rbac.AccountCreate(c, req.RoleID, req.CompanyID, req.LocationID); err != nil {
+// Custom errors
+var (
+	ErrUnknownRole      = echo.NewHTTPError(http.StatusBadRequest, "role does not exist")
+	ErrInsecurePassword = echo.NewHTTPError(http.StatusBadRequest, "insecure password")
+	ErrBreachedPassword = echo.NewHTTPError(http.StatusBadRequest, "password found in breach")
+)
+
+// Create creates a new user account, and emails a verification token to user's email
+func (u *User) Create(ctx context.Context, req gorsk.User) (*gorsk.User, error) {
+	db := u.db.WithContext(ctx)
+	usr, err := u.create(ctx, db, req)
+	if err != nil {
 		return nil, err
 	}
-	req.Password = u.sec.Hash(req.Password)
-	return u.udb.Create(u.db, req)
+	u.verifyCreated(db, usr)
+	return usr, nil
 }
 
-// List returns list of users
-func (u *User) List(c echo.Context, p *gorsk.Pagination) ([]gorsk.User, error) {
-	au := u.rbac.User(c)
-	q, err := query.List(au)
+func (u *User) create(ctx context.Context, db orm.DB, req gorsk.User) (*gorsk.User, error) {
+	role, err := u.udb.ViewRole(db, req.RoleID)
+	if err == pg.ErrNoRows {
+		return nil, ErrUnknownRole
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.rbac.AccountCreate(ctx, role, req.CompanyID, req.LocationID); err != nil {
+		return nil, err
+	}
+	return u.insert(db, req)
+}
+
+// insert checks and hashes user's password, and creates the user
+func (u *User) insert(db orm.DB, req gorsk.User) (*gorsk.User, error) {
+	if !u.sec.Password(req.Password, req.FirstName, req.LastName, req.Username, req.Email) {
+		return nil, ErrInsecurePassword
+	}
+	breached, err := u.sec.Breached(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		return nil, ErrBreachedPassword
+	}
+	hash, err := u.sec.Hash(req.Password)
 	if err != nil {
 		return nil, err
 	}
-	return u.udb.List(u.db, q, p)
+	req.Password = hash
+	return u.udb.Create(db, req)
+}
+
+// verifyCreated emails a verification token to email of created user.
+// The account exists already, so failures are ignored and the verification can be resent.
+func (u *User) verifyCreated(db orm.DB, usr *gorsk.User) {
+	if usr.Email != "" {
+		_ = u.sendVerification(db, usr.ID, usr.Email, "Verify your email")
+	}
+}
+
+// List returns page of users matching the filter, limited to those the user may access
+func (u *User) List(ctx context.Context, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
+	db := u.db.WithContext(ctx)
+	if err := u.rbac.Enforce(ctx, gorsk.PermUsersRead); err != nil {
+		return nil, nil, err
+	}
+	au := u.rbac.User(ctx)
+	q, err := query.List(au)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u.udb.List(db, q, f, p)
 }
 
 // View returns single user
-func (u *User) View(c echo.Context, id int) (*gorsk.User, error) {
-	if err := u.rbac.EnforceUser(c, id); err != nil {
+func (u *User) View(ctx context.Context, id int) (*gorsk.User, error) {
+	db := u.db.WithContext(ctx)
+	if err := u.rbac.EnforceUser(ctx, gorsk.PermUsersRead, id); err != nil {
 		return nil, err
 	}
-	return u.udb.View(u.db, id)
+	return u.udb.View(db, id)
 }
 
 // Delete deletes a user
-func (u *User) Delete(c echo.Context, id int) error {
-	user, err := u.udb.View(u.db, id)
+func (u *User) Delete(ctx context.Context, id int) error {
+	db := u.db.WithContext(ctx)
+	if err := u.rbac.EnforceUser(ctx, gorsk.PermUsersDelete, id); err != nil {
+		return err
+	}
+	user, err := u.udb.View(db, id)
 	if err != nil {
 		return err
 	}
-	if err := u.rbac.IsLowerRole(c, user.Role.AccessLevel); err != nil {
+	if err := u.rbac.IsLowerRole(ctx, user.Role.AccessLevel); err != nil {
 		return err
 	}
-	return u.udb.Delete(u.db, user)
+	return u.udb.Delete(db, user)
 }
 
 // Update contains user's information used for updating
@@ -57,12 +123,13 @@ type Update struct {
 }
 
 // Update updates user's contact information
-func (u *User) Update(c echo.Context, r *Update) (*gorsk.User, error) {
-	if err := u.rbac.EnforceUser(c, r.ID); err != nil {
+func (u *User) Update(ctx context.Context, r *Update) (*gorsk.User, error) {
+	db := u.db.WithContext(ctx)
+	if err := u.rbac.EnforceUser(ctx, gorsk.PermUsersUpdate, r.ID); err != nil {
 		return nil, err
 	}
 
-	if err := u.udb.Update(u.db, &gorsk.User{
+	if err := u.udb.Update(db, &gorsk.User{
 		Base:      gorsk.Base{ID: r.ID},
 		FirstName: r.FirstName,
 		LastName:  r.LastName,
@@ -72,5 +139,5 @@ func (u *User) Update(c echo.Context, r *Update) (*gorsk.User, error) {
 		return nil, err
 	}
 
-	return u.udb.View(u.db, r.ID)
+	return u.udb.View(db, r.ID)
 }
@@ -1,13 +1,19 @@
 package user_test
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/figassis/goduck/pkg/api/user"
+	"github.com/figassis/goduck/pkg/utl/mail"
 	"github.com/figassis/goduck/pkg/utl/mock"
 	"github.com/figassis/goduck/pkg/utl/mock/mockdb"
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/throttle"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/labstack/echo"
 
@@ -16,7 +22,6 @@ import (
 
 func TestCreate(t *testing.T) {
 	type args struct {
-		c   echo.Context
 		req gorsk.User
 	}
 	cases := []struct {
@@ -24,15 +29,17 @@ func TestCreate(t *testing.T) {
 		args     args
 		wantErr  bool
 		wantData *gorsk.User
+		wantMail bool
 		udb      *mockdb.User
 		rbac     *mock.RBAC
 		sec      *mock.Secure
 	}{{
-		name: "Fail on is lower role",
-		rbac: &mock.RBAC{
-			AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
-				return gorsk.ErrGeneric
-			}},
+		name: "Fail on unknown role",
+		udb: &mockdb.User{
+			ViewRoleFn: func(orm.DB, gorsk.AccessRole) (*gorsk.Role, error) {
+				return nil, pg.ErrNoRows
+			},
+		},
 		wantErr: true,
 		args: args{req: gorsk.User{
 			FirstName: "John",
@@ -42,12 +49,117 @@ func TestCreate(t *testing.T) {
 			Password:  "Thranduil8822",
 		}},
 	},
+		{
+			name: "Fail on is lower role",
+			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
+					return gorsk.ErrGeneric
+				}},
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "Thranduil8822",
+			}},
+		},
+		{
+			name: "Fail on insecure password",
+			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(pass string, inputs ...string) bool {
+					return !strings.Contains(pass, inputs[2])
+				},
+			},
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "JohnDoe1234",
+			}},
+		},
+		{
+			name: "Fail on breached password",
+			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) bool {
+					return true
+				},
+				BreachedFn: func(pass string) (bool, error) {
+					return pass == "Thranduil8822", nil
+				},
+			},
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "Thranduil8822",
+			}},
+		},
+		{
+			name: "Fail on hashing password",
+			udb: &mockdb.User{
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole}, nil
+				},
+			},
+			rbac: &mock.RBAC{
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error {
+					return nil
+				}},
+			sec: &mock.Secure{
+				PasswordFn: func(string, ...string) bool {
+					return true
+				},
+				BreachedFn: func(string) (bool, error) {
+					return false, nil
+				},
+				HashFn: func(string) (string, error) {
+					return "", gorsk.ErrGeneric
+				},
+			},
+			wantErr: true,
+			args: args{req: gorsk.User{
+				FirstName: "John",
+				LastName:  "Doe",
+				Username:  "JohnDoe",
+				RoleID:    1,
+				Password:  "Thranduil8822",
+			}},
+		},
 		{
 			name: "Success",
 			args: args{req: gorsk.User{
 				FirstName: "John",
 				LastName:  "Doe",
 				Username:  "JohnDoe",
+				Email:     "johndoe@mail.com",
 				RoleID:    1,
 				Password:  "Thranduil8822",
 			}},
@@ -58,16 +170,41 @@ func TestCreate(t *testing.T) {
 					u.Base.ID = 1
 					return &u, nil
 				},
+				ViewRoleFn: func(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+					return &gorsk.Role{ID: id, AccessLevel: gorsk.UserRole, CompanyID: 3}, nil
+				},
+				CreateEmailVerificationFn: func(db orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+					if ev.UserID != 1 || ev.Email != "johndoe@mail.com" || ev.TokenHash != "hashedtoken" {
+						return nil, gorsk.ErrGeneric
+					}
+					return &ev, nil
+				},
 			},
 			rbac: &mock.RBAC{
-				AccountCreateFn: func(echo.Context, gorsk.AccessRole, int, int) error {
+				AccountCreateFn: func(_ context.Context, r *gorsk.Role, _, _ int) error {
+					if r.CompanyID != 3 {
+						return gorsk.ErrGeneric
+					}
 					return nil
 				}},
 			sec: &mock.Secure{
-				HashFn: func(string) string {
-					return "h4$h3d"
+				PasswordFn: func(string, ...string) bool {
+					return true
+				},
+				BreachedFn: func(string) (bool, error) {
+					return false, nil
+				},
+				HashFn: func(string) (string, error) {
+					return "h4$h3d", nil
+				},
+				RandomTokenFn: func(prefix string) (string, error) {
+					return prefix + "token", nil
+				},
+				TokenHashFn: func(string) string {
+					return "hashedtoken"
 				},
 			},
+			wantMail: true,
 			wantData: &gorsk.User{
 				Base: gorsk.Base{
 					ID:        1,
@@ -77,22 +214,29 @@ func TestCreate(t *testing.T) {
 				FirstName: "John",
 				LastName:  "Doe",
 				Username:  "JohnDoe",
+				Email:     "johndoe@mail.com",
 				RoleID:    1,
 				Password:  "h4$h3d",
 			}}}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, tt.sec)
-			usr, err := s.Create(tt.args.c, tt.args.req)
+			mailer := mail.NewMemory("noreply@mail.com")
+			s := user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, tt.sec, mailer, nil)
+			usr, err := s.Create(context.Background(), tt.args.req)
 			assert.Equal(t, tt.wantErr, err != nil)
 			assert.Equal(t, tt.wantData, usr)
+			msgs := mailer.Messages()
+			assert.Equal(t, tt.wantMail, len(msgs) == 1)
+			if tt.wantMail {
+				assert.Equal(t, "johndoe@mail.com", msgs[0].To)
+				assert.Contains(t, msgs[0].Body, "ev_token")
+			}
 		})
 	}
 }
 
 func TestView(t *testing.T) {
 	type args struct {
-		c  echo.Context
 		id int
 	}
 	cases := []struct {
@@ -107,7 +251,7 @@ func TestView(t *testing.T) {
 			name: "Fail on RBAC",
 			args: args{id: 5},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -126,7 +270,7 @@ func TestView(t *testing.T) {
 				Username:  "JohnDoe",
 			},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			udb: &mockdb.User{
@@ -149,8 +293,8 @@ func TestView(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
-			usr, err := s.View(tt.args.c, tt.args.id)
+			s := user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, nil, nil, nil)
+			usr, err := s.View(context.Background(), tt.args.id)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
 		})
@@ -159,26 +303,28 @@ func TestView(t *testing.T) {
 
 func TestList(t *testing.T) {
 	type args struct {
-		c   echo.Context
-		pgn *gorsk.Pagination
+		filter *gorsk.UserFilter
+		pgn    *gorsk.Pagination
 	}
 	cases := []struct {
 		name     string
 		args     args
 		wantData []gorsk.User
+		wantPage *gorsk.Page
 		wantErr  bool
 		udb      *mockdb.User
 		rbac     *mock.RBAC
 	}{
 		{
 			name: "Fail on query List",
-			args: args{c: nil, pgn: &gorsk.Pagination{
+			args: args{pgn: &gorsk.Pagination{
 				Limit:  100,
 				Offset: 200,
 			}},
 			wantErr: true,
 			rbac: &mock.RBAC{
-				UserFn: func(c echo.Context) *gorsk.AuthUser {
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{
 						ID:         1,
 						CompanyID:  2,
@@ -186,14 +332,41 @@ func TestList(t *testing.T) {
 						Role:       gorsk.UserRole,
 					}
 				}}},
+		{
+			name: "Filter keeps company admin scope",
+			args: args{
+				filter: &gorsk.UserFilter{CompanyID: 5, Search: "doe"},
+				pgn:    &gorsk.Pagination{Limit: 100},
+			},
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{
+						ID:         1,
+						CompanyID:  2,
+						LocationID: 3,
+						Role:       gorsk.CompanyAdminRole,
+					}
+				}},
+			udb: &mockdb.User{
+				ListFn: func(_ orm.DB, q *gorsk.ListQuery, f *gorsk.UserFilter, _ *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
+					if *q != (gorsk.ListQuery{Query: `"user".company_id = ?`, ID: 2}) || f.CompanyID != 5 || f.Search != "doe" {
+						return nil, nil, gorsk.ErrGeneric
+					}
+					return []gorsk.User{}, &gorsk.Page{}, nil
+				}},
+			wantData: []gorsk.User{},
+			wantPage: &gorsk.Page{},
+		},
 		{
 			name: "Success",
-			args: args{c: nil, pgn: &gorsk.Pagination{
+			args: args{pgn: &gorsk.Pagination{
 				Limit:  100,
 				Offset: 200,
 			}},
 			rbac: &mock.RBAC{
-				UserFn: func(c echo.Context) *gorsk.AuthUser {
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(c context.Context) *gorsk.AuthUser {
 					return &gorsk.AuthUser{
 						ID:         1,
 						CompanyID:  2,
@@ -202,7 +375,7 @@ func TestList(t *testing.T) {
 					}
 				}},
 			udb: &mockdb.User{
-				ListFn: func(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.User, error) {
+				ListFn: func(orm.DB, *gorsk.ListQuery, *gorsk.UserFilter, *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
 					return []gorsk.User{
 						{
 							Base: gorsk.Base{
@@ -226,7 +399,7 @@ func TestList(t *testing.T) {
 							Email:     "logan@aol.com",
 							Username:  "hunterlogan",
 						},
-					}, nil
+					}, &gorsk.Page{Total: 2}, nil
 				}},
 			wantData: []gorsk.User{
 				{
@@ -251,13 +424,15 @@ func TestList(t *testing.T) {
 					Email:     "logan@aol.com",
 					Username:  "hunterlogan",
 				}},
+			wantPage: &gorsk.Page{Total: 2},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
-			usrs, err := s.List(tt.args.c, tt.args.pgn)
+			s := user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, nil, nil, nil)
+			usrs, page, err := s.List(context.Background(), tt.args.filter, tt.args.pgn)
 			assert.Equal(t, tt.wantData, usrs)
+			assert.Equal(t, tt.wantPage, page)
 			assert.Equal(t, tt.wantErr, err != nil)
 		})
 	}
@@ -266,7 +441,6 @@ func TestList(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	type args struct {
-		c  echo.Context
 		id int
 	}
 	cases := []struct {
@@ -276,10 +450,25 @@ func TestDelete(t *testing.T) {
 		udb     *mockdb.User
 		rbac    *mock.RBAC
 	}{
+		{
+			name:    "Fail on EnforceUser",
+			args:    args{id: 1},
+			wantErr: echo.ErrForbidden,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(_ context.Context, p gorsk.Permission, _ int) error {
+					if p != gorsk.PermUsersDelete {
+						return gorsk.ErrGeneric
+					}
+					return echo.ErrForbidden
+				}},
+		},
 		{
 			name:    "Fail on ViewUser",
 			args:    args{id: 1},
 			wantErr: gorsk.ErrGeneric,
+			rbac: &mock.RBAC{
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error { return nil },
+			},
 			udb: &mockdb.User{
 				ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
 					if id != 1 {
@@ -290,7 +479,7 @@ func TestDelete(t *testing.T) {
 			},
 		},
 		{
-			name: "Fail on RBAC",
+			name: "Fail on IsLowerRole",
 			args: args{id: 1},
 			udb: &mockdb.User{
 				ViewFn: func(db orm.DB, id int) (*gorsk.User, error) {
@@ -309,7 +498,8 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error { return nil },
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -339,15 +529,16 @@ func TestDelete(t *testing.T) {
 				},
 			},
 			rbac: &mock.RBAC{
-				IsLowerRoleFn: func(echo.Context, gorsk.AccessRole) error {
+				EnforceUserFn: func(context.Context, gorsk.Permission, int) error { return nil },
+				IsLowerRoleFn: func(context.Context, gorsk.AccessRole) error {
 					return nil
 				}},
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
-			err := s.Delete(tt.args.c, tt.args.id)
+			s := user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, nil, nil, nil)
+			err := s.Delete(context.Background(), tt.args.id)
 			if err != tt.wantErr {
 				t.Errorf("Expected error %v, received %v", tt.wantErr, err)
 			}
@@ -357,7 +548,6 @@ func TestDelete(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	type args struct {
-		c   echo.Context
 		upd *user.Update
 	}
 	cases := []struct {
@@ -374,7 +564,7 @@ func TestUpdate(t *testing.T) {
 				ID: 1,
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return gorsk.ErrGeneric
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -385,7 +575,7 @@ func TestUpdate(t *testing.T) {
 				ID: 1,
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			wantErr: gorsk.ErrGeneric,
@@ -423,7 +613,7 @@ func TestUpdate(t *testing.T) {
 				Phone:     "234567",
 			}},
 			rbac: &mock.RBAC{
-				EnforceUserFn: func(c echo.Context, id int) error {
+				EnforceUserFn: func(c context.Context, _ gorsk.Permission, id int) error {
 					return nil
 				}},
 			wantData: &gorsk.User{
@@ -470,8 +660,8 @@ func TestUpdate(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := user.New(nil, tt.udb, tt.rbac, nil)
-			usr, err := s.Update(tt.args.c, tt.args.upd)
+			s := user.New(mockdb.DB{}, tt.udb, nil, tt.rbac, nil, nil, nil)
+			usr, err := s.Update(context.Background(), tt.args.upd)
 			assert.Equal(t, tt.wantData, usr)
 			assert.Equal(t, tt.wantErr, err)
 		})
@@ -479,8 +669,531 @@ func TestUpdate(t *testing.T) {
 }
 
 func TestInitialize(t *testing.T) {
-	u := user.Initialize(nil, nil, nil)
+	u := user.Initialize(nil, nil, nil, nil, nil)
 	if u == nil {
 		t.Error("User service not initialized")
 	}
 }
+
+func TestImport(t *testing.T) {
+	allow := &mock.RBAC{
+		EnforceFn:       func(context.Context, gorsk.Permission) error { return nil },
+		AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error { return nil },
+	}
+	udb := func() *mockdb.User {
+		id := 10
+		return &mockdb.User{
+			ViewRoleFn: func(_ orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+				if id == 50 {
+					return nil, pg.ErrNoRows
+				}
+				return &gorsk.Role{ID: id, AccessLevel: id}, nil
+			},
+			CreateFn: func(_ orm.DB, u gorsk.User) (*gorsk.User, error) {
+				if u.Password != "h4sh3d" {
+					return nil, gorsk.ErrGeneric
+				}
+				if u.Username == "broken" {
+					return nil, gorsk.ErrGeneric
+				}
+				id++
+				u.ID = id
+				return &u, nil
+			},
+		}
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(string, ...string) bool { return true },
+		BreachedFn: func(string) (bool, error) { return false, nil },
+		HashFn:     func(string) (string, error) { return "h4sh3d", nil },
+	}
+	rows := []user.ImportRow{
+		{User: gorsk.User{Username: "johndoe", Password: "hunter123", RoleID: gorsk.UserRole}},
+		{Err: gorsk.ErrBadRequest},
+		{User: gorsk.User{Username: "janedoe", Password: "hunter123", RoleID: 50}},
+		{User: gorsk.User{Username: "joedoe", Password: "hunter123", RoleID: gorsk.UserRole}},
+	}
+	failed := []user.ImportResult{
+		{Row: 2, Error: "Bad Request"},
+		{Row: 3, Error: "role does not exist"},
+	}
+	cases := []struct {
+		name     string
+		rows     []user.ImportRow
+		opt      user.ImportOptions
+		rbac     *mock.RBAC
+		wantData []user.ImportResult
+		wantErr  error
+	}{
+		{
+			name: "Fail on RBAC",
+			rows: rows,
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return echo.ErrForbidden },
+			},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name:     "Atomic import with invalid rows",
+			rows:     rows,
+			rbac:     allow,
+			wantData: []user.ImportResult{{Row: 1}, failed[0], failed[1], {Row: 4}},
+		},
+		{
+			name:     "Best effort import",
+			rows:     rows,
+			opt:      user.ImportOptions{BestEffort: true},
+			rbac:     allow,
+			wantData: []user.ImportResult{{Row: 1, ID: 11}, failed[0], failed[1], {Row: 4, ID: 12}},
+		},
+		{
+			name:     "Dry run",
+			rows:     []user.ImportRow{rows[0], rows[3]},
+			opt:      user.ImportOptions{DryRun: true, BestEffort: true},
+			rbac:     allow,
+			wantData: []user.ImportResult{{Row: 1}, {Row: 2}},
+		},
+		{
+			name: "Forbidden row",
+			rows: []user.ImportRow{rows[0]},
+			rbac: &mock.RBAC{
+				EnforceFn:       func(context.Context, gorsk.Permission) error { return nil },
+				AccountCreateFn: func(context.Context, *gorsk.Role, int, int) error { return echo.ErrForbidden },
+			},
+			wantData: []user.ImportResult{{Row: 1, Error: "Forbidden"}},
+		},
+		{
+			name:    "Fail on database error",
+			rows:    []user.ImportRow{rows[0], {User: gorsk.User{Username: "broken", RoleID: gorsk.UserRole}}},
+			opt:     user.ImportOptions{BestEffort: true},
+			rbac:    allow,
+			wantErr: gorsk.ErrGeneric,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := user.New(mockdb.DB{}, udb(), nil, tt.rbac, sec, nil, nil)
+			res, err := s.Import(context.Background(), tt.rows, tt.opt)
+			assert.Equal(t, tt.wantData, res)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	cases := []struct {
+		name    string
+		rbac    *mock.RBAC
+		wantErr error
+		wantIDs []int
+	}{
+		{
+			name: "Fail on query List",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn:    func(context.Context) *gorsk.AuthUser { return &gorsk.AuthUser{Role: gorsk.UserRole} },
+			},
+			wantErr: echo.ErrForbidden,
+		},
+		{
+			name: "Success",
+			rbac: &mock.RBAC{
+				EnforceFn: func(context.Context, gorsk.Permission) error { return nil },
+				UserFn: func(context.Context) *gorsk.AuthUser {
+					return &gorsk.AuthUser{Role: gorsk.LocationAdminRole, LocationID: 3}
+				},
+			},
+			wantIDs: []int{1, 2},
+		},
+	}
+	udb := &mockdb.User{
+		ExportFn: func(_ orm.DB, q *gorsk.ListQuery, f *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+			if *q != (gorsk.ListQuery{Query: `"user".location_id = ?`, ID: 3}) || f.Search != "doe" {
+				return gorsk.ErrGeneric
+			}
+			for _, id := range []int{1, 2} {
+				if err := fn(&gorsk.User{Base: gorsk.Base{ID: id}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []int
+			err := user.New(mockdb.DB{}, udb, nil, tt.rbac, nil, nil, nil).Export(context.Background(), &gorsk.UserFilter{Search: "doe"}, func(u *gorsk.User) error {
+				ids = append(ids, u.ID)
+				return nil
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	valid := func(orm.DB, string) (*gorsk.EmailVerification, error) {
+		return &gorsk.EmailVerification{UserID: 1, Email: "janedoe@mail.com", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}
+	view := func(_ orm.DB, id int) (*gorsk.User, error) {
+		return &gorsk.User{Base: gorsk.Base{ID: id}, Email: "johndoe@mail.com"}, nil
+	}
+	cases := []struct {
+		name      string
+		udb       *mockdb.User
+		wantErr   error
+		wantEmail string
+	}{
+		{
+			name: "Unknown token",
+			udb: &mockdb.User{
+				FindEmailVerificationFn: func(orm.DB, string) (*gorsk.EmailVerification, error) {
+					return nil, pg.ErrNoRows
+				},
+			},
+			wantErr: user.ErrInvalidVerificationToken,
+		},
+		{
+			name: "Expired token",
+			udb: &mockdb.User{
+				FindEmailVerificationFn: func(orm.DB, string) (*gorsk.EmailVerification, error) {
+					return &gorsk.EmailVerification{UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)}, nil
+				},
+			},
+			wantErr: user.ErrInvalidVerificationToken,
+		},
+		{
+			name: "Email taken meanwhile",
+			udb: &mockdb.User{
+				FindEmailVerificationFn: valid,
+				ViewFn:                  view,
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: 2}}, nil
+				},
+			},
+			wantErr: user.ErrEmailTaken,
+		},
+		{
+			name: "Token already used",
+			udb: &mockdb.User{
+				FindEmailVerificationFn: valid,
+				ViewFn:                  view,
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return nil, pg.ErrNoRows
+				},
+				UseEmailVerificationFn: func(orm.DB, *gorsk.EmailVerification) error {
+					return pg.ErrNoRows
+				},
+			},
+			wantErr: user.ErrInvalidVerificationToken,
+		},
+		{
+			name: "Success",
+			udb: &mockdb.User{
+				FindEmailVerificationFn: valid,
+				ViewFn:                  view,
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					return nil, pg.ErrNoRows
+				},
+				UseEmailVerificationFn: func(orm.DB, *gorsk.EmailVerification) error {
+					return nil
+				},
+			},
+			wantEmail: "janedoe@mail.com",
+		},
+	}
+	sec := &mock.Secure{
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *gorsk.User
+			tt.udb.UpdateEmailFn = func(_ orm.DB, u *gorsk.User) error {
+				updated = u
+				return nil
+			}
+			err := user.New(mockdb.DB{}, tt.udb, nil, nil, sec, nil, nil).VerifyEmail(context.Background(), "ev_token")
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantEmail != "" {
+				assert.Equal(t, tt.wantEmail, updated.Email)
+				assert.True(t, updated.EmailVerified())
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	cases := []struct {
+		name     string
+		email    string
+		usr      *gorsk.User
+		wantSent int
+	}{
+		{
+			name:  "Unknown email",
+			email: "unknown@mail.com",
+		},
+		{
+			name:  "Already verified",
+			email: "johndoe@mail.com",
+			usr:   &gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true, EmailVerifiedAt: mock.TestTime(2018)},
+		},
+		{
+			name:     "Resent once per interval",
+			email:    "johndoe@mail.com",
+			usr:      &gorsk.User{Base: gorsk.Base{ID: 1}, Email: "johndoe@mail.com", Active: true},
+			wantSent: 1,
+		},
+	}
+	sec := &mock.Secure{
+		RandomTokenFn: func(prefix string) (string, error) {
+			return prefix + "token", nil
+		},
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			udb := &mockdb.User{
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					if tt.usr == nil {
+						return nil, pg.ErrNoRows
+					}
+					return tt.usr, nil
+				},
+				CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+					return &ev, nil
+				},
+			}
+			mailer := mail.NewMemory("noreply@mail.com")
+			s := user.New(mockdb.DB{}, udb, throttle.NewMemory(), nil, sec, mailer, nil)
+			assert.Nil(t, s.ResendVerification(context.Background(), tt.email))
+			assert.Equal(t, user.ErrVerificationThrottled, s.ResendVerification(context.Background(), strings.ToUpper(tt.email)))
+			assert.Equal(t, tt.wantSent, len(mailer.Messages()))
+		})
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	cases := []struct {
+		name      string
+		password  string
+		email     string
+		other     *gorsk.User
+		throttled bool
+		wantErr   error
+	}{
+		{
+			name:      "Throttled",
+			password:  "hunter123",
+			email:     "janedoe@mail.com",
+			throttled: true,
+			wantErr:   user.ErrVerificationThrottled,
+		},
+		{
+			name:     "Incorrect password",
+			password: "wrong",
+			email:    "janedoe@mail.com",
+			wantErr:  user.ErrIncorrectPassword,
+		},
+		{
+			name:     "Email unchanged",
+			password: "hunter123",
+			email:    "JohnDoe@mail.com",
+			wantErr:  user.ErrEmailUnchanged,
+		},
+		{
+			name:     "Email taken",
+			password: "hunter123",
+			email:    "janedoe@mail.com",
+			other:    &gorsk.User{Base: gorsk.Base{ID: 2}},
+		},
+		{
+			name:     "Success",
+			password: "hunter123",
+			email:    "janedoe@mail.com",
+		},
+	}
+	rbac := &mock.RBAC{
+		UserFn: func(context.Context) *gorsk.AuthUser {
+			return &gorsk.AuthUser{ID: 1}
+		},
+	}
+	sec := &mock.Secure{
+		HashMatchesPasswordFn: func(hash, pass string) bool {
+			return pass == "hunter123"
+		},
+		RandomTokenFn: func(prefix string) (string, error) {
+			return prefix + "token", nil
+		},
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *gorsk.EmailVerification
+			udb := &mockdb.User{
+				ViewFn: func(_ orm.DB, id int) (*gorsk.User, error) {
+					return &gorsk.User{Base: gorsk.Base{ID: id}, Email: "johndoe@mail.com", Password: "h4sh3d"}, nil
+				},
+				FindByEmailFn: func(orm.DB, string) (*gorsk.User, error) {
+					if tt.other == nil {
+						return nil, pg.ErrNoRows
+					}
+					return tt.other, nil
+				},
+				CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+					created = &ev
+					return &ev, nil
+				},
+			}
+			mailer := mail.NewMemory("noreply@mail.com")
+			lim := throttle.NewMemory()
+			if tt.throttled {
+				if err := lim.Lock(context.Background(), "email-change:1", time.Now().Add(time.Minute)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := user.New(mockdb.DB{}, udb, lim, rbac, sec, mailer, &user.Config{VerifyURL: "https://example.com/verify"})
+			err := s.ChangeEmail(context.Background(), tt.password, tt.email)
+			assert.Equal(t, tt.wantErr, err)
+			msgs := mailer.Messages()
+			if tt.wantErr != nil {
+				assert.Nil(t, created)
+				assert.Empty(t, msgs)
+				return
+			}
+			assert.Equal(t, user.ErrVerificationThrottled, s.ChangeEmail(context.Background(), tt.password, tt.email), "repeated change should be throttled")
+			if tt.other != nil {
+				assert.Nil(t, created)
+				if assert.Len(t, msgs, 1) {
+					assert.Equal(t, "johndoe@mail.com", msgs[0].To)
+				}
+				return
+			}
+			assert.Equal(t, 1, created.UserID)
+			assert.Equal(t, "janedoe@mail.com", created.Email)
+			if assert.Len(t, msgs, 2) {
+				assert.Equal(t, "janedoe@mail.com", msgs[0].To)
+				assert.Contains(t, msgs[0].Body, "https://example.com/verify?token=ev_token")
+				assert.Equal(t, "johndoe@mail.com", msgs[1].To)
+				assert.NotContains(t, msgs[1].Body, "ev_token")
+			}
+		})
+	}
+}
+
+func TestSignup(t *testing.T) {
+	req := gorsk.User{FirstName: "John", LastName: "Doe", Username: "johndoe", Email: "johndoe@mail.com", Password: "Thranduil8822"}
+	udb := func(createErr error) *mockdb.User {
+		return &mockdb.User{
+			CreateCompanyFn: func(_ orm.DB, c gorsk.Company) (*gorsk.Company, error) {
+				if createErr != nil {
+					return nil, createErr
+				}
+				c.ID = 3
+				return &c, nil
+			},
+			CreateLocationFn: func(_ orm.DB, l gorsk.Location) (*gorsk.Location, error) {
+				l.ID = 4
+				return &l, nil
+			},
+			CreateFn: func(_ orm.DB, u gorsk.User) (*gorsk.User, error) {
+				u.ID = 5
+				return &u, nil
+			},
+			UpdateCompanyOwnerFn: func(_ orm.DB, c *gorsk.Company) error {
+				if c.OwnerID != 5 {
+					return gorsk.ErrGeneric
+				}
+				return nil
+			},
+			CreateEmailVerificationFn: func(_ orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+				return &ev, nil
+			},
+		}
+	}
+	cases := []struct {
+		name     string
+		cfg      *user.Config
+		udb      *mockdb.User
+		password string
+		wantErr  error
+		wantData *gorsk.Company
+	}{
+		{
+			name:    "Signup disabled",
+			cfg:     &user.Config{DisableSignup: true},
+			wantErr: user.ErrSignupDisabled,
+		},
+		{
+			name:    "Fail on company create",
+			udb:     udb(gorsk.ErrGeneric),
+			wantErr: gorsk.ErrGeneric,
+		},
+		{
+			name:     "Fail on insecure password",
+			udb:      udb(nil),
+			password: "johndoe",
+			wantErr:  user.ErrInsecurePassword,
+		},
+		{
+			name: "Success",
+			udb:  udb(nil),
+			wantData: &gorsk.Company{
+				Base:      gorsk.Base{ID: 3},
+				Name:      "Acme",
+				Active:    true,
+				Locations: []gorsk.Location{{Base: gorsk.Base{ID: 4}, Name: user.SignupLocation, Active: true, CompanyID: 3}},
+				OwnerID:   5,
+				Owner: gorsk.User{
+					Base:       gorsk.Base{ID: 5},
+					FirstName:  "John",
+					LastName:   "Doe",
+					Username:   "johndoe",
+					Email:      "johndoe@mail.com",
+					Password:   "h4$h3d",
+					Active:     true,
+					RoleID:     gorsk.CompanyAdminRole,
+					CompanyID:  3,
+					LocationID: 4,
+				},
+			},
+		},
+	}
+	sec := &mock.Secure{
+		PasswordFn: func(pass string, _ ...string) bool {
+			return pass != "johndoe"
+		},
+		BreachedFn: func(string) (bool, error) {
+			return false, nil
+		},
+		HashFn: func(string) (string, error) {
+			return "h4$h3d", nil
+		},
+		RandomTokenFn: func(prefix string) (string, error) {
+			return prefix + "token", nil
+		},
+		TokenHashFn: func(string) string {
+			return "hashedtoken"
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := req
+			if tt.password != "" {
+				r.Password = tt.password
+			}
+			mailer := mail.NewMemory("noreply@mail.com")
+			cmp, err := user.New(mockdb.DB{}, tt.udb, nil, nil, sec, mailer, tt.cfg).Signup(context.Background(), "Acme", r)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantData, cmp)
+			assert.Equal(t, tt.wantData != nil, len(mailer.Messages()) == 1)
+		})
+	}
+}
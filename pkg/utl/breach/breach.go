@@ -0,0 +1,157 @@
+// Package breach screens passwords against a local dataset of SHA-1 hashes of breached passwords,
+// such as the Have I Been Pwned dump ordered by hash, without making any network requests
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// magic identifies breached hashes files, followed by sorted 20 bytes SHA-1 hashes
+const magic = "GDBREACH1\n"
+
+// Custom errors
+var (
+	ErrInvalidFile = errors.New("breach: not a breached hashes file")
+	ErrUnsorted    = errors.New("breach: hashes are not sorted, use the dump ordered by hash")
+)
+
+// Build writes breached hashes file from dump read from r. Each line of the dump holds a hex encoded SHA-1
+// hash, optionally followed by a colon and the number of times it was seen, as in HIBP dumps.
+// Hashes seen less than minCount times are skipped. Dump has to be ordered by hash, so it is never loaded into memory.
+// Returns number of hashes written.
+func Build(w io.Writer, r io.Reader, minCount int) (int, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(magic); err != nil {
+		return 0, err
+	}
+
+	var n, line int
+	var prev []byte
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		hash, count, err := parseLine(text)
+		if err != nil {
+			return n, fmt.Errorf("breach: line %d: %v", line, err)
+		}
+		if count < minCount {
+			continue
+		}
+		switch cmp := bytes.Compare(hash, prev); {
+		case prev != nil && cmp == 0:
+			continue
+		case prev != nil && cmp < 0:
+			return n, fmt.Errorf("%w (line %d)", ErrUnsorted, line)
+		}
+		if _, err := bw.Write(hash); err != nil {
+			return n, err
+		}
+		prev = hash
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+func parseLine(text string) ([]byte, int, error) {
+	count := 1
+	if i := strings.IndexByte(text, ':'); i >= 0 {
+		c, err := strconv.Atoi(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid count %q", text[i+1:])
+		}
+		count = c
+		text = text[:i]
+	}
+	if len(text) != hex.EncodedLen(sha1.Size) {
+		return nil, 0, fmt.Errorf("invalid SHA-1 hash %q", text)
+	}
+	hash, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid SHA-1 hash %q", text)
+	}
+	return hash, count, nil
+}
+
+// Open opens breached hashes file created by Build. Hashes are looked up on disk, so files of any size can be used.
+func Open(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := newList(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func newList(f *os.File) (*List, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, len(magic))
+	if _, err := f.ReadAt(head, 0); err != nil || string(head) != magic {
+		return nil, ErrInvalidFile
+	}
+	size := fi.Size() - int64(len(magic))
+	if size%sha1.Size != 0 {
+		return nil, ErrInvalidFile
+	}
+	return &List{f: f, n: size / sha1.Size}, nil
+}
+
+// List looks up passwords in breached hashes file, safe for concurrent use
+type List struct {
+	f *os.File
+	n int64
+}
+
+// Len returns number of breached hashes
+func (l *List) Len() int64 {
+	return l.n
+}
+
+// Contains reports whether password was found in breach, using binary search over the file
+func (l *List) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	buf := make([]byte, sha1.Size)
+	lo, hi := int64(0), l.n
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := l.f.ReadAt(buf, int64(len(magic))+mid*sha1.Size); err != nil {
+			return false, err
+		}
+		switch bytes.Compare(buf, sum[:]) {
+		case 0:
+			return true, nil
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// Close closes breached hashes file
+func (l *List) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,144 @@
+package breach_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/breach"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func dump(counts map[string]int) string {
+	var lines []string
+	for pw, count := range counts {
+		lines = append(lines, sha(pw)+":"+strconv.Itoa(count))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\r\n")
+}
+
+func TestBuild(t *testing.T) {
+	cases := []struct {
+		name     string
+		dump     string
+		minCount int
+		wantN    int
+		wantErr  string
+	}{
+		{
+			name:    "Invalid hash",
+			dump:    "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD:3",
+			wantErr: "breach: line 1: invalid SHA-1 hash",
+		},
+		{
+			name:    "Invalid count",
+			dump:    sha("password") + ":many",
+			wantErr: "breach: line 1: invalid count",
+		},
+		{
+			name:    "Unsorted dump",
+			dump:    sha("123456") + "\n" + sha("password"),
+			wantErr: breach.ErrUnsorted.Error(),
+		},
+		{
+			name:  "Hashes without counts and duplicates",
+			dump:  sha("password") + "\n\n" + sha("password") + "\n" + strings.ToLower(sha("123456")) + "\n",
+			wantN: 2,
+		},
+		{
+			name:     "Rare hashes skipped",
+			dump:     dump(map[string]int{"123456": 37359195, "password": 9545824, "correct horse battery staple": 3}),
+			minCount: 10,
+			wantN:    2,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := breach.Build(&buf, strings.NewReader(tt.dump), tt.minCount)
+			if tt.wantErr != "" {
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantN, n)
+			assert.Equal(t, len("GDBREACH1\n")+n*sha1.Size, buf.Len())
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	breached := map[string]int{}
+	for i, pw := range []string{"123456", "password", "qwerty", "letmein", "iloveyou", "monkey", "dragon"} {
+		breached[pw] = i + 1
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "breached.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := breach.Build(f, strings.NewReader(dump(breached)), 0); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	l, err := breach.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	assert.Equal(t, int64(len(breached)), l.Len())
+
+	for pw := range breached {
+		found, err := l.Contains(pw)
+		assert.Nil(t, err)
+		assert.True(t, found, pw)
+	}
+	for _, pw := range []string{"", "Password", "correct horse battery staple", "zzzzzzzz"} {
+		found, err := l.Contains(pw)
+		assert.Nil(t, err)
+		assert.False(t, found, pw)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	_, err := breach.Open(filepath.Join(dir, "missing.bin"))
+	assert.True(t, os.IsNotExist(err))
+
+	for name, content := range map[string]string{
+		"dump.txt":      sha("password") + ":1\n",
+		"truncated.bin": "GDBREACH1\n" + "0123456789",
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := breach.Open(path)
+		assert.Equal(t, breach.ErrInvalidFile, err, name)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "breach")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
@@ -3,29 +3,69 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
 
-// Load returns Configuration struct
-func Load(path string) (*Configuration, error) {
+// EnvName is environment variable selecting per-environment overlay file
+const EnvName = "ENVIRONMENT_NAME"
+
+// Load returns Configuration struct.
+// The file at path is read first, followed by overlays in order and the overlay of ENVIRONMENT_NAME environment, if present.
+// For ENVIRONMENT_NAME=prod, conf.yaml is overlaid by conf.prod.yaml.
+// Environment variables override file values, missing values are defaulted and the result is validated.
+func Load(path string, overlays ...string) (*Configuration, error) {
+	var cfg = new(Configuration)
+	if err := cfg.read(path); err != nil {
+		return nil, err
+	}
+	for _, o := range overlays {
+		if err := cfg.read(o); err != nil {
+			return nil, err
+		}
+	}
+	if env := os.Getenv(EnvName); env != "" {
+		ext := filepath.Ext(path)
+		overlay := strings.TrimSuffix(path, ext) + "." + env + ext
+		if _, err := os.Stat(overlay); !os.IsNotExist(err) {
+			if err := cfg.read(overlay); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Configuration) read(path string) error {
 	bytes, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("error reading config file, %s", err)
+		return fmt.Errorf("error reading config file, %s", err)
 	}
-	var cfg = new(Configuration)
-	if err := yaml.Unmarshal(bytes, cfg); err != nil {
-		return nil, fmt.Errorf("unable to decode into struct, %v", err)
+	if err := yaml.Unmarshal(bytes, c); err != nil {
+		return fmt.Errorf("unable to decode %s into struct, %v", path, err)
 	}
-	return cfg, nil
+	return nil
 }
 
 // Configuration holds data necessery for configuring application
 type Configuration struct {
-	Server *Server      `yaml:"server,omitempty"`
-	DB     *Database    `yaml:"database,omitempty"`
-	JWT    *JWT         `yaml:"jwt,omitempty"`
-	App    *Application `yaml:"application,omitempty"`
+	Server *Server       `yaml:"server,omitempty"`
+	DB     *Database     `yaml:"database,omitempty"`
+	JWT    *JWT          `yaml:"jwt,omitempty"`
+	App    *Application  `yaml:"application,omitempty"`
+	Mail   *Mail         `yaml:"mail,omitempty"`
+	MFA    *MFA          `yaml:"mfa,omitempty"`
+	Hash   *PasswordHash `yaml:"password_hash,omitempty"`
 }
 
 // Database holds data necessery for database configuration
@@ -41,6 +81,10 @@ type Server struct {
 	Debug        bool   `yaml:"debug,omitempty"`
 	ReadTimeout  int    `yaml:"read_timeout_seconds,omitempty"`
 	WriteTimeout int    `yaml:"write_timeout_seconds,omitempty"`
+	// CORSOrigins are origins allowed to make cross-origin requests, all if empty
+	CORSOrigins []string `yaml:"cors_origins,omitempty"`
+	// TrustedProxies are IPs or CIDR ranges whose X-Forwarded-For and X-Real-IP headers are honoured
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
 }
 
 // JWT holds data necessery for JWT configuration
@@ -50,10 +94,92 @@ type JWT struct {
 	RefreshDuration  int    `yaml:"refresh_duration_minutes,omitempty"`
 	MaxRefresh       int    `yaml:"max_refresh_minutes,omitempty"`
 	SigningAlgorithm string `yaml:"signing_algorithm,omitempty"`
+	Issuer           string `yaml:"issuer,omitempty"`
+	Audience         string `yaml:"audience,omitempty"`
+	Leeway           int    `yaml:"leeway_seconds,omitempty"`
+	// Keys are PEM encoded RSA, ECDSA or Ed25519 keys, used instead of Secret when set
+	Keys []JWTKey `yaml:"keys,omitempty"`
+	// SigningKeyID is kid of the key used for signing, defaults to the first private key
+	SigningKeyID string `yaml:"signing_kid,omitempty"`
+}
+
+// JWTKey holds data necessery for loading a JWT key.
+// Public keys only verify tokens, which allows rotating old keys out.
+type JWTKey struct {
+	ID        string `yaml:"kid,omitempty"`
+	File      string `yaml:"file,omitempty"`
+	Algorithm string `yaml:"algorithm,omitempty"`
 }
 
 // Application holds application configuration details
 type Application struct {
 	MinPasswordStr int    `yaml:"min_password_strength,omitempty"`
 	SwaggerUIPath  string `yaml:"swagger_ui_path,omitempty"`
+	LogLevel       string `yaml:"log_level,omitempty"`
+	ResetDuration  int    `yaml:"password_reset_duration_minutes,omitempty"`
+	ResetURL       string `yaml:"password_reset_url,omitempty"`
+	// Failed login throttling, 0 disables the respective limit
+	LoginMaxFailures   int `yaml:"login_max_failures,omitempty"`
+	LoginMaxIPFailures int `yaml:"login_max_ip_failures,omitempty"`
+	LoginLockout       int `yaml:"login_lockout_minutes,omitempty"`
+	LoginDelay         int `yaml:"login_delay_seconds,omitempty"`
+	// CursorSecret encrypts pagination cursors, a random one valid until restart is used if empty
+	CursorSecret string `yaml:"cursor_secret,omitempty"`
+	// TokenKey keys hashes of refresh, reset and challenge tokens stored in the database, it is required.
+	// Changing it invalidates all issued tokens and recovery codes.
+	TokenKey string `yaml:"token_key,omitempty"`
+	// CountEstimate estimates list totals above this number of rows instead of counting them, 0 always counts
+	CountEstimate int `yaml:"count_estimate_threshold,omitempty"`
+	// PasswordMinLength is the minimal number of characters of passwords, 8 if 0
+	PasswordMinLength int `yaml:"password_min_length,omitempty"`
+	// PasswordHistory disallows reusing this many most recent passwords, including the current one, 0 disables the check
+	PasswordHistory int `yaml:"password_history,omitempty"`
+	// PasswordMaxAge requires changing passwords older than this number of days on login, 0 disables the expiry
+	PasswordMaxAge int `yaml:"password_max_age_days,omitempty"`
+	// BreachedPasswords is the path of breached password hashes file built with cmd/breach, empty disables the screening
+	BreachedPasswords string `yaml:"breached_passwords_file,omitempty"`
+	// Email verification, durations default to 24 hours and 60 seconds if 0
+	VerifyURL            string `yaml:"email_verification_url,omitempty"`
+	VerifyDuration       int    `yaml:"email_verification_duration_hours,omitempty"`
+	VerifyResendInterval int    `yaml:"email_verification_resend_seconds,omitempty"`
+	// RequireVerifiedEmail refuses logins of users who haven't verified their email
+	RequireVerifiedEmail bool `yaml:"require_verified_email,omitempty"`
+	// DisableSignup refuses self-service signups of new companies
+	DisableSignup bool `yaml:"disable_signup,omitempty"`
+}
+
+// Mail holds data necessery for mailer configuration
+type Mail struct {
+	Driver   string `yaml:"driver,omitempty"`
+	From     string `yaml:"from,omitempty"`
+	Host     string `yaml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Dir      string `yaml:"dir,omitempty"`
+}
+
+// MFA holds data necessery for two-factor authentication configuration
+type MFA struct {
+	Issuer string `yaml:"issuer,omitempty"`
+	// RequiredRole requires MFA for users with this access role or a more privileged one, 0 disables the requirement
+	RequiredRole int `yaml:"required_role,omitempty"`
+}
+
+// PasswordHash holds data necessery for password hashing configuration.
+// Passwords hashed with another algorithm or parameters are rehashed as users log in.
+type PasswordHash struct {
+	// Algorithm is bcrypt, argon2id or scrypt, bcrypt if empty
+	Algorithm string `yaml:"algorithm,omitempty"`
+	// Pepper is mixed into hashed passwords, keep it out of the database, e.g. in APP_PASSWORD_HASH_PEPPER_FILE
+	Pepper     string `yaml:"pepper,omitempty"`
+	BcryptCost int    `yaml:"bcrypt_cost,omitempty"`
+	// Argon2id parameters, memory is in KiB
+	Argon2Memory      int `yaml:"argon2_memory_kib,omitempty"`
+	Argon2Iterations  int `yaml:"argon2_iterations,omitempty"`
+	Argon2Parallelism int `yaml:"argon2_parallelism,omitempty"`
+	// Scrypt parameters, cost is log2 of N
+	ScryptCost        int `yaml:"scrypt_cost,omitempty"`
+	ScryptBlockSize   int `yaml:"scrypt_block_size,omitempty"`
+	ScryptParallelism int `yaml:"scrypt_parallelism,omitempty"`
 }
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// EnvPrefix prefixes environment variables overriding configuration.
+// Variable names are built from yaml keys, e.g. APP_DATABASE_PSN overrides database.psn.
+// Appending _FILE reads the value from a file instead, e.g. APP_JWT_SECRET_FILE=/run/secrets/jwt.
+const EnvPrefix = "APP"
+
+// applyEnv overrides configuration fields with environment variables, creating missing sections as needed
+func (c *Configuration) applyEnv(lookup func(string) (string, bool)) error {
+	var errs ValidationError
+	cv := reflect.ValueOf(c).Elem()
+	for i := 0; i < cv.NumField(); i++ {
+		section := yamlKey(cv.Type().Field(i))
+		sv := cv.Field(i)
+		st := sv.Type().Elem()
+		for j := 0; j < st.NumField(); j++ {
+			key := yamlKey(st.Field(j))
+			name := strings.ToUpper(EnvPrefix + "_" + section + "_" + key)
+			value, ok, err := envValue(lookup, name)
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if sv.IsNil() {
+				sv.Set(reflect.New(st))
+			}
+			if err := setField(sv.Elem().Field(j), value); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// envValue looks up the variable or reads the file named by its _FILE variant
+func envValue(lookup func(string) (string, bool), name string) (string, bool, error) {
+	value, ok := lookup(name)
+	path, fromFile := lookup(name + "_FILE")
+	if !fromFile {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("%s: both %s and %s_FILE are set", name, name, name)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %v", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setField(f reflect.Value, value string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		f.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		f.SetBool(b)
+	default:
+		// Lists are given in YAML flow style, e.g. [{kid: "2019", file: keys/2019.pem}]
+		v := reflect.New(f.Type())
+		if err := yaml.Unmarshal([]byte(value), v.Interface()); err != nil {
+			return err
+		}
+		f.Set(v.Elem())
+	}
+	return nil
+}
+
+func yamlKey(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+)
+
+// ValidationError lists all invalid configuration fields
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return "invalid configuration:\n\t" + strings.Join(e, "\n\t")
+}
+
+// setDefaults creates missing sections and sets values whose zero value isn't usable
+func (c *Configuration) setDefaults() {
+	if c.DB == nil {
+		c.DB = &Database{}
+	}
+	if c.Server == nil {
+		c.Server = &Server{}
+	}
+	if c.Server.Port == "" {
+		c.Server.Port = ":8080"
+	}
+	if c.JWT == nil {
+		c.JWT = &JWT{}
+	}
+	if c.JWT.Duration == 0 {
+		c.JWT.Duration = 15
+	}
+	if c.JWT.RefreshDuration == 0 {
+		c.JWT.RefreshDuration = 15
+	}
+	if c.JWT.MaxRefresh == 0 {
+		c.JWT.MaxRefresh = 1440
+	}
+	if c.JWT.SigningAlgorithm == "" && len(c.JWT.Keys) == 0 {
+		c.JWT.SigningAlgorithm = "HS256"
+	}
+	if c.App == nil {
+		c.App = &Application{}
+	}
+	if c.App.SwaggerUIPath == "" {
+		c.App.SwaggerUIPath = "assets/swaggerui"
+	}
+	if c.App.ResetDuration == 0 {
+		c.App.ResetDuration = 30
+	}
+	if c.App.PasswordMinLength == 0 {
+		c.App.PasswordMinLength = 8
+	}
+	if c.Mail == nil {
+		c.Mail = &Mail{}
+	}
+	if c.MFA == nil {
+		c.MFA = &MFA{}
+	}
+	if c.Hash == nil {
+		c.Hash = &PasswordHash{}
+	}
+}
+
+// Validate checks configuration values, reporting all invalid fields at once
+func (c *Configuration) Validate() error {
+	var errs ValidationError
+	check := func(ok bool, field, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, field+": "+fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.DB.PSN != "", "database.psn", "is required")
+	check(c.DB.Timeout >= 0, "database.timeout_seconds", "must not be negative")
+
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout_seconds", "must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout_seconds", "must not be negative")
+	for i, p := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(p)
+		check(err == nil || net.ParseIP(p) != nil, fmt.Sprintf("server.trusted_proxies[%d]", i), "%q is not an IP or CIDR range", p)
+	}
+
+	check(c.JWT.Duration > 0, "jwt.duration_minutes", "must be positive")
+	check(c.JWT.RefreshDuration > 0, "jwt.refresh_duration_minutes", "must be positive")
+	check(c.JWT.MaxRefresh >= c.JWT.RefreshDuration, "jwt.max_refresh_minutes", "must not be shorter than refresh_duration_minutes")
+	check(c.JWT.Leeway >= 0, "jwt.leeway_seconds", "must not be negative")
+	if len(c.JWT.Keys) == 0 {
+		check(c.JWT.Secret != "", "jwt.secret", "is required unless jwt.keys are set")
+		check(c.JWT.SigningAlgorithm == "HS256" || c.JWT.SigningAlgorithm == "HS384" || c.JWT.SigningAlgorithm == "HS512",
+			"jwt.signing_algorithm", "%q is not a HMAC signing method", c.JWT.SigningAlgorithm)
+		check(c.JWT.SigningKeyID == "", "jwt.signing_kid", "requires jwt.keys")
+	} else {
+		kids := make(map[string]bool, len(c.JWT.Keys))
+		for i, k := range c.JWT.Keys {
+			field := fmt.Sprintf("jwt.keys[%d]", i)
+			check(k.ID != "", field+".kid", "is required")
+			check(k.File != "", field+".file", "is required")
+			check(!kids[k.ID], field+".kid", "%q is duplicated", k.ID)
+			kids[k.ID] = true
+		}
+		check(c.JWT.SigningKeyID == "" || kids[c.JWT.SigningKeyID], "jwt.signing_kid", "%q is not in jwt.keys", c.JWT.SigningKeyID)
+	}
+
+	check(c.App.MinPasswordStr >= 0 && c.App.MinPasswordStr <= 4, "application.min_password_strength", "must be between 0 and 4")
+	switch c.App.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		check(false, "application.log_level", "%q is not one of debug, info, warn or error", c.App.LogLevel)
+	}
+	check(c.App.TokenKey != "", "application.token_key", "is required")
+	check(c.App.ResetDuration > 0, "application.password_reset_duration_minutes", "must be positive")
+	check(c.App.LoginMaxFailures >= 0, "application.login_max_failures", "must not be negative")
+	check(c.App.LoginMaxIPFailures >= 0, "application.login_max_ip_failures", "must not be negative")
+	check(c.App.LoginLockout >= 0, "application.login_lockout_minutes", "must not be negative")
+	check(c.App.LoginDelay >= 0, "application.login_delay_seconds", "must not be negative")
+	check(c.App.CountEstimate >= 0, "application.count_estimate_threshold", "must not be negative")
+	check(c.App.PasswordMinLength > 0, "application.password_min_length", "must be positive")
+	check(c.App.PasswordHistory >= 0, "application.password_history", "must not be negative")
+	check(c.App.PasswordMaxAge >= 0, "application.password_max_age_days", "must not be negative")
+	check(c.App.VerifyDuration >= 0, "application.email_verification_duration_hours", "must not be negative")
+	check(c.App.VerifyResendInterval >= 0, "application.email_verification_resend_seconds", "must not be negative")
+
+	switch c.Mail.Driver {
+	case "":
+		check(false, "mail.driver", "is required, use smtp, file or memory")
+	case "memory":
+	case "smtp":
+		check(c.Mail.Host != "", "mail.host", "is required by smtp driver")
+		check(c.Mail.Port > 0, "mail.port", "is required by smtp driver")
+	case "file":
+		check(c.Mail.Dir != "", "mail.dir", "is required by file driver")
+	default:
+		check(false, "mail.driver", "%q is not one of smtp, file or memory", c.Mail.Driver)
+	}
+
+	check(c.MFA.RequiredRole >= 0, "mfa.required_role", "must not be negative")
+
+	switch c.Hash.Algorithm {
+	case "", "bcrypt", "argon2id", "scrypt":
+	default:
+		check(false, "password_hash.algorithm", "%q is not one of bcrypt, argon2id or scrypt", c.Hash.Algorithm)
+	}
+	check(c.Hash.BcryptCost == 0 || c.Hash.BcryptCost >= 4 && c.Hash.BcryptCost <= 31, "password_hash.bcrypt_cost", "must be between 4 and 31")
+	check(c.Hash.Argon2Memory >= 0 && int64(c.Hash.Argon2Memory) <= math.MaxUint32, "password_hash.argon2_memory_kib", "must be between 0 and %d", uint32(math.MaxUint32))
+	check(c.Hash.Argon2Iterations >= 0 && int64(c.Hash.Argon2Iterations) <= math.MaxUint32, "password_hash.argon2_iterations", "must be between 0 and %d", uint32(math.MaxUint32))
+	check(c.Hash.Argon2Parallelism >= 0 && c.Hash.Argon2Parallelism <= math.MaxUint8, "password_hash.argon2_parallelism", "must be between 0 and %d", math.MaxUint8)
+	check(c.Hash.ScryptCost >= 0 && c.Hash.ScryptCost <= 30, "password_hash.scrypt_cost", "must be between 0 and 30")
+	check(c.Hash.ScryptBlockSize >= 0, "password_hash.scrypt_block_size", "must not be negative")
+	check(c.Hash.ScryptParallelism >= 0, "password_hash.scrypt_parallelism", "must not be negative")
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NewWatcher creates watcher reloading configuration loaded from path and overlays
+func NewWatcher(cfg *Configuration, path string, overlays ...string) *Watcher {
+	w := &Watcher{path: path, overlays: overlays, cfg: cfg}
+	w.stamps = w.stat()
+	return w
+}
+
+// Watcher reloads configuration when the process receives SIGHUP or configuration files change
+type Watcher struct {
+	path     string
+	overlays []string
+
+	mu       sync.Mutex
+	cfg      *Configuration
+	stamps   map[string]time.Time
+	handlers []func(old, cfg *Configuration) error
+}
+
+// Config returns current configuration
+func (w *Watcher) Config() *Configuration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cfg
+}
+
+// OnReload registers fn to be called with the previous and the new configuration on reload.
+// If fn returns an error, the new configuration is rejected and later handlers are not called,
+// so fn should apply its changes only once nothing can fail.
+func (w *Watcher) OnReload(fn func(old, cfg *Configuration) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Reload loads and validates configuration, passing it to reload handlers.
+// Current configuration is kept on error.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stamps = w.stat()
+
+	cfg, err := Load(w.path, w.overlays...)
+	if err != nil {
+		return err
+	}
+	for _, fn := range w.handlers {
+		if err := fn(w.cfg, cfg); err != nil {
+			return err
+		}
+	}
+	w.cfg = cfg
+	return nil
+}
+
+// Watch reloads configuration on SIGHUP and whenever watched files change, checking them every interval.
+// Reload errors are passed to onErr. Calling the returned function stops watching.
+func (w *Watcher) Watch(interval time.Duration, onErr func(error)) (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+			case <-ticker.C:
+				if !w.changed() {
+					continue
+				}
+			}
+			if err := w.Reload(); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(hup)
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+func (w *Watcher) changed() bool {
+	stamps := w.stat()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !reflect.DeepEqual(stamps, w.stamps)
+}
+
+// stat returns modification times of configuration files, secret files referenced by environment and
+// the environment overlay, which may not exist yet
+func (w *Watcher) stat() map[string]time.Time {
+	files := append([]string{w.path}, w.overlays...)
+	if env := os.Getenv(EnvName); env != "" {
+		ext := filepath.Ext(w.path)
+		files = append(files, strings.TrimSuffix(w.path, ext)+"."+env+ext)
+	}
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if strings.HasPrefix(parts[0], EnvPrefix+"_") && strings.HasSuffix(parts[0], "_FILE") {
+			files = append(files, parts[1])
+		}
+	}
+
+	stamps := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			stamps[f] = fi.ModTime()
+		}
+	}
+	return stamps
+}
+
+// Changed returns yaml paths of fields which differ between configurations, e.g. database.psn
+func Changed(old, cfg *Configuration) []string {
+	var changed []string
+	ov, nv := reflect.ValueOf(old).Elem(), reflect.ValueOf(cfg).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		section := yamlKey(ov.Type().Field(i))
+		st := ov.Field(i).Type().Elem()
+		a, b := ov.Field(i), nv.Field(i)
+		if a.IsNil() {
+			a = reflect.New(st)
+		}
+		if b.IsNil() {
+			b = reflect.New(st)
+		}
+		for j := 0; j < st.NumField(); j++ {
+			if !reflect.DeepEqual(a.Elem().Field(j).Interface(), b.Elem().Field(j).Interface()) {
+				changed = append(changed, section+"."+yamlKey(st.Field(j)))
+			}
+		}
+	}
+	return changed
+}
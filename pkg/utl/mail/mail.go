@@ -0,0 +1,145 @@
+// Package mail provides email delivery over SMTP, to files or to memory
+package mail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message represents an email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer represents email delivery interface
+type Mailer interface {
+	Send(Message) error
+}
+
+// Config represents mailer specific config
+type Config struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Dir      string
+}
+
+// New returns a mailer for the configured driver.
+// Supported drivers are smtp, file and memory. The driver has to be set explicitly,
+// so a missing setting never silently discards emails.
+func New(cfg *Config) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTP(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From), nil
+	case "file":
+		return NewFile(cfg.Dir, cfg.From)
+	case "memory":
+		return NewMemory(cfg.From), nil
+	case "":
+		return nil, fmt.Errorf("mail driver is required")
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// NewSMTP creates new mailer delivering messages through an SMTP server
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+	}
+}
+
+// SMTP delivers messages through an SMTP server
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// Send sends the message
+func (s *SMTP) Send(m Message) error {
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{m.To}, format(s.from, m))
+}
+
+// NewFile creates new mailer writing every message to a separate file in dir
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create mail directory, %v", err)
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// File writes messages to files, useful for development
+type File struct {
+	mu   sync.Mutex
+	dir  string
+	from string
+	seq  int
+}
+
+// Send writes the message to a new .eml file
+func (f *File) Send(m Message) error {
+	f.mu.Lock()
+	f.seq++
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), f.seq)
+	f.mu.Unlock()
+	return ioutil.WriteFile(filepath.Join(f.dir, name), format(f.from, m), 0600)
+}
+
+// NewMemory creates new mailer keeping sent messages in memory
+func NewMemory(from string) *Memory {
+	return &Memory{from: from}
+}
+
+// Memory keeps sent messages in memory, useful for testing
+type Memory struct {
+	mu       sync.Mutex
+	from     string
+	messages []Message
+}
+
+// Send stores the message
+func (mm *Memory) Send(m Message) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.messages = append(mm.messages, m)
+	return nil
+}
+
+// Messages returns all messages sent so far
+func (mm *Memory) Messages() []Message {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]Message(nil), mm.messages...)
+}
+
+func format(from string, m Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(m.Body)
+	return []byte(b.String())
+}
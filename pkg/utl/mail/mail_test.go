@@ -0,0 +1,78 @@
+package mail_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/mail"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     *mail.Config
+		wantErr bool
+	}{
+		{
+			name:    "Unknown driver",
+			cfg:     &mail.Config{Driver: "carrier-pigeon"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing driver",
+			cfg:     &mail.Config{},
+			wantErr: true,
+		},
+		{
+			name: "SMTP",
+			cfg:  &mail.Config{Driver: "smtp", Host: "localhost", Port: 25},
+		},
+		{
+			name: "Memory",
+			cfg:  &mail.Config{Driver: "memory"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := mail.New(tt.cfg)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantErr, m == nil)
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := mail.NewMemory("noreply@mail.com")
+	msg := mail.Message{To: "johndoe@mail.com", Subject: "Hi", Body: "Hello"}
+	assert.Nil(t, m.Send(msg))
+	assert.Equal(t, []mail.Message{msg}, m.Messages())
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mail.New(&mail.Config{Driver: "file", Dir: filepath.Join(dir, "out"), From: "noreply@mail.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, m.Send(mail.Message{To: "johndoe@mail.com", Subject: "Hi", Body: "Hello"}))
+	assert.Nil(t, m.Send(mail.Message{To: "janedoe@mail.com", Subject: "Hi", Body: "Hello"}))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, files, 2)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "out", files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(string(b), "From: noreply@mail.com\r\n"))
+	assert.True(t, strings.HasSuffix(string(b), "\r\n\r\nHello"))
+}
@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Claims errors
+var (
+	ErrTokenExpired     = errors.New("jwt: token is expired")
+	ErrTokenNotValidYet = errors.New("jwt: token is not valid yet")
+	ErrInvalidIssuer    = errors.New("jwt: unexpected token issuer")
+	ErrInvalidAudience  = errors.New("jwt: unexpected token audience")
+	ErrMissingClaims    = errors.New("jwt: required claims are missing")
+	ErrInvalidSubject   = errors.New("jwt: subject does not match user id")
+)
+
+// Claims represents access token claims.
+// Short user claim names are kept for compatibility with already issued tokens.
+type Claims struct {
+	jwt.StandardClaims
+	ID         int              `json:"id"`
+	Username   string           `json:"u"`
+	Email      string           `json:"e"`
+	Role       gorsk.AccessRole `json:"r"`
+	RoleID     gorsk.AccessRole `json:"rid,omitempty"`
+	CompanyID  int              `json:"c"`
+	LocationID int              `json:"l"`
+	SessionID  int              `json:"sid,omitempty"`
+}
+
+// validate checks time based claims, expected issuer and audience and presence of required claims
+func (cfg *Config) validate(c *Claims, now time.Time) error {
+	if c.ExpiresAt == 0 || c.Id == "" || c.Subject == "" || c.ID == 0 || c.Role == 0 {
+		return ErrMissingClaims
+	}
+	if c.Subject != strconv.Itoa(c.ID) {
+		return ErrInvalidSubject
+	}
+	if !now.Before(time.Unix(c.ExpiresAt, 0).Add(cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(cfg.Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrTokenNotValidYet
+	}
+	if c.IssuedAt != 0 && now.Add(cfg.Leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return ErrTokenNotValidYet
+	}
+	if cfg.Issuer != "" && c.Issuer != cfg.Issuer {
+		return ErrInvalidIssuer
+	}
+	if cfg.Audience != "" && c.Audience != cfg.Audience {
+		return ErrInvalidAudience
+	}
+	return nil
+}
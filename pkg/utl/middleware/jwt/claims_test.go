@@ -0,0 +1,201 @@
+package jwt_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/middleware/jwt"
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaims(t *testing.T) {
+	now := time.Now()
+	claims := func(override jwtgo.MapClaims) jwtgo.MapClaims {
+		c := jwtgo.MapClaims{
+			"jti": "0f8fad5bd9cb469fa16570867728950e",
+			"sub": "1",
+			"iss": "https://api.example.com",
+			"aud": "example",
+			"iat": now.Unix(),
+			"nbf": now.Unix(),
+			"exp": now.Add(time.Minute).Unix(),
+			"id":  1,
+			"u":   "johndoe",
+			"e":   "johndoe@mail.com",
+			"r":   100,
+			"c":   1,
+			"l":   1,
+		}
+		for k, v := range override {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+	cases := []struct {
+		name       string
+		claims     jwtgo.MapClaims
+		wantStatus int
+	}{
+		{
+			name:       "Success",
+			claims:     claims(nil),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Missing id",
+			claims:     claims(jwtgo.MapClaims{"id": nil}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Malformed id",
+			claims:     claims(jwtgo.MapClaims{"id": "one"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Malformed company",
+			claims:     claims(jwtgo.MapClaims{"c": []int{1}}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing role",
+			claims:     claims(jwtgo.MapClaims{"r": nil}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing jti",
+			claims:     claims(jwtgo.MapClaims{"jti": nil}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Missing exp",
+			claims:     claims(jwtgo.MapClaims{"exp": nil}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Subject not matching id",
+			claims:     claims(jwtgo.MapClaims{"sub": "2"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong issuer",
+			claims:     claims(jwtgo.MapClaims{"iss": "https://evil.example.com"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong audience",
+			claims:     claims(jwtgo.MapClaims{"aud": "other"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Expired within leeway",
+			claims:     claims(jwtgo.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Expired",
+			claims:     claims(jwtgo.MapClaims{"exp": now.Add(-time.Minute).Unix()}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Not before within leeway",
+			claims:     claims(jwtgo.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Not valid yet",
+			claims:     claims(jwtgo.MapClaims{"nbf": now.Add(time.Minute).Unix()}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Issued in future",
+			claims:     claims(jwtgo.MapClaims{"iat": now.Add(time.Minute).Unix()}),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	key, err := jwt.NewHMACKey("jwtsecret", "HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := jwt.NewKeyset("", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtMW := jwt.NewWithKeyset(ks, &jwt.Config{
+		Duration: 60,
+		Issuer:   "https://api.example.com",
+		Audience: "example",
+		Leeway:   30 * time.Second,
+	}, nil)
+	ts := httptest.NewServer(echoHandler(jwtMW.MWFunc()))
+	defer ts.Close()
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, tt.claims).SignedString([]byte("jwtsecret"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest("GET", ts.URL+"/hello", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal("Cannot create http request")
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestGeneratedClaims(t *testing.T) {
+	key, err := jwt.NewHMACKey("jwtsecret", "HS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := jwt.NewKeyset("", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwtMW := jwt.NewWithKeyset(ks, &jwt.Config{Duration: 60, Issuer: "goduck", Audience: "goduck"}, nil)
+	token, _, err := jwtMW.GenerateToken(&gorsk.User{
+		Base:       gorsk.Base{ID: 7},
+		Username:   "johndoe",
+		Email:      "johndoe@mail.com",
+		Role:       &gorsk.Role{AccessLevel: gorsk.CompanyAdminRole},
+		CompanyID:  2,
+		LocationID: 3,
+	}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	claims, err := jwtMW.ParseToken(echo.New().NewContext(req, httptest.NewRecorder()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, "goduck", claims.Issuer)
+	assert.Equal(t, "goduck", claims.Audience)
+	assert.Len(t, claims.Id, 32)
+	assert.Equal(t, claims.IssuedAt, claims.NotBefore)
+	assert.Equal(t, claims.IssuedAt+3600, claims.ExpiresAt)
+	assert.Equal(t, 7, claims.ID)
+	assert.Equal(t, "johndoe", claims.Username)
+	assert.Equal(t, gorsk.CompanyAdminRole, claims.Role)
+	assert.Equal(t, 2, claims.CompanyID)
+	assert.Equal(t, 3, claims.LocationID)
+	assert.Equal(t, 5, claims.SessionID)
+}
@@ -1,8 +1,13 @@
 package jwt
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/figassis/goduck/pkg/utl/model"
@@ -12,63 +17,127 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-// New generates new JWT service necessery for auth middleware
-func New(secret, algo string, d int) *Service {
-	signingMethod := jwt.GetSigningMethod(algo)
-	if signingMethod == nil {
+// Revoker represents access token revocation store interface
+type Revoker interface {
+	Revoke(context.Context, string, int, time.Time) error
+	RevokeUser(context.Context, int, time.Time) error
+	IsRevoked(context.Context, string, int, time.Time) (bool, error)
+}
+
+// Config represents access token configuration
+type Config struct {
+	// Duration is access token lifetime in minutes
+	Duration int
+	// Issuer is put into iss claim and required when verifying, if set
+	Issuer string
+	// Audience is put into aud claim and required when verifying, if set
+	Audience string
+	// Leeway is the allowed clock skew when checking exp, nbf and iat claims
+	Leeway time.Duration
+}
+
+// New generates new JWT service necessery for auth middleware, signing tokens with a shared HMAC secret.
+// If revoker is nil, tokens cannot be revoked before expiry.
+func New(secret, algo string, d int, rs Revoker) *Service {
+	key, err := NewHMACKey(secret, algo)
+	if err != nil {
 		panic("invalid jwt signing method")
 	}
-	return &Service{
-		key:      []byte(secret),
-		algo:     signingMethod,
-		duration: time.Duration(d) * time.Minute,
+	ks, err := NewKeyset("", key)
+	if err != nil {
+		panic(err)
 	}
+	return NewWithKeyset(ks, &Config{Duration: d}, rs)
+}
+
+// NewWithKeyset generates new JWT service signing tokens with keyset's signing key.
+// Tokens are verified with the keyset key matching their kid header.
+func NewWithKeyset(ks *Keyset, cfg *Config, rs Revoker) *Service {
+	j := &Service{revoker: rs}
+	j.Reload(ks, cfg)
+	return j
 }
 
 // Service provides a Json-Web-Token authentication implementation
 type Service struct {
-	// Secret key used for signing.
-	key []byte
+	// Current keys and configuration, swapped on reload
+	settings atomic.Value
+
+	// Store holding revoked tokens
+	revoker Revoker
+}
+
+type settings struct {
+	// Keys used for signing and verifying tokens
+	keys *Keyset
+
+	// Expected claims
+	cfg *Config
 
 	// Duration for which the jwt token is valid.
 	duration time.Duration
+}
 
-	// JWT signing algorithm
-	algo jwt.SigningMethod
+// Reload replaces keys and configuration, safe for concurrent use with token parsing and generation.
+// Tokens signed by keys missing from the new keyset are no longer valid.
+func (j *Service) Reload(ks *Keyset, cfg *Config) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	j.settings.Store(&settings{
+		keys:     ks,
+		cfg:      cfg,
+		duration: time.Duration(cfg.Duration) * time.Minute,
+	})
+}
+
+func (j *Service) load() *settings {
+	return j.settings.Load().(*settings)
 }
 
 // MWFunc makes JWT implement the Middleware interface.
 func (j *Service) MWFunc() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			token, err := j.ParseToken(c)
-			if err != nil || !token.Valid {
+			claims, err := j.ParseToken(c)
+			if err != nil {
 				return c.NoContent(http.StatusUnauthorized)
 			}
 
-			claims := token.Claims.(jwt.MapClaims)
-
-			id := int(claims["id"].(float64))
-			companyID := int(claims["c"].(float64))
-			locationID := int(claims["l"].(float64))
-			username := claims["u"].(string)
-			email := claims["e"].(string)
-			role := gorsk.AccessRole(claims["r"].(float64))
+			req := c.Request()
+			revoked, err := j.isRevoked(req.Context(), claims)
+			if err != nil {
+				return err
+			}
+			if revoked {
+				return c.NoContent(http.StatusUnauthorized)
+			}
 
-			c.Set("id", id)
-			c.Set("company_id", companyID)
-			c.Set("location_id", locationID)
-			c.Set("username", username)
-			c.Set("email", email)
-			c.Set("role", role)
+			// Tokens issued before role ids were included carry built-in roles, whose id equals access level
+			roleID := claims.RoleID
+			if roleID == 0 {
+				roleID = claims.Role
+			}
+			c.SetRequest(req.WithContext(gorsk.NewUserContext(req.Context(), &gorsk.AuthUser{
+				ID:           claims.ID,
+				CompanyID:    claims.CompanyID,
+				LocationID:   claims.LocationID,
+				Username:     claims.Username,
+				Email:        claims.Email,
+				Role:         claims.Role,
+				RoleID:       roleID,
+				SessionID:    claims.SessionID,
+				TokenID:      claims.Id,
+				TokenExpires: time.Unix(claims.ExpiresAt, 0),
+			})))
 
 			return next(c)
 		}
 	}
 }
 
-// ParseToken parses token from Authorization header
-func (j *Service) ParseToken(c echo.Context) (*jwt.Token, error) {
+// ParseToken parses token from Authorization header and validates its claims
+func (j *Service) ParseToken(c echo.Context) (*Claims, error) {
 
 	token := c.Request().Header.Get("Authorization")
 	if token == "" {
@@ -79,30 +148,95 @@ func (j *Service) ParseToken(c echo.Context) (*jwt.Token, error) {
 		return nil, gorsk.ErrGeneric
 	}
 
-	return jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-		if j.algo != token.Method {
-			return nil, gorsk.ErrGeneric
-		}
-		return j.key, nil
-	})
+	s := j.load()
+	claims := new(Claims)
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	if _, err := parser.ParseWithClaims(parts[1], claims, s.keys.verificationKey); err != nil {
+		return nil, err
+	}
+	if err := s.cfg.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
 
 }
 
-// GenerateToken generates new JWT token and populates it with user data
-func (j *Service) GenerateToken(u *gorsk.User) (string, string, error) {
-	expire := time.Now().Add(j.duration)
+// GenerateToken generates new JWT token and populates it with user and session data
+func (j *Service) GenerateToken(u *gorsk.User, sessionID int) (string, string, error) {
+	s := j.load()
+	now := time.Now()
+	expire := now.Add(s.duration)
 
-	token := jwt.NewWithClaims((j.algo), jwt.MapClaims{
-		"id":  u.ID,
-		"u":   u.Username,
-		"e":   u.Email,
-		"r":   u.Role.AccessLevel,
-		"c":   u.CompanyID,
-		"l":   u.LocationID,
-		"exp": expire.Unix(),
+	jti, err := newJTI()
+	if err != nil {
+		return "", "", err
+	}
+
+	key := s.keys.signing
+	token := jwt.NewWithClaims(key.Method, &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   strconv.Itoa(u.ID),
+			Issuer:    s.cfg.Issuer,
+			Audience:  s.cfg.Audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: expire.Unix(),
+		},
+		ID:         u.ID,
+		Username:   u.Username,
+		Email:      u.Email,
+		Role:       u.Role.AccessLevel,
+		RoleID:     u.Role.ID,
+		CompanyID:  u.CompanyID,
+		LocationID: u.LocationID,
+		SessionID:  sessionID,
 	})
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
 
-	tokenString, err := token.SignedString(j.key)
+	tokenString, err := token.SignedString(key.private)
 
 	return tokenString, expire.Format(time.RFC3339), err
 }
+
+// JWKS returns public keys used for verifying tokens
+func (j *Service) JWKS() *gorsk.JWKS {
+	return j.load().keys.JWKS()
+}
+
+// Revoke revokes the access token the user carried by ctx authenticated with, until it expires
+func (j *Service) Revoke(ctx context.Context) error {
+	if j.revoker == nil {
+		return nil
+	}
+	u := gorsk.UserFromContext(ctx)
+	if u == nil || u.TokenID == "" {
+		return gorsk.ErrUnauthorized
+	}
+	return j.revoker.Revoke(ctx, u.TokenID, u.ID, u.TokenExpires)
+}
+
+// RevokeUser revokes all access tokens issued to the user so far
+func (j *Service) RevokeUser(ctx context.Context, id int) error {
+	if j.revoker == nil {
+		return nil
+	}
+	return j.revoker.RevokeUser(ctx, id, time.Now())
+}
+
+func (j *Service) isRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if j.revoker == nil {
+		return false, nil
+	}
+	return j.revoker.IsRevoked(ctx, claims.Id, claims.ID, time.Unix(claims.IssuedAt, 0))
+}
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
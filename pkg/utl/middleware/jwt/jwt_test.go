@@ -1,6 +1,7 @@
 package jwt_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/revoke"
 
 	"github.com/labstack/echo"
 	"github.com/stretchr/testify/assert"
@@ -55,7 +57,7 @@ func TestMWFunc(t *testing.T) {
 			wantStatus: http.StatusOK,
 		},
 	}
-	jwtMW := jwt.New("jwtsecret", "HS256", 60)
+	jwtMW := jwt.New("jwtsecret", "HS256", 60, nil)
 	ts := httptest.NewServer(echoHandler(jwtMW.MWFunc()))
 	defer ts.Close()
 	path := ts.URL + "/hello"
@@ -108,14 +110,103 @@ func TestGenerateToken(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.algo != "HS256" {
 				assert.Panics(t, func() {
-					jwt.New("jwtsecret", tt.algo, 60)
+					jwt.New("jwtsecret", tt.algo, 60, nil)
 				}, "The code did not panic")
 				return
 			}
-			jwt := jwt.New("jwtsecret", tt.algo, 60)
-			str, _, err := jwt.GenerateToken(tt.req)
+			jwt := jwt.New("jwtsecret", tt.algo, 60, nil)
+			str, _, err := jwt.GenerateToken(tt.req, 1)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.wantToken, strings.Split(str, ".")[0])
 		})
 	}
 }
+
+func TestRevoke(t *testing.T) {
+	usr := &gorsk.User{
+		Base: gorsk.Base{
+			ID: 1,
+		},
+		Username: "johndoe",
+		Email:    "johndoe@mail.com",
+		Role: &gorsk.Role{
+			AccessLevel: gorsk.SuperAdminRole,
+		},
+		CompanyID:  1,
+		LocationID: 1,
+	}
+	jwtMW := jwt.New("jwtsecret", "HS256", 60, revoke.NewMemory())
+	e := echoHandler(jwtMW.MWFunc())
+	e.POST("/logout", func(c echo.Context) error {
+		return jwtMW.Revoke(c.Request().Context())
+	})
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	client := &http.Client{}
+
+	newToken := func() string {
+		token, _, err := jwtMW.GenerateToken(usr, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	status := func(method, path, header string) int {
+		req, _ := http.NewRequest(method, ts.URL+path, nil)
+		req.Header.Set("Authorization", header)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal("Cannot create http request")
+		}
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	first, second := newToken(), newToken()
+	assert.Equal(t, http.StatusOK, status("GET", "/hello", first))
+	assert.Equal(t, http.StatusOK, status("GET", "/hello", mock.HeaderValid()))
+
+	assert.Equal(t, http.StatusOK, status("POST", "/logout", first))
+	assert.Equal(t, http.StatusUnauthorized, status("GET", "/hello", first))
+	assert.Equal(t, http.StatusOK, status("GET", "/hello", second))
+
+	assert.Equal(t, gorsk.ErrUnauthorized, jwtMW.Revoke(context.Background()))
+
+	assert.Nil(t, jwtMW.RevokeUser(context.Background(), usr.ID))
+	assert.Equal(t, http.StatusUnauthorized, status("GET", "/hello", second))
+	assert.Equal(t, http.StatusUnauthorized, status("GET", "/hello", mock.HeaderValid()))
+}
+
+func TestReload(t *testing.T) {
+	usr := &gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}
+	jwtMW := jwt.New("jwtsecret", "HS256", 60, nil)
+	ts := httptest.NewServer(echoHandler(jwtMW.MWFunc()))
+	defer ts.Close()
+
+	status := func(header string) int {
+		req, _ := http.NewRequest("GET", ts.URL+"/hello", nil)
+		req.Header.Set("Authorization", header)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Cannot create http request")
+		}
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	old, _, err := jwtMW.GenerateToken(usr, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status("Bearer "+old))
+
+	key, err := jwt.NewHMACKey("rotatedsecret", "HS512")
+	assert.Nil(t, err)
+	ks, err := jwt.NewKeyset("", key)
+	assert.Nil(t, err)
+	jwtMW.Reload(ks, &jwt.Config{Duration: 5, Issuer: "goduck"})
+
+	assert.Equal(t, http.StatusUnauthorized, status("Bearer "+old))
+	str, _, err := jwtMW.GenerateToken(usr, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "eyJhbGciOiJIUzUxMiIsInR5cCI6IkpXVCJ9", strings.Split(str, ".")[0])
+	assert.Equal(t, http.StatusOK, status("Bearer "+str))
+}
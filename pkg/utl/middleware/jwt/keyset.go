@@ -0,0 +1,245 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Key represents a key identified by kid.
+// Keys without the private part can only verify tokens, which is used while rotating keys out.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// CanSign checks whether the key holds the private part
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// NewHMACKey creates a symmetric key, used by tokens without kid header
+func NewHMACKey(secret, algo string) (*Key, error) {
+	method, ok := jwt.GetSigningMethod(algo).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("jwt: %q is not a HMAC signing method", algo)
+	}
+	return &Key{Method: method, private: []byte(secret), public: []byte(secret)}, nil
+}
+
+// LoadKey reads PEM encoded private or public key from file
+func LoadKey(kid, path, algo string) (*Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(kid, data, algo)
+}
+
+// ParseKey parses PEM encoded RSA, ECDSA or Ed25519 private or public key.
+// If algo is empty, it is derived from the key type.
+func ParseKey(kid string, data []byte, algo string) (*Key, error) {
+	if kid == "" {
+		return nil, errors.New("jwt: key id is required")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt: key %s is not PEM encoded", kid)
+	}
+
+	k := &Key{ID: kid}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		k.public = pub
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		k.public = pub
+	default:
+		priv, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %s: %v", kid, err)
+		}
+		k.private = priv
+		k.public = priv.Public()
+	}
+
+	method, err := keyMethod(k.public, algo)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: key %s: %v", kid, err)
+	}
+	k.Method = method
+	return k, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return signer, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+}
+
+// keyMethod returns the signing method for the key, checking it matches the configured algorithm
+func keyMethod(pub interface{}, algo string) (jwt.SigningMethod, error) {
+	var def string
+	var allowed func(jwt.SigningMethod) bool
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		def = "RS256"
+		allowed = func(m jwt.SigningMethod) bool {
+			switch m.(type) {
+			case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+				return true
+			}
+			return false
+		}
+	case *ecdsa.PublicKey:
+		switch p.Curve {
+		case elliptic.P256():
+			def = "ES256"
+		case elliptic.P384():
+			def = "ES384"
+		case elliptic.P521():
+			def = "ES512"
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+		allowed = func(m jwt.SigningMethod) bool {
+			return m.Alg() == def
+		}
+	case ed25519.PublicKey:
+		def = SigningMethodEdDSA.Alg()
+		allowed = func(m jwt.SigningMethod) bool {
+			return m == SigningMethodEdDSA
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	if algo == "" {
+		algo = def
+	}
+	method := jwt.GetSigningMethod(algo)
+	if method == nil || !allowed(method) {
+		return nil, fmt.Errorf("signing method %q does not match key type", algo)
+	}
+	return method, nil
+}
+
+// Keyset holds keys valid for verifying tokens, one of which is used for signing
+type Keyset struct {
+	keys    map[string]*Key
+	ids     []string
+	signing *Key
+}
+
+// NewKeyset creates a keyset signing with the key identified by signingID.
+// If signingID is empty, the first key able to sign is used.
+func NewKeyset(signingID string, keys ...*Key) (*Keyset, error) {
+	ks := &Keyset{keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.ids = append(ks.ids, k.ID)
+		if ks.signing == nil && k.CanSign() && (signingID == "" || signingID == k.ID) {
+			ks.signing = k
+		}
+	}
+	if ks.signing == nil {
+		return nil, errors.New("jwt: no signing key in keyset")
+	}
+	return ks, nil
+}
+
+// Key returns the key identified by kid
+func (ks *Keyset) Key(kid string) (*Key, bool) {
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// verificationKey selects the key by token's kid header, rejecting unknown keys and mismatched algorithms
+func (ks *Keyset) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.Key(kid)
+	if !ok || k.Method.Alg() != token.Method.Alg() {
+		return nil, gorsk.ErrGeneric
+	}
+	return k.public, nil
+}
+
+// JWKS returns public keys of the keyset as JSON Web Key Set.
+// Symmetric keys are never published.
+func (ks *Keyset) JWKS() *gorsk.JWKS {
+	set := &gorsk.JWKS{Keys: []gorsk.JWK{}}
+	for _, id := range ks.ids {
+		k := ks.keys[id]
+		jwk := gorsk.JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = b64(pub.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.KeyType = "EC"
+			jwk.Curve = pub.Curve.Params().Name
+			jwk.X = b64(pad(pub.X.Bytes(), size))
+			jwk.Y = b64(pad(pub.Y.Bytes(), size))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = b64(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func pad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
@@ -0,0 +1,273 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/middleware/jwt"
+	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pemKey(t *testing.T, priv interface{}, public bool) []byte {
+	var block *pem.Block
+	if public {
+		der, err := x509.MarshalPKIXPublicKey(priv.(crypto.Signer).Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func testKeys(t *testing.T) (rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey, edKey ed25519.PrivateKey) {
+	var err error
+	if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if _, edKey, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, ecKey, edKey := testKeys(t)
+	cases := []struct {
+		name     string
+		kid      string
+		data     []byte
+		algo     string
+		wantAlg  string
+		wantSign bool
+		wantErr  bool
+	}{
+		{
+			name:    "Missing kid",
+			data:    pemKey(t, rsaKey, false),
+			wantErr: true,
+		},
+		{
+			name:    "Not PEM",
+			kid:     "1",
+			data:    []byte("secret"),
+			wantErr: true,
+		},
+		{
+			name:    "Algorithm not matching key",
+			kid:     "1",
+			data:    pemKey(t, ecKey, false),
+			algo:    "RS256",
+			wantErr: true,
+		},
+		{
+			name:     "RSA with default algorithm",
+			kid:      "1",
+			data:     pemKey(t, rsaKey, false),
+			wantAlg:  "RS256",
+			wantSign: true,
+		},
+		{
+			name:     "RSA PKCS1",
+			kid:      "1",
+			data:     pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			algo:     "PS384",
+			wantAlg:  "PS384",
+			wantSign: true,
+		},
+		{
+			name:     "ECDSA",
+			kid:      "1",
+			data:     pemKey(t, ecKey, false),
+			wantAlg:  "ES256",
+			wantSign: true,
+		},
+		{
+			name:     "Ed25519",
+			kid:      "1",
+			data:     pemKey(t, edKey, false),
+			wantAlg:  "EdDSA",
+			wantSign: true,
+		},
+		{
+			name:    "Public key",
+			kid:     "1",
+			data:    pemKey(t, ecKey, true),
+			wantAlg: "ES256",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := jwt.ParseKey(tt.kid, tt.data, tt.algo)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, tt.wantAlg, k.Method.Alg())
+				assert.Equal(t, tt.wantSign, k.CanSign())
+			}
+		})
+	}
+}
+
+func TestNewKeyset(t *testing.T) {
+	_, ecKey, _ := testKeys(t)
+	priv, err := jwt.ParseKey("new", pemKey(t, ecKey, false), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := jwt.ParseKey("old", pemKey(t, ecKey, true), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = jwt.NewKeyset("", pub)
+	assert.NotNil(t, err, "keyset without private key")
+	_, err = jwt.NewKeyset("old", priv, pub)
+	assert.NotNil(t, err, "signing key without private part")
+	_, err = jwt.NewKeyset("", priv, priv)
+	assert.NotNil(t, err, "duplicate kid")
+	_, err = jwt.NewKeyset("", pub, priv)
+	assert.Nil(t, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, ecKey, edKey := testKeys(t)
+	parse := func(kid string, priv interface{}, public bool) *jwt.Key {
+		k, err := jwt.ParseKey(kid, pemKey(t, priv, public), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	keyset := func(signing string, keys ...*jwt.Key) *jwt.Service {
+		ks, err := jwt.NewKeyset(signing, keys...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jwt.NewWithKeyset(ks, &jwt.Config{Duration: 60}, nil)
+	}
+	usr := &gorsk.User{Base: gorsk.Base{ID: 1}, Username: "johndoe", Role: &gorsk.Role{AccessLevel: gorsk.UserRole}}
+	token := func(j *jwt.Service) string {
+		str, _, err := j.GenerateToken(usr, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + str
+	}
+
+	oldService := keyset("", parse("2018", rsaKey, false))
+	edService := keyset("", parse("ed", edKey, false))
+	newService := keyset("2019", parse("2018", rsaKey, true), parse("2019", ecKey, false), parse("ed", edKey, true))
+	hmacService := jwt.New("jwtsecret", "HS256", 60, nil)
+	afterRotation := keyset("", parse("2019", ecKey, false))
+
+	cases := []struct {
+		name       string
+		service    *jwt.Service
+		header     string
+		wantStatus int
+	}{
+		{
+			name:       "Token signed with current key",
+			service:    newService,
+			header:     token(newService),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Token signed with rotated key",
+			service:    newService,
+			header:     token(oldService),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "EdDSA token",
+			service:    newService,
+			header:     token(edService),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Rotated key removed",
+			service:    afterRotation,
+			header:     token(oldService),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Token without kid",
+			service:    newService,
+			header:     token(hmacService),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Token with kid on HMAC service",
+			service:    hmacService,
+			header:     token(newService),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(echoHandler(tt.service.MWFunc()))
+			defer ts.Close()
+			req, _ := http.NewRequest("GET", ts.URL+"/hello", nil)
+			req.Header.Set("Authorization", tt.header)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal("Cannot create http request")
+			}
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, ecKey, edKey := testKeys(t)
+	var keys []*jwt.Key
+	for _, k := range []struct {
+		kid  string
+		priv interface{}
+	}{{"rsa", rsaKey}, {"ec", ecKey}, {"ed", edKey}} {
+		key, err := jwt.ParseKey(k.kid, pemKey(t, k.priv, false), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	ks, err := jwt.NewKeyset("", keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := ks.JWKS()
+	if assert.Len(t, set.Keys, 3) {
+		assert.Equal(t, gorsk.JWK{KeyType: "RSA", KeyID: "rsa", Use: "sig", Algorithm: "RS256", N: set.Keys[0].N, E: "AQAB"}, set.Keys[0])
+		assert.Len(t, set.Keys[0].N, 342)
+		assert.Equal(t, "EC", set.Keys[1].KeyType)
+		assert.Equal(t, "P-256", set.Keys[1].Curve)
+		assert.Len(t, set.Keys[1].X, 43)
+		assert.Len(t, set.Keys[1].Y, 43)
+		assert.Equal(t, gorsk.JWK{KeyType: "OKP", KeyID: "ed", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: set.Keys[2].X}, set.Keys[2])
+		assert.Len(t, set.Keys[2].X, 43)
+	}
+
+	assert.Empty(t, jwt.New("jwtsecret", "HS256", 60, nil).JWKS().Keys)
+}
@@ -1,6 +1,8 @@
 package secure
 
 import (
+	"sync/atomic"
+
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 )
@@ -25,14 +27,43 @@ func Headers() echo.MiddlewareFunc {
 	}
 }
 
-// CORS adds Cross-Origin Resource Sharing support
+// CORS adds Cross-Origin Resource Sharing support, allowing all origins
 func CORS() echo.MiddlewareFunc {
-	return middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"*"},
+	return NewCORS(nil).MWFunc()
+}
+
+// NewCORS creates Cross-Origin Resource Sharing middleware allowing given origins, or all if none are given
+func NewCORS(origins []string) *CORSService {
+	s := new(CORSService)
+	s.SetOrigins(origins)
+	return s
+}
+
+// CORSService provides Cross-Origin Resource Sharing support whose allowed origins can change at runtime
+type CORSService struct {
+	mw atomic.Value
+}
+
+// SetOrigins replaces allowed origins, safe for concurrent use with requests being served
+func (s *CORSService) SetOrigins(origins []string) {
+	if len(origins) == 0 {
+		origins = []string{"*"}
+	}
+	s.mw.Store(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins:     origins,
 		MaxAge:           86400,
 		AllowMethods:     []string{"POST", "GET", "PUT", "DELETE", "PATCH", "HEAD"},
 		AllowHeaders:     []string{"*"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
-	})
+	}))
+}
+
+// MWFunc returns CORS middleware using currently allowed origins
+func (s *CORSService) MWFunc() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return s.mw.Load().(echo.MiddlewareFunc)(next)(c)
+		}
+	}
 }
@@ -52,3 +52,27 @@ func TestCORS(t *testing.T) {
 	// assert.Equal(t, "Content-Length", resp.Header.Get("Access-Control-Expose-Headers"))
 	// assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
 }
+
+func TestCORSOrigins(t *testing.T) {
+	cors := secure.NewCORS([]string{"https://example.com"})
+	ts := httptest.NewServer(echoHandler(cors.MWFunc()))
+	defer ts.Close()
+
+	origin := func(o string) string {
+		req, _ := http.NewRequest("OPTIONS", ts.URL+"/hello", nil)
+		req.Header.Set("Origin", o)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Cannot create http request")
+		}
+		defer resp.Body.Close()
+		return resp.Header.Get("Access-Control-Allow-Origin")
+	}
+
+	assert.Equal(t, "https://example.com", origin("https://example.com"))
+	assert.Equal(t, "", origin("https://other.com"))
+
+	cors.SetOrigins([]string{"https://other.com"})
+	assert.Equal(t, "", origin("https://example.com"))
+	assert.Equal(t, "https://other.com", origin("https://other.com"))
+}
@@ -0,0 +1,328 @@
+// Package migrate provides versioned database schema migrations
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// lockKey identifies the advisory lock held while migrating
+const lockKey int64 = 7279411362417045
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version bigint PRIMARY KEY,
+	name text NOT NULL,
+	checksum text NOT NULL,
+	applied_at timestamptz NOT NULL
+)`
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration represents a single versioned schema change.
+// SQL migrations are loaded from files, Go migrations set Up and Down functions instead.
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+	Up      func(orm.DB) error
+	Down    func(orm.DB) error
+	// Revision stands in for the code of Up and Down, which can't be hashed.
+	// Bump it whenever a Go migration changes, so applied ones are detected as modified.
+	Revision int
+}
+
+// Checksum returns hash of migration's SQL, used for detecting migrations changed after being applied.
+// Go migrations are hashed by name and revision instead.
+func (m *Migration) Checksum() string {
+	h := sha256.New()
+	h.Write([]byte(m.UpSQL))
+	h.Write([]byte{0})
+	h.Write([]byte(m.DownSQL))
+	if m.Up != nil || m.Down != nil {
+		fmt.Fprintf(h, "\x00go:%s:%d", m.Name, m.Revision)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+func (m *Migration) reversible() bool {
+	return m.Down != nil || strings.TrimSpace(m.DownSQL) != ""
+}
+
+// Load reads SQL migrations from dir.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql, down files being optional.
+func Load(dir string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(f.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: invalid migration file name %q", f.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: migration %d has files with different names", version)
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(data)
+		} else {
+			m.DownSQL = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migrate: migration %s has no up file", m)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status represents migration state in the database
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set if migration changed after it was applied
+	Modified bool
+	// Missing is set if applied migration is not known anymore
+	Missing bool
+}
+
+// record represents a row in schema_migrations table
+type record struct {
+	tableName struct{} `sql:"schema_migrations"`
+
+	Version   int64 `sql:",pk"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// ErrNoChange is returned when there is no migration to revert
+var ErrNoChange = errors.New("migrate: no migration to revert")
+
+// New creates new migrator, applying migrations in version order
+func New(db *pg.DB, migrations ...*Migration) (*Migrator, error) {
+	ms := make([]*Migration, len(migrations))
+	copy(ms, migrations)
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Version < ms[j].Version })
+
+	for i, m := range ms {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migrate: migration %q has invalid version %d", m.Name, m.Version)
+		}
+		if i > 0 && ms[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migrate: duplicate migration version %d", m.Version)
+		}
+		if m.Up == nil && strings.TrimSpace(m.UpSQL) == "" {
+			return nil, fmt.Errorf("migrate: migration %s has nothing to apply", m)
+		}
+	}
+	return &Migrator{db: db, migrations: ms}, nil
+}
+
+// Migrator applies and reverts migrations.
+// Every migration runs in its own transaction holding an advisory lock, so concurrent migrators wait for each other.
+type Migrator struct {
+	db         *pg.DB
+	migrations []*Migration
+}
+
+// Up applies all pending migrations, returning the ones applied
+func (m *Migrator) Up() ([]*Migration, error) {
+	var done []*Migration
+	for {
+		var next *Migration
+		err := m.locked(true, func(tx *pg.Tx, applied map[int64]*record) error {
+			for _, mig := range m.migrations {
+				if _, ok := applied[mig.Version]; !ok {
+					next = mig
+					return m.apply(tx, mig)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		if next == nil {
+			return done, nil
+		}
+		done = append(done, next)
+	}
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down() (*Migration, error) {
+	var mig *Migration
+	err := m.locked(true, func(tx *pg.Tx, applied map[int64]*record) error {
+		var err error
+		if mig, err = m.last(applied); err != nil {
+			return err
+		}
+		return m.revert(tx, mig)
+	})
+	return mig, err
+}
+
+// Redo reverts and applies again the last applied migration.
+// Changed migrations are allowed, so the one being worked on can be refreshed.
+func (m *Migrator) Redo() (*Migration, error) {
+	var mig *Migration
+	err := m.locked(false, func(tx *pg.Tx, applied map[int64]*record) error {
+		var err error
+		if mig, err = m.last(applied); err != nil {
+			return err
+		}
+		if err = m.revert(tx, mig); err != nil {
+			return err
+		}
+		return m.apply(tx, mig)
+	})
+	return mig, err
+}
+
+// Status returns state of known and applied migrations, ordered by version
+func (m *Migrator) Status() ([]Status, error) {
+	var list []Status
+	err := m.locked(false, func(tx *pg.Tx, applied map[int64]*record) error {
+		for _, mig := range m.migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if r, ok := applied[mig.Version]; ok {
+				s.Applied = true
+				s.AppliedAt = r.AppliedAt
+				s.Modified = r.Checksum != mig.Checksum()
+				delete(applied, mig.Version)
+			}
+			list = append(list, s)
+		}
+		for _, r := range applied {
+			list = append(list, Status{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt, Missing: true})
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, err
+}
+
+// locked runs fn in a transaction holding the migration lock.
+// If verify is set, applied migrations must not have changed since.
+func (m *Migrator) locked(verify bool, fn func(*pg.Tx, map[int64]*record) error) error {
+	return m.db.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(createTable); err != nil {
+			return err
+		}
+
+		var records []*record
+		if err := tx.Model(&records).Select(); err != nil {
+			return err
+		}
+		applied := make(map[int64]*record, len(records))
+		for _, r := range records {
+			applied[r.Version] = r
+		}
+
+		if verify {
+			for _, mig := range m.migrations {
+				if r, ok := applied[mig.Version]; ok && r.Checksum != mig.Checksum() {
+					return fmt.Errorf("migrate: migration %s changed after it was applied", mig)
+				}
+			}
+		}
+		return fn(tx, applied)
+	})
+}
+
+// last returns the applied migration with the highest version
+func (m *Migrator) last(applied map[int64]*record) (*Migration, error) {
+	var top *record
+	for _, r := range applied {
+		if top == nil || r.Version > top.Version {
+			top = r
+		}
+	}
+	if top == nil {
+		return nil, ErrNoChange
+	}
+	for _, mig := range m.migrations {
+		if mig.Version == top.Version {
+			return mig, nil
+		}
+	}
+	return nil, fmt.Errorf("migrate: applied migration %04d_%s is unknown", top.Version, top.Name)
+}
+
+func (m *Migrator) apply(tx *pg.Tx, mig *Migration) error {
+	if err := run(tx, mig.UpSQL, mig.Up); err != nil {
+		return fmt.Errorf("migrate: applying %s: %v", mig, err)
+	}
+	return tx.Insert(&record{
+		Version:   mig.Version,
+		Name:      mig.Name,
+		Checksum:  mig.Checksum(),
+		AppliedAt: time.Now(),
+	})
+}
+
+func (m *Migrator) revert(tx *pg.Tx, mig *Migration) error {
+	if !mig.reversible() {
+		return fmt.Errorf("migrate: migration %s cannot be reverted", mig)
+	}
+	if err := run(tx, mig.DownSQL, mig.Down); err != nil {
+		return fmt.Errorf("migrate: reverting %s: %v", mig, err)
+	}
+	_, err := tx.Model(&record{Version: mig.Version}).WherePK().Delete()
+	return err
+}
+
+func run(tx *pg.Tx, sql string, fn func(orm.DB) error) error {
+	if fn != nil {
+		return fn(tx)
+	}
+	_, err := tx.Exec(sql)
+	return err
+}
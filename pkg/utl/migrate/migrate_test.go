@@ -0,0 +1,169 @@
+package migrate_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/migrate"
+	"github.com/figassis/goduck/pkg/utl/mock"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		dir      string
+		wantData []string
+		wantErr  bool
+	}{
+		{
+			name:    "Fail on non-existing dir",
+			dir:     "testdata/notExists",
+			wantErr: true,
+		},
+		{
+			name:    "Fail on invalid file name",
+			dir:     "testdata/invalid",
+			wantErr: true,
+		},
+		{
+			name:    "Fail on missing up file",
+			dir:     "testdata/noup",
+			wantErr: true,
+		},
+		{
+			name:     "Success",
+			dir:      "testdata/valid",
+			wantData: []string{"0001_create_items", "0002_seed_items"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := migrate.Load(tt.dir)
+			assert.Equal(t, tt.wantErr, err != nil)
+			var names []string
+			for _, m := range ms {
+				names = append(names, m.String())
+			}
+			assert.Equal(t, tt.wantData, names)
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	m := &migrate.Migration{Version: 1, Name: "a", UpSQL: "SELECT 1", DownSQL: "SELECT 2"}
+	assert.Equal(t, m.Checksum(), (&migrate.Migration{Version: 2, Name: "b", UpSQL: "SELECT 1", DownSQL: "SELECT 2"}).Checksum())
+	assert.NotEqual(t, m.Checksum(), (&migrate.Migration{UpSQL: "SELECT 1", DownSQL: "SELECT 3"}).Checksum())
+	assert.NotEqual(t, m.Checksum(), (&migrate.Migration{UpSQL: "SELECT 1SELECT 2"}).Checksum())
+
+	up := func(orm.DB) error { return nil }
+	g := &migrate.Migration{Version: 3, Name: "seed", Up: up}
+	assert.Equal(t, g.Checksum(), (&migrate.Migration{Version: 3, Name: "seed", Up: up}).Checksum())
+	assert.NotEqual(t, g.Checksum(), (&migrate.Migration{Name: "seed"}).Checksum())
+	assert.NotEqual(t, g.Checksum(), (&migrate.Migration{Name: "seed", Up: up, Revision: 1}).Checksum())
+	assert.NotEqual(t, g.Checksum(), (&migrate.Migration{Name: "seed_admin", Up: up}).Checksum())
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name       string
+		migrations []*migrate.Migration
+		wantErr    bool
+	}{
+		{
+			name:       "Fail on invalid version",
+			migrations: []*migrate.Migration{{Name: "a", UpSQL: "SELECT 1"}},
+			wantErr:    true,
+		},
+		{
+			name: "Fail on duplicate version",
+			migrations: []*migrate.Migration{
+				{Version: 1, Name: "a", UpSQL: "SELECT 1"},
+				{Version: 1, Name: "b", UpSQL: "SELECT 1"},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "Fail on empty migration",
+			migrations: []*migrate.Migration{{Version: 1, Name: "a"}},
+			wantErr:    true,
+		},
+		{
+			name: "Success",
+			migrations: []*migrate.Migration{
+				{Version: 2, Name: "b", Up: func(orm.DB) error { return nil }},
+				{Version: 1, Name: "a", UpSQL: "SELECT 1"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := migrate.New(nil, tt.migrations...)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestPG(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon)
+
+	ms, err := migrate.Load("testdata/valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.New(db, ms...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = m.Down()
+	assert.Equal(t, migrate.ErrNoChange, err)
+
+	applied, err := m.Up()
+	assert.Nil(t, err)
+	assert.Len(t, applied, 2)
+
+	applied, err = m.Up()
+	assert.Nil(t, err)
+	assert.Len(t, applied, 0)
+
+	var count int
+	_, err = db.QueryOne(&count, "SELECT count(*) FROM items")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = m.Down()
+	assert.NotNil(t, err, "Migration without down file cannot be reverted")
+
+	mig, err := m.Redo()
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(2), mig.Version)
+
+	up := ms[1].UpSQL
+	ms[1].UpSQL = "SELECT 1"
+	_, err = m.Up()
+	assert.NotNil(t, err, "Changed migration must be detected")
+
+	status, err := m.Status()
+	assert.Nil(t, err)
+	assert.Len(t, status, 2)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[0].Modified)
+	assert.True(t, status[1].Modified)
+	ms[1].UpSQL = up
+
+	m, err = migrate.New(db, ms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = m.Status()
+	assert.Nil(t, err)
+	assert.True(t, status[1].Missing)
+
+	_, err = m.Down()
+	assert.NotNil(t, err, "Unknown applied migration cannot be reverted")
+}
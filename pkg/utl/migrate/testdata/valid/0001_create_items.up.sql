@@ -0,0 +1,2 @@
+CREATE TABLE items (id bigserial PRIMARY KEY, name text);
+CREATE INDEX items_name_idx ON items (name);
@@ -0,0 +1 @@
+INSERT INTO items (name) VALUES ('first') ON CONFLICT DO NOTHING;
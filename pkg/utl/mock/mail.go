@@ -0,0 +1,15 @@
+package mock
+
+import (
+	"github.com/figassis/goduck/pkg/utl/mail"
+)
+
+// Mailer mock
+type Mailer struct {
+	SendFn func(mail.Message) error
+}
+
+// Send mock
+func (m *Mailer) Send(msg mail.Message) error {
+	return m.SendFn(msg)
+}
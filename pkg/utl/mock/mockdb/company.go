@@ -0,0 +1,40 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Company database mock
+type Company struct {
+	CreateFn func(orm.DB, gorsk.Company) (*gorsk.Company, error)
+	ViewFn   func(orm.DB, int) (*gorsk.Company, error)
+	ListFn   func(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Company, error)
+	DeleteFn func(orm.DB, *gorsk.Company) error
+	UpdateFn func(orm.DB, *gorsk.Company) error
+}
+
+// Create mock
+func (c *Company) Create(db orm.DB, cmp gorsk.Company) (*gorsk.Company, error) {
+	return c.CreateFn(db, cmp)
+}
+
+// View mock
+func (c *Company) View(db orm.DB, id int) (*gorsk.Company, error) {
+	return c.ViewFn(db, id)
+}
+
+// List mock
+func (c *Company) List(db orm.DB, lq *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Company, error) {
+	return c.ListFn(db, lq, p)
+}
+
+// Delete mock
+func (c *Company) Delete(db orm.DB, cmp *gorsk.Company) error {
+	return c.DeleteFn(db, cmp)
+}
+
+// Update mock
+func (c *Company) Update(db orm.DB, cmp *gorsk.Company) error {
+	return c.UpdateFn(db, cmp)
+}
@@ -0,0 +1,31 @@
+package mockdb
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/orm"
+)
+
+// DB database mock, for services whose repositories are mocked.
+// Transactions run directly, as the mocked repositories ignore the database they get.
+type DB struct{}
+
+// WithContext mock
+func (DB) WithContext(context.Context) orm.DB {
+	return Tx{}
+}
+
+// RunInTransaction mock
+func (DB) RunInTransaction(_ context.Context, fn func(orm.DB) error) error {
+	return fn(Tx{})
+}
+
+// Tx transaction mock, ignoring statements executed directly on it, like savepoints
+type Tx struct {
+	orm.DB
+}
+
+// Exec mock
+func (Tx) Exec(interface{}, ...interface{}) (orm.Result, error) {
+	return nil, nil
+}
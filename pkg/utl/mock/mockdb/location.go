@@ -0,0 +1,40 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Location database mock
+type Location struct {
+	CreateFn func(orm.DB, gorsk.Location) (*gorsk.Location, error)
+	ViewFn   func(orm.DB, int) (*gorsk.Location, error)
+	ListFn   func(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Location, error)
+	DeleteFn func(orm.DB, *gorsk.Location) error
+	UpdateFn func(orm.DB, *gorsk.Location) error
+}
+
+// Create mock
+func (l *Location) Create(db orm.DB, loc gorsk.Location) (*gorsk.Location, error) {
+	return l.CreateFn(db, loc)
+}
+
+// View mock
+func (l *Location) View(db orm.DB, id int) (*gorsk.Location, error) {
+	return l.ViewFn(db, id)
+}
+
+// List mock
+func (l *Location) List(db orm.DB, lq *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Location, error) {
+	return l.ListFn(db, lq, p)
+}
+
+// Delete mock
+func (l *Location) Delete(db orm.DB, loc *gorsk.Location) error {
+	return l.DeleteFn(db, loc)
+}
+
+// Update mock
+func (l *Location) Update(db orm.DB, loc *gorsk.Location) error {
+	return l.UpdateFn(db, loc)
+}
@@ -0,0 +1,46 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// MFA database mock
+type MFA struct {
+	CreateChallengeFn      func(orm.DB, gorsk.LoginChallenge) (*gorsk.LoginChallenge, error)
+	FindChallengeFn        func(orm.DB, string) (*gorsk.LoginChallenge, error)
+	UpdateChallengeFn      func(orm.DB, *gorsk.LoginChallenge) error
+	DeleteChallengeFn      func(orm.DB, *gorsk.LoginChallenge) error
+	ReplaceRecoveryCodesFn func(orm.DB, int, []string) error
+	UseRecoveryCodeFn      func(orm.DB, int, string) error
+}
+
+// CreateChallenge mock
+func (m *MFA) CreateChallenge(db orm.DB, ch gorsk.LoginChallenge) (*gorsk.LoginChallenge, error) {
+	return m.CreateChallengeFn(db, ch)
+}
+
+// FindChallenge mock
+func (m *MFA) FindChallenge(db orm.DB, hash string) (*gorsk.LoginChallenge, error) {
+	return m.FindChallengeFn(db, hash)
+}
+
+// UpdateChallenge mock
+func (m *MFA) UpdateChallenge(db orm.DB, ch *gorsk.LoginChallenge) error {
+	return m.UpdateChallengeFn(db, ch)
+}
+
+// DeleteChallenge mock
+func (m *MFA) DeleteChallenge(db orm.DB, ch *gorsk.LoginChallenge) error {
+	return m.DeleteChallengeFn(db, ch)
+}
+
+// ReplaceRecoveryCodes mock
+func (m *MFA) ReplaceRecoveryCodes(db orm.DB, userID int, hashes []string) error {
+	return m.ReplaceRecoveryCodesFn(db, userID, hashes)
+}
+
+// UseRecoveryCode mock
+func (m *MFA) UseRecoveryCode(db orm.DB, userID int, hash string) error {
+	return m.UseRecoveryCodeFn(db, userID, hash)
+}
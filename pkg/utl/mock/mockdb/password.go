@@ -0,0 +1,28 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// PasswordReset database mock
+type PasswordReset struct {
+	CreateFn      func(orm.DB, gorsk.PasswordReset) (*gorsk.PasswordReset, error)
+	FindByTokenFn func(orm.DB, string) (*gorsk.PasswordReset, error)
+	UseFn         func(orm.DB, *gorsk.PasswordReset) error
+}
+
+// Create mock
+func (r *PasswordReset) Create(db orm.DB, pr gorsk.PasswordReset) (*gorsk.PasswordReset, error) {
+	return r.CreateFn(db, pr)
+}
+
+// FindByToken mock
+func (r *PasswordReset) FindByToken(db orm.DB, hash string) (*gorsk.PasswordReset, error) {
+	return r.FindByTokenFn(db, hash)
+}
+
+// Use mock
+func (r *PasswordReset) Use(db orm.DB, pr *gorsk.PasswordReset) error {
+	return r.UseFn(db, pr)
+}
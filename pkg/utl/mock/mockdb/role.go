@@ -0,0 +1,40 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Role database mock
+type Role struct {
+	CreateFn func(orm.DB, gorsk.Role) (*gorsk.Role, error)
+	ViewFn   func(orm.DB, gorsk.AccessRole) (*gorsk.Role, error)
+	ListFn   func(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.Role, error)
+	DeleteFn func(orm.DB, *gorsk.Role) error
+	UpdateFn func(orm.DB, *gorsk.Role) error
+}
+
+// Create mock
+func (r *Role) Create(db orm.DB, role gorsk.Role) (*gorsk.Role, error) {
+	return r.CreateFn(db, role)
+}
+
+// View mock
+func (r *Role) View(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+	return r.ViewFn(db, id)
+}
+
+// List mock
+func (r *Role) List(db orm.DB, lq *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.Role, error) {
+	return r.ListFn(db, lq, p)
+}
+
+// Delete mock
+func (r *Role) Delete(db orm.DB, role *gorsk.Role) error {
+	return r.DeleteFn(db, role)
+}
+
+// Update mock
+func (r *Role) Update(db orm.DB, role *gorsk.Role) error {
+	return r.UpdateFn(db, role)
+}
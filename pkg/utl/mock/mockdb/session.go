@@ -0,0 +1,58 @@
+package mockdb
+
+import (
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Session database mock
+type Session struct {
+	CreateFn       func(orm.DB, gorsk.Session) (*gorsk.Session, error)
+	ViewFn         func(orm.DB, int) (*gorsk.Session, error)
+	FindByTokenFn  func(orm.DB, string) (*gorsk.Session, error)
+	ListFn         func(orm.DB, int) ([]gorsk.Session, error)
+	RotateFn       func(orm.DB, *gorsk.Session, string) error
+	FindRetiredFn  func(orm.DB, string) (int, error)
+	DeleteFn       func(orm.DB, *gorsk.Session) error
+	DeleteByUserFn func(orm.DB, int) error
+}
+
+// Create mock
+func (s *Session) Create(db orm.DB, sess gorsk.Session) (*gorsk.Session, error) {
+	return s.CreateFn(db, sess)
+}
+
+// View mock
+func (s *Session) View(db orm.DB, id int) (*gorsk.Session, error) {
+	return s.ViewFn(db, id)
+}
+
+// FindByToken mock
+func (s *Session) FindByToken(db orm.DB, hash string) (*gorsk.Session, error) {
+	return s.FindByTokenFn(db, hash)
+}
+
+// List mock
+func (s *Session) List(db orm.DB, userID int) ([]gorsk.Session, error) {
+	return s.ListFn(db, userID)
+}
+
+// Rotate mock
+func (s *Session) Rotate(db orm.DB, sess *gorsk.Session, oldHash string) error {
+	return s.RotateFn(db, sess, oldHash)
+}
+
+// FindRetired mock
+func (s *Session) FindRetired(db orm.DB, hash string) (int, error) {
+	return s.FindRetiredFn(db, hash)
+}
+
+// Delete mock
+func (s *Session) Delete(db orm.DB, sess *gorsk.Session) error {
+	return s.DeleteFn(db, sess)
+}
+
+// DeleteByUser mock
+func (s *Session) DeleteByUser(db orm.DB, userID int) error {
+	return s.DeleteByUserFn(db, userID)
+}
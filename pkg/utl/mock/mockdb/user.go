@@ -10,10 +10,21 @@ type User struct {
 	CreateFn         func(orm.DB, gorsk.User) (*gorsk.User, error)
 	ViewFn           func(orm.DB, int) (*gorsk.User, error)
 	FindByUsernameFn func(orm.DB, string) (*gorsk.User, error)
-	FindByTokenFn    func(orm.DB, string) (*gorsk.User, error)
-	ListFn           func(orm.DB, *gorsk.ListQuery, *gorsk.Pagination) ([]gorsk.User, error)
+	FindByEmailFn    func(orm.DB, string) (*gorsk.User, error)
+	ListFn           func(orm.DB, *gorsk.ListQuery, *gorsk.UserFilter, *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error)
+	ExportFn         func(orm.DB, *gorsk.ListQuery, *gorsk.UserFilter, func(*gorsk.User) error) error
 	DeleteFn         func(orm.DB, *gorsk.User) error
 	UpdateFn         func(orm.DB, *gorsk.User) error
+	ViewRoleFn       func(orm.DB, gorsk.AccessRole) (*gorsk.Role, error)
+
+	UpdateEmailFn             func(orm.DB, *gorsk.User) error
+	CreateEmailVerificationFn func(orm.DB, gorsk.EmailVerification) (*gorsk.EmailVerification, error)
+	FindEmailVerificationFn   func(orm.DB, string) (*gorsk.EmailVerification, error)
+	UseEmailVerificationFn    func(orm.DB, *gorsk.EmailVerification) error
+
+	CreateCompanyFn      func(orm.DB, gorsk.Company) (*gorsk.Company, error)
+	CreateLocationFn     func(orm.DB, gorsk.Location) (*gorsk.Location, error)
+	UpdateCompanyOwnerFn func(orm.DB, *gorsk.Company) error
 }
 
 // Create mock
@@ -31,14 +42,19 @@ func (u *User) FindByUsername(db orm.DB, uname string) (*gorsk.User, error) {
 	return u.FindByUsernameFn(db, uname)
 }
 
-// FindByToken mock
-func (u *User) FindByToken(db orm.DB, token string) (*gorsk.User, error) {
-	return u.FindByTokenFn(db, token)
+// FindByEmail mock
+func (u *User) FindByEmail(db orm.DB, email string) (*gorsk.User, error) {
+	return u.FindByEmailFn(db, email)
 }
 
 // List mock
-func (u *User) List(db orm.DB, lq *gorsk.ListQuery, p *gorsk.Pagination) ([]gorsk.User, error) {
-	return u.ListFn(db, lq, p)
+func (u *User) List(db orm.DB, lq *gorsk.ListQuery, f *gorsk.UserFilter, p *gorsk.Pagination) ([]gorsk.User, *gorsk.Page, error) {
+	return u.ListFn(db, lq, f, p)
+}
+
+// Export mock
+func (u *User) Export(db orm.DB, lq *gorsk.ListQuery, f *gorsk.UserFilter, fn func(*gorsk.User) error) error {
+	return u.ExportFn(db, lq, f, fn)
 }
 
 // Delete mock
@@ -50,3 +66,43 @@ func (u *User) Delete(db orm.DB, usr *gorsk.User) error {
 func (u *User) Update(db orm.DB, usr *gorsk.User) error {
 	return u.UpdateFn(db, usr)
 }
+
+// ViewRole mock
+func (u *User) ViewRole(db orm.DB, id gorsk.AccessRole) (*gorsk.Role, error) {
+	return u.ViewRoleFn(db, id)
+}
+
+// UpdateEmail mock
+func (u *User) UpdateEmail(db orm.DB, usr *gorsk.User) error {
+	return u.UpdateEmailFn(db, usr)
+}
+
+// CreateEmailVerification mock
+func (u *User) CreateEmailVerification(db orm.DB, ev gorsk.EmailVerification) (*gorsk.EmailVerification, error) {
+	return u.CreateEmailVerificationFn(db, ev)
+}
+
+// FindEmailVerification mock
+func (u *User) FindEmailVerification(db orm.DB, hash string) (*gorsk.EmailVerification, error) {
+	return u.FindEmailVerificationFn(db, hash)
+}
+
+// UseEmailVerification mock
+func (u *User) UseEmailVerification(db orm.DB, ev *gorsk.EmailVerification) error {
+	return u.UseEmailVerificationFn(db, ev)
+}
+
+// CreateCompany mock
+func (u *User) CreateCompany(db orm.DB, cmp gorsk.Company) (*gorsk.Company, error) {
+	return u.CreateCompanyFn(db, cmp)
+}
+
+// CreateLocation mock
+func (u *User) CreateLocation(db orm.DB, loc gorsk.Location) (*gorsk.Location, error) {
+	return u.CreateLocationFn(db, loc)
+}
+
+// UpdateCompanyOwner mock
+func (u *User) UpdateCompanyOwner(db orm.DB, cmp *gorsk.Company) error {
+	return u.UpdateCompanyOwnerFn(db, cmp)
+}
@@ -1,15 +1,44 @@
 package mock
 
 import (
+	"context"
+
 	"github.com/figassis/goduck/pkg/utl/model"
+
+	"github.com/labstack/echo"
 )
 
 // JWT mock
 type JWT struct {
-	GenerateTokenFn func(*gorsk.User) (string, string, error)
+	GenerateTokenFn func(*gorsk.User, int) (string, string, error)
+	RevokeFn        func(context.Context) error
+	RevokeUserFn    func(context.Context, int) error
+	JWKSFn          func() *gorsk.JWKS
 }
 
 // GenerateToken mock
-func (j *JWT) GenerateToken(u *gorsk.User) (string, string, error) {
-	return j.GenerateTokenFn(u)
+func (j *JWT) GenerateToken(u *gorsk.User, sessionID int) (string, string, error) {
+	return j.GenerateTokenFn(u, sessionID)
+}
+
+// Revoke mock
+func (j *JWT) Revoke(ctx context.Context) error {
+	return j.RevokeFn(ctx)
+}
+
+// RevokeUser mock
+func (j *JWT) RevokeUser(ctx context.Context, id int) error {
+	return j.RevokeUserFn(ctx, id)
+}
+
+// JWKS mock
+func (j *JWT) JWKS() *gorsk.JWKS {
+	return j.JWKSFn()
+}
+
+// RequirePermission mock, allowing all requests
+func RequirePermission(...gorsk.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return next
+	}
 }
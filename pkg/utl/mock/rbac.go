@@ -1,52 +1,71 @@
 package mock
 
 import (
-	"github.com/labstack/echo"
+	"context"
+
 	"github.com/figassis/goduck/pkg/utl/model"
 )
 
 // RBAC Mock
 type RBAC struct {
-	UserFn            func(echo.Context) *gorsk.AuthUser
-	EnforceRoleFn     func(echo.Context, gorsk.AccessRole) error
-	EnforceUserFn     func(echo.Context, int) error
-	EnforceCompanyFn  func(echo.Context, int) error
-	EnforceLocationFn func(echo.Context, int) error
-	AccountCreateFn   func(echo.Context, gorsk.AccessRole, int, int) error
-	IsLowerRoleFn     func(echo.Context, gorsk.AccessRole) error
+	UserFn            func(context.Context) *gorsk.AuthUser
+	EnforceFn         func(context.Context, gorsk.Permission) error
+	EnforceRoleFn     func(context.Context, gorsk.AccessRole) error
+	EnforceUserFn     func(context.Context, gorsk.Permission, int) error
+	EnforceCompanyFn  func(context.Context, gorsk.Permission, int) error
+	EnforceLocationFn func(context.Context, gorsk.Permission, int) error
+	EnforceGrantFn    func(context.Context, gorsk.AccessRole, []gorsk.Permission) error
+	AccountCreateFn   func(context.Context, *gorsk.Role, int, int) error
+	IsLowerRoleFn     func(context.Context, gorsk.AccessRole) error
+	RefreshFn         func(context.Context) error
 }
 
 // User mock
-func (a *RBAC) User(c echo.Context) *gorsk.AuthUser {
-	return a.UserFn(c)
+func (a *RBAC) User(ctx context.Context) *gorsk.AuthUser {
+	return a.UserFn(ctx)
+}
+
+// Enforce mock
+func (a *RBAC) Enforce(ctx context.Context, p gorsk.Permission) error {
+	return a.EnforceFn(ctx, p)
 }
 
 // EnforceRole mock
-func (a *RBAC) EnforceRole(c echo.Context, role gorsk.AccessRole) error {
-	return a.EnforceRoleFn(c, role)
+func (a *RBAC) EnforceRole(ctx context.Context, role gorsk.AccessRole) error {
+	return a.EnforceRoleFn(ctx, role)
 }
 
 // EnforceUser mock
-func (a *RBAC) EnforceUser(c echo.Context, id int) error {
-	return a.EnforceUserFn(c, id)
+func (a *RBAC) EnforceUser(ctx context.Context, p gorsk.Permission, id int) error {
+	return a.EnforceUserFn(ctx, p, id)
 }
 
 // EnforceCompany mock
-func (a *RBAC) EnforceCompany(c echo.Context, id int) error {
-	return a.EnforceCompanyFn(c, id)
+func (a *RBAC) EnforceCompany(ctx context.Context, p gorsk.Permission, id int) error {
+	return a.EnforceCompanyFn(ctx, p, id)
 }
 
 // EnforceLocation mock
-func (a *RBAC) EnforceLocation(c echo.Context, id int) error {
-	return a.EnforceLocationFn(c, id)
+func (a *RBAC) EnforceLocation(ctx context.Context, p gorsk.Permission, id int) error {
+	return a.EnforceLocationFn(ctx, p, id)
+}
+
+// EnforceGrant mock
+func (a *RBAC) EnforceGrant(ctx context.Context, level gorsk.AccessRole, perms []gorsk.Permission) error {
+	return a.EnforceGrantFn(ctx, level, perms)
 }
 
 // AccountCreate mock
-func (a *RBAC) AccountCreate(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
-	return a.AccountCreateFn(c, roleID, companyID, locationID)
+func (a *RBAC) AccountCreate(ctx context.Context, role *gorsk.Role, companyID, locationID int) error {
+	return a.AccountCreateFn(ctx, role, companyID, locationID)
 }
 
 // IsLowerRole mock
-func (a *RBAC) IsLowerRole(c echo.Context, role gorsk.AccessRole) error {
-	return a.IsLowerRoleFn(c, role)
+func (a *RBAC) IsLowerRole(ctx context.Context, role gorsk.AccessRole) error {
+	return a.IsLowerRoleFn(ctx, role)
+}
+
+// Refresh mock
+func (a *RBAC) Refresh(ctx context.Context) error {
+	return a.RefreshFn(ctx)
 }
@@ -3,9 +3,12 @@ package mock
 // Secure mock
 type Secure struct {
 	PasswordFn            func(string, ...string) bool
-	HashFn                func(string) string
+	BreachedFn            func(string) (bool, error)
+	HashFn                func(string) (string, error)
 	HashMatchesPasswordFn func(string, string) bool
-	TokenFn               func(string) string
+	VerifyPasswordFn      func(string, string) (bool, bool)
+	RandomTokenFn         func(string) (string, error)
+	TokenHashFn           func(string) string
 }
 
 // Password mock
@@ -13,8 +16,13 @@ func (s *Secure) Password(pw string, inputs ...string) bool {
 	return s.PasswordFn(pw, inputs...)
 }
 
+// Breached mock
+func (s *Secure) Breached(pw string) (bool, error) {
+	return s.BreachedFn(pw)
+}
+
 // Hash mock
-func (s *Secure) Hash(pw string) string {
+func (s *Secure) Hash(pw string) (string, error) {
 	return s.HashFn(pw)
 }
 
@@ -23,7 +31,17 @@ func (s *Secure) HashMatchesPassword(hash, pw string) bool {
 	return s.HashMatchesPasswordFn(hash, pw)
 }
 
-// Token mock
-func (s *Secure) Token(token string) string {
-	return s.TokenFn(token)
+// VerifyPassword mock
+func (s *Secure) VerifyPassword(hash, pw string) (bool, bool) {
+	return s.VerifyPasswordFn(hash, pw)
+}
+
+// RandomToken mock
+func (s *Secure) RandomToken(prefix string) (string, error) {
+	return s.RandomTokenFn(prefix)
+}
+
+// TokenHash mock
+func (s *Secure) TokenHash(token string) string {
+	return s.TokenHashFn(token)
 }
@@ -1,29 +1,53 @@
 package gorsk
 
 import (
-	"github.com/labstack/echo"
+	"context"
 )
 
-// AuthToken holds authentication token details with refresh token
+// AuthToken holds authentication token details with refresh token.
+// RecoveryCodes are set only when MFA was enabled during login.
 type AuthToken struct {
+	Token         string   `json:"token"`
+	Expires       string   `json:"expires"`
+	RefreshToken  string   `json:"refresh_token"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// RefreshToken holds authentication token details with rotated refresh token
+type RefreshToken struct {
 	Token        string `json:"token"`
 	Expires      string `json:"expires"`
 	RefreshToken string `json:"refresh_token"`
 }
 
-// RefreshToken holds authentication token details
-type RefreshToken struct {
-	Token   string `json:"token"`
-	Expires string `json:"expires"`
+// JWKS represents JSON Web Key Set (RFC 7517) with public keys used for verifying tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK represents a public JSON Web Key
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
 }
 
 // RBACService represents role-based access control service interface
 type RBACService interface {
-	User(echo.Context) *AuthUser
-	EnforceRole(echo.Context, AccessRole) error
-	EnforceUser(echo.Context, int) error
-	EnforceCompany(echo.Context, int) error
-	EnforceLocation(echo.Context, int) error
-	AccountCreate(echo.Context, AccessRole, int, int) error
-	IsLowerRole(echo.Context, AccessRole) error
+	User(context.Context) *AuthUser
+	Enforce(context.Context, Permission) error
+	EnforceRole(context.Context, AccessRole) error
+	EnforceUser(context.Context, Permission, int) error
+	EnforceCompany(context.Context, Permission, int) error
+	EnforceLocation(context.Context, Permission, int) error
+	EnforceGrant(context.Context, AccessRole, []Permission) error
+	AccountCreate(context.Context, *Role, int, int) error
+	IsLowerRole(context.Context, AccessRole) error
+	Refresh(context.Context) error
 }
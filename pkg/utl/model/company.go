@@ -6,5 +6,6 @@ type Company struct {
 	Name      string     `json:"name"`
 	Active    bool       `json:"active"`
 	Locations []Location `json:"locations,omitempty"`
+	OwnerID   int        `json:"owner_id,omitempty"`
 	Owner     User       `json:"owner"`
 }
@@ -0,0 +1,38 @@
+package gorsk
+
+import "context"
+
+type contextKey int
+
+const (
+	userKey contextKey = iota
+	clientKey
+)
+
+// Client holds details of the client making the request
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// NewUserContext returns a copy of ctx carrying the authenticated user
+func NewUserContext(ctx context.Context, u *AuthUser) context.Context {
+	return context.WithValue(ctx, userKey, u)
+}
+
+// UserFromContext returns the authenticated user carried by ctx, or nil for anonymous requests
+func UserFromContext(ctx context.Context) *AuthUser {
+	u, _ := ctx.Value(userKey).(*AuthUser)
+	return u
+}
+
+// NewClientContext returns a copy of ctx carrying client details
+func NewClientContext(ctx context.Context, cl Client) context.Context {
+	return context.WithValue(ctx, clientKey, cl)
+}
+
+// ClientFromContext returns client details carried by ctx, empty if unknown
+func ClientFromContext(ctx context.Context) Client {
+	cl, _ := ctx.Value(clientKey).(Client)
+	return cl
+}
@@ -0,0 +1,21 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// EmailVerification represents a single-use token confirming that user controls the email address.
+// The address is user's current email, or the new one when changing it. Only the hash of the token is stored.
+type EmailVerification struct {
+	Base
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UsedAt    time.Time `json:"used_at,omitempty"`
+}
+
+// Valid checks whether the verification token is unused and not expired at the given time
+func (v *EmailVerification) Valid(now time.Time) bool {
+	return v.UsedAt.IsZero() && now.Before(v.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// LoginAttempt tracks failed login attempts for a username or client IP
+type LoginAttempt struct {
+	Key         string    `json:"key" sql:",pk"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Locked checks whether further login attempts are refused at the given time
+func (l *LoginAttempt) Locked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
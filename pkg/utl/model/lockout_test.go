@@ -0,0 +1,36 @@
+package gorsk_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+func TestLoginAttemptLocked(t *testing.T) {
+	cases := []struct {
+		name    string
+		attempt gorsk.LoginAttempt
+		want    bool
+	}{
+		{
+			name: "Never locked",
+		},
+		{
+			name:    "Lock expired",
+			attempt: gorsk.LoginAttempt{LockedUntil: mock.TestTime(2000)},
+		},
+		{
+			name:    "Locked",
+			attempt: gorsk.LoginAttempt{LockedUntil: mock.TestTime(2002)},
+			want:    true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.attempt.Locked(mock.TestTime(2001)); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
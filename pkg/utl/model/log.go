@@ -1,9 +1,9 @@
 package gorsk
 
-import "github.com/labstack/echo"
+import "context"
 
 // Logger represents logging interface
 type Logger interface {
 	// source, msg, error, params
-	Log(echo.Context, string, string, error, map[string]interface{})
+	Log(context.Context, string, string, error, map[string]interface{})
 }
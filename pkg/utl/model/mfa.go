@@ -0,0 +1,50 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// MFAChallenge is returned on login instead of AuthToken when user has to pass a second factor,
+// or to change expired password. The challenge token is good only for completing the challenge.
+type MFAChallenge struct {
+	MFARequired            bool   `json:"mfa_required"`
+	PasswordChangeRequired bool   `json:"password_change_required,omitempty"`
+	ChallengeToken         string `json:"challenge_token"`
+	Expires                string `json:"expires"`
+	EnrollmentRequired     bool   `json:"enrollment_required"`
+	// RecoveryCodes are returned if MFA was enrolled before the password change was required
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TOTPEnrollment holds data needed to add the account to an authenticator app
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// LoginChallenge represents a pending second login step, passed password check.
+// Only the hash of the challenge token is stored.
+type LoginChallenge struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	TokenHash string    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// PasswordChange challenges are completed by changing expired password, others by MFA code
+	PasswordChange bool `json:"password_change"`
+}
+
+// Expired checks whether the challenge has expired at the given time
+func (l *LoginChallenge) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// RecoveryCode represents a hashed single-use code, replacing the TOTP code when the authenticator is lost
+type RecoveryCode struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	CodeHash  string    `json:"-"`
+	UsedAt    time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
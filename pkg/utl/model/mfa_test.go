@@ -0,0 +1,18 @@
+package gorsk_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+func TestLoginChallengeExpired(t *testing.T) {
+	ch := &gorsk.LoginChallenge{ExpiresAt: mock.TestTime(2001)}
+	if ch.Expired(mock.TestTime(2000)) {
+		t.Errorf("Challenge should not be expired")
+	}
+	if !ch.Expired(mock.TestTime(2002)) {
+		t.Errorf("Challenge should be expired")
+	}
+}
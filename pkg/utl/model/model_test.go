@@ -3,6 +3,8 @@ package gorsk_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/figassis/goduck/pkg/utl/mock"
 	gorsk "github.com/figassis/goduck/pkg/utl/model"
 )
@@ -55,3 +57,19 @@ func TestPaginationTransform(t *testing.T) {
 	}
 
 }
+
+func TestParseSort(t *testing.T) {
+	sort, err := gorsk.ParseSort("-last_login,last_name", gorsk.UserSortFields...)
+	assert.Nil(t, err)
+	assert.Equal(t, []gorsk.Sort{{Field: "last_login", Desc: true}, {Field: "last_name"}}, sort)
+
+	sort, err = gorsk.ParseSort("", gorsk.UserSortFields...)
+	assert.Nil(t, err)
+	assert.Nil(t, sort)
+
+	_, err = gorsk.ParseSort("last_name,password", gorsk.UserSortFields...)
+	assert.Equal(t, gorsk.ErrBadRequest, err)
+
+	_, err = gorsk.ParseSort("last_name,", gorsk.UserSortFields...)
+	assert.Equal(t, gorsk.ErrBadRequest, err)
+}
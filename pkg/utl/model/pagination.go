@@ -1,15 +1,19 @@
 package gorsk
 
+import "strings"
+
 // Pagination constants
 const (
 	paginationDefaultLimit = 100
 	paginationMaxLimit     = 1000
 )
 
-// PaginationReq holds pagination http fields and tags
+// PaginationReq holds pagination http fields and tags.
+// Cursor, when set, takes precedence over Page.
 type PaginationReq struct {
-	Limit int `query:"limit"`
-	Page  int `query:"page" validate:"min=0"`
+	Limit  int    `query:"limit"`
+	Page   int    `query:"page" validate:"min=0"`
+	Cursor string `query:"cursor"`
 }
 
 // Transform checks and converts http pagination into database pagination model
@@ -29,4 +33,57 @@ func (p *PaginationReq) Transform() *Pagination {
 type Pagination struct {
 	Limit  int `json:"limit,omitempty"`
 	Offset int `json:"offset,omitempty"`
+	// Cursor selects the page next to a row, replacing Offset
+	Cursor *Cursor `json:"cursor,omitempty"`
+	// EstimateAbove estimates totals bigger than it instead of counting all rows, 0 always counts them
+	EstimateAbove int `json:"-"`
+}
+
+// Cursor points to the row next to which a page starts, for keyset pagination
+type Cursor struct {
+	// Values of the row's sort columns, the last one being its ID
+	Values []interface{} `json:"v"`
+	// Before selects the page preceding the row instead of the one following it
+	Before bool `json:"b,omitempty"`
+}
+
+// Page describes a page of a list
+type Page struct {
+	Total     int
+	Estimated bool
+	// Next and Prev point to adjacent pages, nil if there are none
+	Next *Cursor
+	Prev *Cursor
+}
+
+// Sort orders a list by a field, descending if Desc is set
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses comma separated fields, each prefixed with - for descending order, e.g. -last_login,last_name.
+// Only listed fields can be sorted by, ErrBadRequest is returned for others.
+func ParseSort(s string, fields ...string) ([]Sort, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var sort []Sort
+	for _, f := range strings.Split(s, ",") {
+		srt := Sort{Field: strings.TrimPrefix(f, "-"), Desc: strings.HasPrefix(f, "-")}
+		if !contains(fields, srt.Field) {
+			return nil, ErrBadRequest
+		}
+		sort = append(sort, srt)
+	}
+	return sort, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,30 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// PasswordReset represents a single-use password reset token.
+// Only the hash of the token is stored.
+type PasswordReset struct {
+	Base
+	UserID    int       `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UsedAt    time.Time `json:"used_at,omitempty"`
+}
+
+// Valid checks whether the reset token is unused and not expired at the given time
+func (r *PasswordReset) Valid(now time.Time) bool {
+	return r.UsedAt.IsZero() && now.Before(r.ExpiresAt)
+}
+
+// PasswordHistory holds hash of one of user's previous passwords, which can't be reused
+type PasswordHistory struct {
+	tableName struct{} `sql:"password_history"`
+
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package gorsk_test
+
+import (
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+func TestPasswordResetValid(t *testing.T) {
+	cases := []struct {
+		name  string
+		reset gorsk.PasswordReset
+		want  bool
+	}{
+		{
+			name:  "Expired",
+			reset: gorsk.PasswordReset{ExpiresAt: mock.TestTime(2000)},
+		},
+		{
+			name:  "Already used",
+			reset: gorsk.PasswordReset{ExpiresAt: mock.TestTime(2002), UsedAt: mock.TestTime(2000)},
+		},
+		{
+			name:  "Valid",
+			reset: gorsk.PasswordReset{ExpiresAt: mock.TestTime(2002)},
+			want:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reset.Valid(mock.TestTime(2001)); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package gorsk
+
+// Permission names an action allowed on a resource, formatted as resource:action
+type Permission string
+
+// Permissions checked by the application
+const (
+	PermUsersRead   Permission = "users:read"
+	PermUsersCreate Permission = "users:create"
+	PermUsersUpdate Permission = "users:update"
+	PermUsersDelete Permission = "users:delete"
+	// PermUsersUnlock allows lifting lockouts caused by failed logins
+	PermUsersUnlock Permission = "users:unlock"
+	// PermSessionsRevoke allows terminating all sessions of other users
+	PermSessionsRevoke Permission = "sessions:revoke"
+	// PermPasswordsExpire allows forcing users to change password on next login
+	PermPasswordsExpire Permission = "passwords:expire"
+
+	PermCompaniesRead   Permission = "companies:read"
+	PermCompaniesCreate Permission = "companies:create"
+	PermCompaniesUpdate Permission = "companies:update"
+	PermCompaniesDelete Permission = "companies:delete"
+
+	PermLocationsRead   Permission = "locations:read"
+	PermLocationsCreate Permission = "locations:create"
+	PermLocationsUpdate Permission = "locations:update"
+	PermLocationsDelete Permission = "locations:delete"
+
+	PermRolesRead   Permission = "roles:read"
+	PermRolesCreate Permission = "roles:create"
+	PermRolesUpdate Permission = "roles:update"
+	PermRolesDelete Permission = "roles:delete"
+)
+
+// Permissions lists all permissions known to the application
+var Permissions = []Permission{
+	PermUsersRead, PermUsersCreate, PermUsersUpdate, PermUsersDelete, PermUsersUnlock, PermSessionsRevoke, PermPasswordsExpire,
+	PermCompaniesRead, PermCompaniesCreate, PermCompaniesUpdate, PermCompaniesDelete,
+	PermLocationsRead, PermLocationsCreate, PermLocationsUpdate, PermLocationsDelete,
+	PermRolesRead, PermRolesCreate, PermRolesUpdate, PermRolesDelete,
+}
+
+// DefaultPermissions maps built-in roles to permissions matching what their access level allowed
+// before permissions were introduced. Which users and companies a permission reaches still depends
+// on the access level, e.g. users:update lets regular users update only themselves.
+var DefaultPermissions = map[AccessRole][]Permission{
+	SuperAdminRole: Permissions,
+	AdminRole:      Permissions,
+	CompanyAdminRole: {
+		PermUsersRead, PermUsersCreate, PermUsersUpdate, PermUsersDelete, PermPasswordsExpire,
+		PermCompaniesRead, PermCompaniesUpdate,
+		PermLocationsRead, PermLocationsCreate, PermLocationsUpdate, PermLocationsDelete,
+		PermRolesRead, PermRolesCreate, PermRolesUpdate, PermRolesDelete,
+	},
+	LocationAdminRole: {
+		PermUsersRead, PermUsersCreate, PermUsersUpdate, PermUsersDelete, PermPasswordsExpire,
+		PermLocationsRead, PermLocationsUpdate,
+		PermRolesRead,
+	},
+	UserRole: {
+		PermUsersRead, PermUsersUpdate,
+	},
+}
+
+// RolePermission grants permission to a role
+type RolePermission struct {
+	RoleID     AccessRole `json:"role_id" sql:",pk"`
+	Permission Permission `json:"permission" sql:",pk"`
+}
+
+// Valid checks whether permission is known to the application
+func (p Permission) Valid() bool {
+	for _, perm := range Permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
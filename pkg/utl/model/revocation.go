@@ -0,0 +1,18 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// RevokedToken represents an access token revoked before its expiry
+type RevokedToken struct {
+	JTI       string    `json:"jti" sql:",pk"`
+	UserID    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserRevocation represents a cutoff before which all user's access tokens are revoked
+type UserRevocation struct {
+	UserID        int       `json:"user_id" sql:",pk"`
+	RevokedBefore time.Time `json:"revoked_before"`
+}
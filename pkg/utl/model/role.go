@@ -21,8 +21,18 @@ const (
 )
 
 // Role model
+// Built-in roles have ids equal to their access level. Custom roles reuse an access level, deciding
+// whose data their users reach, and may be scoped to a single company.
 type Role struct {
-	ID          AccessRole `json:"id"`
-	AccessLevel AccessRole `json:"access_level"`
-	Name        string     `json:"name"`
+	ID          AccessRole   `json:"id"`
+	AccessLevel AccessRole   `json:"access_level"`
+	Name        string       `json:"name"`
+	CompanyID   int          `json:"company_id,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty" sql:"-"`
+}
+
+// BuiltIn checks whether role is one of the roles every installation has
+func (r *Role) BuiltIn() bool {
+	_, ok := DefaultPermissions[r.ID]
+	return ok && r.CompanyID == 0
 }
@@ -0,0 +1,43 @@
+package gorsk
+
+import (
+	"time"
+)
+
+// Session represents a single logged in device, holding its refresh token
+type Session struct {
+	Base
+	UserID    int       `json:"user_id"`
+	TokenHash string    `json:"-"`
+	UserAgent string    `json:"user_agent"`
+	IP        string    `json:"ip"`
+	LastUsed  time.Time `json:"last_used"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// Current is set when listing sessions, marking the one making the request
+	Current bool `json:"current" sql:"-"`
+}
+
+// Expired checks whether session has expired at the given time, either by reaching
+// its absolute expiry time or by not being used for longer than idle duration.
+// Zero expiry time or idle duration disable the respective check.
+func (s *Session) Expired(now time.Time, idle time.Duration) bool {
+	if !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt) {
+		return true
+	}
+	return idle > 0 && !now.Before(s.LastUsed.Add(idle))
+}
+
+// UpdateLastUsed updates session's last used time and client address
+func (s *Session) UpdateLastUsed(ip string) {
+	s.LastUsed = time.Now()
+	s.IP = ip
+}
+
+// RetiredToken represents a refresh token replaced by rotation.
+// Presenting a retired token indicates it was stolen, so the session it belonged to gets terminated.
+type RetiredToken struct {
+	TokenHash string    `json:"-" sql:",pk"`
+	SessionID int       `json:"session_id"`
+	RetiredAt time.Time `json:"retired_at"`
+}
@@ -0,0 +1,62 @@
+package gorsk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+func TestSessionExpired(t *testing.T) {
+	cases := []struct {
+		name    string
+		session gorsk.Session
+		idle    time.Duration
+		want    bool
+	}{
+		{
+			name: "No expiry",
+		},
+		{
+			name:    "Expired",
+			session: gorsk.Session{ExpiresAt: mock.TestTime(2000)},
+			want:    true,
+		},
+		{
+			name:    "Not expired",
+			session: gorsk.Session{ExpiresAt: mock.TestTime(2002)},
+		},
+		{
+			name:    "Idle for too long",
+			session: gorsk.Session{ExpiresAt: mock.TestTime(2002), LastUsed: mock.TestTime(2000)},
+			idle:    time.Hour,
+			want:    true,
+		},
+		{
+			name:    "Recently used",
+			session: gorsk.Session{ExpiresAt: mock.TestTime(2002), LastUsed: mock.TestTime(2001).Add(-time.Minute)},
+			idle:    time.Hour,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.Expired(mock.TestTime(2001), tt.idle); got != tt.want {
+				t.Errorf("Expected %v, received %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateLastUsed(t *testing.T) {
+	session := &gorsk.Session{IP: "10.0.0.1"}
+
+	session.UpdateLastUsed("10.0.0.2")
+	if session.LastUsed.IsZero() {
+		t.Errorf("Last used time was not changed")
+	}
+
+	if session.IP != "10.0.0.2" {
+		t.Errorf("IP was not changed")
+	}
+}
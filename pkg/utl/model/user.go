@@ -12,6 +12,8 @@ type User struct {
 	Username  string `json:"username"`
 	Password  string `json:"-"`
 	Email     string `json:"email"`
+	// EmailVerifiedAt is when user confirmed controlling the email, zero if never
+	EmailVerifiedAt time.Time `json:"email_verified_at,omitempty"`
 
 	Mobile  string `json:"mobile,omitempty"`
 	Phone   string `json:"phone,omitempty"`
@@ -21,8 +23,12 @@ type User struct {
 
 	LastLogin          time.Time `json:"last_login,omitempty"`
 	LastPasswordChange time.Time `json:"last_password_change,omitempty"`
+	// PasswordChangeRequired forces user to change password on next login
+	PasswordChangeRequired bool `json:"password_change_required"`
 
-	Token string `json:"-"`
+	MFAEnabled  bool   `json:"mfa_enabled"`
+	TOTPSecret  string `json:"-"`
+	TOTPCounter int64  `json:"-"`
 
 	Role *Role `json:"role,omitempty"`
 
@@ -38,17 +44,80 @@ type AuthUser struct {
 	LocationID int
 	Username   string
 	Email      string
-	Role       AccessRole
+	// Role is the access level of user's role, RoleID identifies the role itself
+	Role      AccessRole
+	RoleID    AccessRole
+	SessionID int
+	// TokenID and TokenExpires identify the access token used for authenticating
+	TokenID      string
+	TokenExpires time.Time
+}
+
+// UserSortFields lists fields users can be sorted by
+var UserSortFields = []string{"id", "first_name", "last_name", "username", "email", "created_at", "last_login"}
+
+// UserFilter holds criteria for filtering, searching and sorting users.
+// Zero values don't filter.
+type UserFilter struct {
+	Active          *bool
+	RoleID          AccessRole
+	CompanyID       int
+	LocationID      int
+	CreatedAfter    time.Time
+	LastLoginBefore time.Time
+	// Search matches case-insensitively part of name, username or email
+	Search string
+	Sort   []Sort
 }
 
 // ChangePassword updates user's password related fields
 func (u *User) ChangePassword(hash string) {
 	u.Password = hash
 	u.LastPasswordChange = time.Now()
+	u.PasswordChangeRequired = false
+}
+
+// PasswordExpired checks whether user has to change password before logging in, because it was required
+// or the password is older than maxAge at the given time. Zero maxAge disables the expiry.
+// Users who never changed their password are aged from their creation.
+func (u *User) PasswordExpired(now time.Time, maxAge time.Duration) bool {
+	if u.PasswordChangeRequired {
+		return true
+	}
+	changed := u.LastPasswordChange
+	if changed.IsZero() {
+		changed = u.CreatedAt
+	}
+	if maxAge == 0 || changed.IsZero() {
+		return false
+	}
+	return !now.Before(changed.Add(maxAge))
+}
+
+// EmailVerified checks whether user confirmed controlling the current email
+func (u *User) EmailVerified() bool {
+	return !u.EmailVerifiedAt.IsZero()
+}
+
+// VerifyEmail sets user's email, confirmed to be controlled by the user
+func (u *User) VerifyEmail(email string) {
+	u.Email = email
+	u.EmailVerifiedAt = time.Now()
 }
 
 // UpdateLastLogin updates last login field
-func (u *User) UpdateLastLogin(token string) {
-	u.Token = token
+func (u *User) UpdateLastLogin() {
 	u.LastLogin = time.Now()
 }
+
+// EnableMFA enables two-factor authentication, once the enrolled secret was confirmed
+func (u *User) EnableMFA() {
+	u.MFAEnabled = true
+}
+
+// DisableMFA disables two-factor authentication and clears the TOTP secret
+func (u *User) DisableMFA() {
+	u.MFAEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPCounter = 0
+}
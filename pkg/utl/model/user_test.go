@@ -2,13 +2,15 @@ package gorsk_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/figassis/goduck/pkg/utl/model"
 )
 
 func TestChangePassword(t *testing.T) {
 	user := &gorsk.User{
-		FirstName: "TestGuy",
+		FirstName:              "TestGuy",
+		PasswordChangeRequired: true,
 	}
 
 	hashedPassword := "h4$h3D"
@@ -22,6 +24,56 @@ func TestChangePassword(t *testing.T) {
 		t.Errorf("Password was not changed")
 
 	}
+
+	if user.PasswordChangeRequired {
+		t.Errorf("Password change is still required")
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	maxAge := 30 * 24 * time.Hour
+	cases := []struct {
+		name   string
+		user   gorsk.User
+		maxAge time.Duration
+		want   bool
+	}{
+		{
+			name:   "Expiry disabled",
+			user:   gorsk.User{LastPasswordChange: now.AddDate(-1, 0, 0)},
+			maxAge: 0,
+		},
+		{
+			name:   "Recently changed",
+			user:   gorsk.User{LastPasswordChange: now.AddDate(0, 0, -29)},
+			maxAge: maxAge,
+		},
+		{
+			name:   "Changed before max age",
+			user:   gorsk.User{LastPasswordChange: now.AddDate(0, 0, -30)},
+			maxAge: maxAge,
+			want:   true,
+		},
+		{
+			name:   "Never changed",
+			user:   gorsk.User{Base: gorsk.Base{CreatedAt: now.AddDate(0, -2, 0)}},
+			maxAge: maxAge,
+			want:   true,
+		},
+		{
+			name: "Change required",
+			user: gorsk.User{LastPasswordChange: now, PasswordChangeRequired: true},
+			want: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.PasswordExpired(now, tt.maxAge); got != tt.want {
+				t.Errorf("PasswordExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestUpdateLastLogin(t *testing.T) {
@@ -29,15 +81,37 @@ func TestUpdateLastLogin(t *testing.T) {
 		FirstName: "TestGuy",
 	}
 
-	token := "helloWorld"
-
-	user.UpdateLastLogin(token)
+	user.UpdateLastLogin()
 	if user.LastLogin.IsZero() {
 		t.Errorf("Last login time was not changed")
 	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	user := &gorsk.User{Email: "johndoe@mail.com"}
+	if user.EmailVerified() {
+		t.Errorf("Email was not verified yet")
+	}
+
+	user.VerifyEmail("john.doe@mail.com")
+	if !user.EmailVerified() || user.Email != "john.doe@mail.com" {
+		t.Errorf("Email was not verified")
+	}
+}
 
-	if user.Token != token {
-		t.Errorf("Token was not changed")
+func TestMFA(t *testing.T) {
+	user := &gorsk.User{
+		TOTPSecret: "secret",
+	}
+
+	user.EnableMFA()
+	if !user.MFAEnabled {
+		t.Errorf("MFA was not enabled")
+	}
 
+	user.TOTPCounter = 5
+	user.DisableMFA()
+	if user.MFAEnabled || user.TOTPSecret != "" || user.TOTPCounter != 0 {
+		t.Errorf("MFA was not disabled")
 	}
 }
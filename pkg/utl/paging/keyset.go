@@ -0,0 +1,167 @@
+package paging
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Column is a column lists are sorted by, e.g. user.last_login.
+// NULLs are sorted last in both directions.
+type Column struct {
+	Name string
+	Desc bool
+}
+
+// Select sorts q by cols, the last of which has to be unique and not null, like the primary key,
+// and selects the page p into slice, a pointer to slice of q's model.
+// Row returns values of cols for the row at index i of the selected slice, to point cursors to it.
+// Zero values are treated as NULLs, as go-pg stores them.
+func Select(q *orm.Query, slice interface{}, cols []Column, p *gorsk.Pagination, row func(i int) []interface{}) (*gorsk.Page, error) {
+	page := new(gorsk.Page)
+	var err error
+	if p.EstimateAbove > 0 {
+		page.Total, err = q.CountEstimate(p.EstimateAbove)
+		page.Estimated = page.Total > p.EstimateAbove
+	} else {
+		page.Total, err = q.Count()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	back := p.Cursor != nil && p.Cursor.Before
+	if p.Cursor != nil {
+		if len(p.Cursor.Values) != len(cols) {
+			return nil, ErrInvalidCursor
+		}
+		where, params := Keyset(cols, p.Cursor)
+		q.Where(where, params...)
+	} else {
+		q.Offset(p.Offset)
+	}
+	// Pages preceding the cursor are selected in reverse order
+	nulls := " NULLS LAST"
+	if back {
+		nulls = " NULLS FIRST"
+	}
+	for _, c := range cols {
+		dir := "ASC"
+		if c.Desc != back {
+			dir = "DESC"
+		}
+		q.OrderExpr("? "+dir+nulls, pg.F(c.Name))
+	}
+	// Selecting one row more tells whether there is another page
+	if err := q.Limit(p.Limit + 1).Select(); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(slice).Elem()
+	more := v.Len() > p.Limit
+	if more {
+		v.Set(v.Slice(0, p.Limit))
+	}
+	n := v.Len()
+	if back {
+		swap := reflect.Swapper(v.Interface())
+		for i := 0; i < n/2; i++ {
+			swap(i, n-1-i)
+		}
+	}
+	if n == 0 {
+		return page, nil
+	}
+
+	first := &gorsk.Cursor{Values: values(row(0)), Before: true}
+	last := &gorsk.Cursor{Values: values(row(n - 1))}
+	switch {
+	case back:
+		page.Next = last
+		if more {
+			page.Prev = first
+		}
+	case p.Cursor != nil:
+		page.Prev = first
+		if more {
+			page.Next = last
+		}
+	default:
+		if p.Offset > 0 {
+			page.Prev = first
+		}
+		if more {
+			page.Next = last
+		}
+	}
+	return page, nil
+}
+
+// values replaces zero values by nil
+func values(vals []interface{}) []interface{} {
+	for i, v := range vals {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			vals[i] = nil
+		}
+	}
+	return vals
+}
+
+// Keyset returns condition selecting rows following or preceding the cursor in order of cols, along with its parameters.
+// A row follows the cursor if it equals it in all columns before one in which it comes after it.
+func Keyset(cols []Column, c *gorsk.Cursor) (string, []interface{}) {
+	var (
+		or     []string
+		params []interface{}
+	)
+	for i, col := range cols {
+		cmp, cmpParams := compare(col, c.Values[i], c.Before)
+		if cmp == "" {
+			continue
+		}
+		and := make([]string, 0, i+1)
+		var andParams []interface{}
+		for j := 0; j < i; j++ {
+			if c.Values[j] == nil {
+				and = append(and, "? IS NULL")
+				andParams = append(andParams, pg.F(cols[j].Name))
+			} else {
+				and = append(and, "? = ?")
+				andParams = append(andParams, pg.F(cols[j].Name), c.Values[j])
+			}
+		}
+		and = append(and, cmp)
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+		params = append(append(params, andParams...), cmpParams...)
+	}
+	if len(or) == 0 {
+		return "FALSE", nil
+	}
+	return strings.Join(or, " OR "), params
+}
+
+// compare returns condition selecting column values after v, or before it if before is set.
+// As NULLs come last, nothing comes after NULL and all other values come before it.
+func compare(col Column, v interface{}, before bool) (string, []interface{}) {
+	f := pg.F(col.Name)
+	switch {
+	case v == nil && before:
+		return "? IS NOT NULL", []interface{}{f}
+	case v == nil:
+		return "", nil
+	case before:
+		return "? " + op(!col.Desc) + " ?", []interface{}{f, v}
+	default:
+		return "(? " + op(col.Desc) + " ? OR ? IS NULL)", []interface{}{f, v, f}
+	}
+}
+
+func op(less bool) string {
+	if less {
+		return "<"
+	}
+	return ">"
+}
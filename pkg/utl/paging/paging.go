@@ -0,0 +1,134 @@
+// Package paging implements keyset pagination of lists, with opaque cursors which clients can't read
+// or alter, total counts and RFC 8288 Link headers pointing to adjacent pages.
+//
+// Cursors are encrypted and bound to the list and its sort order, so a cursor issued for one list
+// can't be used with another one. Offset pagination keeps working alongside, lists select the page
+// following or preceding a cursor only when the request has one.
+package paging
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/labstack/echo"
+)
+
+// ErrInvalidCursor is returned for cursors which weren't issued for the list
+var ErrInvalidCursor = echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+
+var encoding = base64.RawURLEncoding
+
+// New creates new pager encrypting cursors with a key derived from secret.
+// Totals bigger than estimateAbove rows are estimated instead of counted, 0 always counts them.
+func New(secret []byte, estimateAbove int) *Pager {
+	key := sha256.Sum256(secret)
+	block, _ := aes.NewCipher(key[:])
+	aead, _ := cipher.NewGCM(block)
+	return &Pager{aead: aead, estimateAbove: estimateAbove}
+}
+
+// Pager converts pagination requests and responses of lists
+type Pager struct {
+	aead          cipher.AEAD
+	estimateAbove int
+}
+
+// Meta holds pagination fields of list responses
+type Meta struct {
+	Total          int    `json:"total"`
+	TotalEstimated bool   `json:"total_estimated,omitempty"`
+	Next           string `json:"next,omitempty"`
+	Prev           string `json:"prev,omitempty"`
+}
+
+// Transform checks and converts http pagination of list into database pagination model.
+// List identifies the list and its sort order, e.g. users?sort=-last_login.
+func (p *Pager) Transform(r *gorsk.PaginationReq, list string) (*gorsk.Pagination, error) {
+	pgn := r.Transform()
+	pgn.EstimateAbove = p.estimateAbove
+	if r.Cursor != "" {
+		c, err := p.Decode(r.Cursor, list)
+		if err != nil {
+			return nil, err
+		}
+		pgn.Cursor, pgn.Offset = c, 0
+	}
+	return pgn, nil
+}
+
+// Encode encrypts cursor of list into an opaque URL safe string
+func (p *Pager) Encode(c *gorsk.Cursor, list string) (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(p.aead.Seal(nonce, nonce, b, []byte(list))), nil
+}
+
+// Decode decrypts cursor of list, returning ErrInvalidCursor if it was altered or issued for another list
+func (p *Pager) Decode(s, list string) (*gorsk.Cursor, error) {
+	b, err := encoding.DecodeString(s)
+	if err != nil || len(b) < p.aead.NonceSize() {
+		return nil, ErrInvalidCursor
+	}
+	b, err = p.aead.Open(nil, b[:p.aead.NonceSize()], b[p.aead.NonceSize():], []byte(list))
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := new(gorsk.Cursor)
+	// Numbers are kept as strings, so big IDs don't lose precision
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(c); err != nil || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Respond sets Link header pointing to the first and adjacent pages of list, and returns pagination
+// fields of the response
+func (p *Pager) Respond(c echo.Context, pg *gorsk.Page, list string) (*Meta, error) {
+	m := &Meta{Total: pg.Total, TotalEstimated: pg.Estimated}
+	links := []string{link(c, "", "first")}
+	var err error
+	if pg.Prev != nil {
+		if m.Prev, err = p.Encode(pg.Prev, list); err != nil {
+			return nil, err
+		}
+		links = append(links, link(c, m.Prev, "prev"))
+	}
+	if pg.Next != nil {
+		if m.Next, err = p.Encode(pg.Next, list); err != nil {
+			return nil, err
+		}
+		links = append(links, link(c, m.Next, "next"))
+	}
+	c.Response().Header().Set("Link", strings.Join(links, ", "))
+	return m, nil
+}
+
+// link returns link to the requested URL with cursor replacing page and cursor parameters
+func link(c echo.Context, cursor, rel string) string {
+	r := c.Request()
+	q := r.URL.Query()
+	q.Del("page")
+	q.Del("cursor")
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	u := url.URL{Scheme: c.Scheme(), Host: r.Host, Path: r.URL.Path, RawQuery: q.Encode()}
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
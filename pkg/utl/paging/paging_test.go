@@ -0,0 +1,116 @@
+package paging_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/paging"
+	"github.com/go-pg/pg"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	p := paging.New([]byte("secret"), 0)
+	s, err := p.Encode(&gorsk.Cursor{Values: []interface{}{"Doe", nil, 12}, Before: true}, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, s, "Doe")
+
+	c, err := p.Decode(s, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, &gorsk.Cursor{Values: []interface{}{"Doe", nil, json.Number("12")}, Before: true}, c)
+
+	_, err = p.Decode(s, "users?sort=last_name")
+	assert.Equal(t, paging.ErrInvalidCursor, err)
+
+	_, err = paging.New([]byte("other"), 0).Decode(s, "users")
+	assert.Equal(t, paging.ErrInvalidCursor, err)
+
+	b := []byte(s)
+	b[len(b)/2] ^= 1
+	_, err = p.Decode(string(b), "users")
+	assert.Equal(t, paging.ErrInvalidCursor, err)
+
+	_, err = p.Decode("abc", "users")
+	assert.Equal(t, paging.ErrInvalidCursor, err)
+}
+
+func TestTransform(t *testing.T) {
+	p := paging.New([]byte("secret"), 1000)
+	pgn, err := p.Transform(&gorsk.PaginationReq{Limit: 20, Page: 2}, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, &gorsk.Pagination{Limit: 20, Offset: 40, EstimateAbove: 1000}, pgn)
+
+	s, err := p.Encode(&gorsk.Cursor{Values: []interface{}{1}}, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgn, err = p.Transform(&gorsk.PaginationReq{Limit: 20, Page: 2, Cursor: s}, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, &gorsk.Pagination{Limit: 20, Cursor: &gorsk.Cursor{Values: []interface{}{json.Number("1")}}, EstimateAbove: 1000}, pgn)
+
+	_, err = p.Transform(&gorsk.PaginationReq{Cursor: s}, "locations")
+	assert.Equal(t, paging.ErrInvalidCursor, err)
+}
+
+func TestRespond(t *testing.T) {
+	p := paging.New([]byte("secret"), 0)
+	req := httptest.NewRequest(http.MethodGet, "http://api.example.com/v1/users?limit=10&page=2&q=doe", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	m, err := p.Respond(c, &gorsk.Page{Total: 35, Estimated: true, Next: &gorsk.Cursor{Values: []interface{}{5}}}, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, 35, m.Total)
+	assert.True(t, m.TotalEstimated)
+	assert.Empty(t, m.Prev)
+	next, err := p.Decode(m.Next, "users")
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{json.Number("5")}, next.Values)
+	assert.Equal(t, `<http://api.example.com/v1/users?limit=10&q=doe>; rel="first", `+
+		`<http://api.example.com/v1/users?cursor=`+m.Next+`&limit=10&q=doe>; rel="next"`, rec.Header().Get("Link"))
+}
+
+func TestKeyset(t *testing.T) {
+	cols := []paging.Column{{Name: "user.last_login", Desc: true}, {Name: "user.id", Desc: true}}
+	cases := []struct {
+		name       string
+		cursor     *gorsk.Cursor
+		wantWhere  string
+		wantParams []interface{}
+	}{
+		{
+			name:      "After",
+			cursor:    &gorsk.Cursor{Values: []interface{}{"2019-01-01T00:00:00Z", 7}},
+			wantWhere: "((? < ? OR ? IS NULL)) OR (? = ? AND (? < ? OR ? IS NULL))",
+			wantParams: []interface{}{
+				pg.F("user.last_login"), "2019-01-01T00:00:00Z", pg.F("user.last_login"),
+				pg.F("user.last_login"), "2019-01-01T00:00:00Z", pg.F("user.id"), 7, pg.F("user.id"),
+			},
+		},
+		{
+			name:       "After NULL",
+			cursor:     &gorsk.Cursor{Values: []interface{}{nil, 7}},
+			wantWhere:  "(? IS NULL AND (? < ? OR ? IS NULL))",
+			wantParams: []interface{}{pg.F("user.last_login"), pg.F("user.id"), 7, pg.F("user.id")},
+		},
+		{
+			name:       "Before NULL",
+			cursor:     &gorsk.Cursor{Values: []interface{}{nil, 7}, Before: true},
+			wantWhere:  "(? IS NOT NULL) OR (? IS NULL AND ? > ?)",
+			wantParams: []interface{}{pg.F("user.last_login"), pg.F("user.last_login"), pg.F("user.id"), 7},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			where, params := paging.Keyset(cols, tt.cursor)
+			assert.Equal(t, tt.wantWhere, where)
+			assert.Equal(t, tt.wantParams, params)
+		})
+	}
+}
@@ -1,24 +1,61 @@
 package postgres
 
 import (
+	"context"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 	// DB adapter
 	_ "github.com/lib/pq"
 )
 
-type dbLogger struct{}
+// NewQueryLogger creates query logger, which can be enabled and disabled at runtime
+func NewQueryLogger(enabled bool) *QueryLogger {
+	l := new(QueryLogger)
+	l.SetEnabled(enabled)
+	return l
+}
 
-func (d dbLogger) BeforeQuery(pg *pg.QueryEvent) {}
+// QueryLogger logs executed queries while enabled
+type QueryLogger struct {
+	enabled int32
+}
 
-func (d dbLogger) AfterQuery(q *pg.QueryEvent) {
-	log.Printf(q.FormattedQuery())
+// SetEnabled turns query logging on or off, safe for concurrent use
+func (l *QueryLogger) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&l.enabled, v)
+}
+
+// BeforeQuery implements pg.QueryHook
+func (l *QueryLogger) BeforeQuery(q *pg.QueryEvent) {}
+
+// AfterQuery implements pg.QueryHook
+func (l *QueryLogger) AfterQuery(q *pg.QueryEvent) {
+	if atomic.LoadInt32(&l.enabled) == 0 {
+		return
+	}
+	query, err := q.FormattedQuery()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Println(query)
 }
 
 // New creates new database connection to a postgres database
 func New(psn string, timeout int, enableLog bool) (*pg.DB, error) {
+	return NewWithLogger(psn, timeout, NewQueryLogger(enableLog))
+}
+
+// NewWithLogger creates new database connection to a postgres database, logging queries with l
+func NewWithLogger(psn string, timeout int, l *QueryLogger) (*pg.DB, error) {
 	u, err := pg.ParseURL(psn)
 	if err != nil {
 		return nil, err
@@ -32,12 +69,56 @@ func New(psn string, timeout int, enableLog bool) (*pg.DB, error) {
 	}
 
 	if timeout > 0 {
-		db.WithTimeout(time.Second * time.Duration(timeout))
+		db = db.WithTimeout(time.Second * time.Duration(timeout))
 	}
 
-	if enableLog {
-		db.AddQueryHook(dbLogger{})
-	}
+	db.AddQueryHook(l)
 
 	return db, nil
 }
+
+// DB represents database of services, binding their queries to request context and running transactions.
+// Tests of services with mocked repositories use a stub instead of a connection.
+type DB interface {
+	// WithContext returns db bound to ctx, so its queries are cancelled together with the request
+	WithContext(ctx context.Context) orm.DB
+	// RunInTransaction runs fn in a transaction bound to ctx, which is committed unless fn returns an error
+	RunInTransaction(ctx context.Context, fn func(tx orm.DB) error) error
+}
+
+// Wrap adapts database connection to DB
+func Wrap(db *pg.DB) DB {
+	return conn{db}
+}
+
+type conn struct {
+	db *pg.DB
+}
+
+// WithContext implements DB
+func (c conn) WithContext(ctx context.Context) orm.DB {
+	return c.db.WithContext(ctx)
+}
+
+// RunInTransaction implements DB
+func (c conn) RunInTransaction(ctx context.Context, fn func(tx orm.DB) error) error {
+	return c.db.WithContext(ctx).RunInTransaction(func(tx *pg.Tx) error {
+		return fn(tx)
+	})
+}
+
+// Savepoint runs fn in a savepoint of transaction tx, so if fn fails only its changes are rolled back
+// and the transaction can go on.
+func Savepoint(tx orm.DB, fn func() error) error {
+	if _, err := tx.Exec("SAVEPOINT fn"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rerr := tx.Exec("ROLLBACK TO SAVEPOINT fn"); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT fn")
+	return err
+}
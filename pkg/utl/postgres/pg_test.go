@@ -1,9 +1,14 @@
 package postgres_test
 
 import (
+	"bytes"
 	"database/sql"
+	"log"
+	"os"
 	"testing"
 
+	"github.com/go-pg/pg"
+
 	"github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/stretchr/testify/assert"
@@ -56,3 +61,19 @@ func TestNew(t *testing.T) {
 	db.Close()
 
 }
+
+func TestQueryLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	l := postgres.NewQueryLogger(false)
+	ev := &pg.QueryEvent{DB: pg.Connect(&pg.Options{}), Query: "SELECT 1"}
+
+	l.AfterQuery(ev)
+	assert.Equal(t, "", buf.String())
+
+	l.SetEnabled(true)
+	l.AfterQuery(ev)
+	assert.Contains(t, buf.String(), "SELECT 1")
+}
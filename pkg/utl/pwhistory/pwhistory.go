@@ -0,0 +1,124 @@
+// Package pwhistory keeps hashes of users' previous passwords to prevent their reuse
+package pwhistory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// Store keeps previous password hashes of users.
+// Add writes through the given db, so the hash is recorded in the same transaction as the password change.
+type Store interface {
+	Recent(ctx context.Context, userID, n int) ([]string, error)
+	Add(ctx context.Context, db orm.DB, userID int, hash string, keep int) error
+}
+
+// New creates new password history, disallowing reuse of the last n passwords, including the current one.
+// Match reports whether password matches the hash. Zero n disables the history.
+func New(s Store, n int, match func(hash, password string) bool) *History {
+	return &History{store: s, n: n, match: match}
+}
+
+// History checks passwords against users' previous ones
+type History struct {
+	store Store
+	n     int
+	match func(hash, password string) bool
+}
+
+// Reused checks whether password is user's current or one of the recent passwords
+func (h *History) Reused(ctx context.Context, u *gorsk.User, password string) (bool, error) {
+	if h.n == 0 {
+		return false, nil
+	}
+	if u.Password != "" && h.match(u.Password, password) {
+		return true, nil
+	}
+	if h.n == 1 {
+		return false, nil
+	}
+	hashes, err := h.store.Recent(ctx, u.ID, h.n-1)
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		if h.match(hash, password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Add records user's current password before it is changed, forgetting ones which may be reused again
+func (h *History) Add(ctx context.Context, db orm.DB, u *gorsk.User) error {
+	if h.n <= 1 || u.Password == "" {
+		return nil
+	}
+	return h.store.Add(ctx, db, u.ID, u.Password, h.n-1)
+}
+
+// NewMemory creates new in-memory history store
+func NewMemory() *Memory {
+	return &Memory{hashes: make(map[int][]string)}
+}
+
+// Memory is an in-memory history store, intended for tests and single instance deployments
+type Memory struct {
+	mu     sync.Mutex
+	hashes map[int][]string
+}
+
+// Recent returns up to n of user's most recent previous hashes, newest first
+func (m *Memory) Recent(ctx context.Context, userID, n int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hashes := m.hashes[userID]
+	if len(hashes) > n {
+		hashes = hashes[:n]
+	}
+	return append([]string(nil), hashes...), nil
+}
+
+// Add records user's previous hash, keeping only the most recent ones
+func (m *Memory) Add(ctx context.Context, db orm.DB, userID int, hash string, keep int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hashes := append([]string{hash}, m.hashes[userID]...)
+	if len(hashes) > keep {
+		hashes = hashes[:keep]
+	}
+	m.hashes[userID] = hashes
+	return nil
+}
+
+// NewPG creates new PostgreSQL backed history store
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a PostgreSQL backed history store, using password_history table
+type PG struct {
+	db orm.DB
+}
+
+// Recent returns up to n of user's most recent previous hashes, newest first
+func (p *PG) Recent(ctx context.Context, userID, n int) ([]string, error) {
+	var hashes []string
+	err := p.db.ModelContext(ctx, (*gorsk.PasswordHistory)(nil)).Column("hash").
+		Where("user_id = ?", userID).Order("id DESC").Limit(n).Select(&hashes)
+	return hashes, err
+}
+
+// Add records user's previous hash using db, keeping only the most recent ones
+func (p *PG) Add(ctx context.Context, db orm.DB, userID int, hash string, keep int) error {
+	if _, err := db.ModelContext(ctx, &gorsk.PasswordHistory{UserID: userID, Hash: hash, CreatedAt: time.Now()}).Insert(); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, `DELETE FROM password_history WHERE user_id = ? AND id NOT IN
+	(SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?)`, userID, userID, keep)
+	return err
+}
@@ -0,0 +1,75 @@
+package pwhistory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/pwhistory"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testStore(t *testing.T, s pwhistory.Store, db orm.DB) {
+	ctx := context.Background()
+
+	hashes, err := s.Recent(ctx, 1, 3)
+	assert.Nil(t, err)
+	assert.Empty(t, hashes)
+
+	for _, h := range []string{"first", "second", "third", "fourth"} {
+		assert.Nil(t, s.Add(ctx, db, 1, h, 3))
+	}
+	assert.Nil(t, s.Add(ctx, db, 2, "other", 3))
+
+	hashes, err = s.Recent(ctx, 1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fourth", "third", "second"}, hashes)
+
+	hashes, err = s.Recent(ctx, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fourth"}, hashes)
+
+	hashes, err = s.Recent(ctx, 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other"}, hashes)
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, pwhistory.NewMemory(), nil)
+}
+
+func TestPG(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.PasswordHistory{})
+
+	testStore(t, pwhistory.NewPG(db), db)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	match := func(hash, password string) bool { return hash == "hash:"+password }
+	u := &gorsk.User{Base: gorsk.Base{ID: 1}, Password: "hash:current"}
+
+	disabled := pwhistory.New(pwhistory.NewMemory(), 0, match)
+	reused, err := disabled.Reused(ctx, u, "current")
+	assert.Nil(t, err)
+	assert.False(t, reused)
+
+	h := pwhistory.New(pwhistory.NewMemory(), 3, match)
+	for _, p := range []string{"first", "second", "third"} {
+		u.Password = "hash:" + p
+		assert.Nil(t, h.Add(ctx, nil, u))
+	}
+	u.Password = "hash:current"
+
+	for pass, want := range map[string]bool{"current": true, "third": true, "second": true, "first": false, "new": false} {
+		reused, err := h.Reused(ctx, u, pass)
+		assert.Nil(t, err)
+		assert.Equal(t, want, reused, pass)
+	}
+}
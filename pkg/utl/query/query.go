@@ -5,16 +5,50 @@ import (
 	"github.com/figassis/goduck/pkg/utl/model"
 )
 
-// List prepares data for list queries
+// List prepares data for user list queries. Columns are qualified as users are listed with their role.
 func List(u *gorsk.AuthUser) (*gorsk.ListQuery, error) {
+	switch true {
+	case u.Role <= gorsk.AdminRole: // user is SuperAdmin or Admin
+		return nil, nil
+	case u.Role == gorsk.CompanyAdminRole:
+		return &gorsk.ListQuery{Query: `"user".company_id = ?`, ID: u.CompanyID}, nil
+	case u.Role == gorsk.LocationAdminRole:
+		return &gorsk.ListQuery{Query: `"user".location_id = ?`, ID: u.LocationID}, nil
+	default:
+		return nil, echo.ErrForbidden
+	}
+}
+
+// Company prepares data for company list queries
+func Company(u *gorsk.AuthUser) (*gorsk.ListQuery, error) {
+	switch true {
+	case u.Role <= gorsk.AdminRole: // user is SuperAdmin or Admin
+		return nil, nil
+	case u.Role == gorsk.CompanyAdminRole:
+		return &gorsk.ListQuery{Query: "id = ?", ID: u.CompanyID}, nil
+	default:
+		return nil, echo.ErrForbidden
+	}
+}
+
+// Location prepares data for location list queries
+func Location(u *gorsk.AuthUser) (*gorsk.ListQuery, error) {
 	switch true {
 	case u.Role <= gorsk.AdminRole: // user is SuperAdmin or Admin
 		return nil, nil
 	case u.Role == gorsk.CompanyAdminRole:
 		return &gorsk.ListQuery{Query: "company_id = ?", ID: u.CompanyID}, nil
 	case u.Role == gorsk.LocationAdminRole:
-		return &gorsk.ListQuery{Query: "location_id = ?", ID: u.LocationID}, nil
+		return &gorsk.ListQuery{Query: "id = ?", ID: u.LocationID}, nil
 	default:
 		return nil, echo.ErrForbidden
 	}
 }
+
+// Role prepares data for role list queries
+func Role(u *gorsk.AuthUser) (*gorsk.ListQuery, error) {
+	if u.Role <= gorsk.AdminRole { // user is SuperAdmin or Admin
+		return nil, nil
+	}
+	return &gorsk.ListQuery{Query: "company_id IS NULL OR company_id = ?", ID: u.CompanyID}, nil
+}
@@ -34,7 +34,7 @@ func TestList(t *testing.T) {
 				CompanyID: 1,
 			}},
 			wantData: &gorsk.ListQuery{
-				Query: "company_id = ?",
+				Query: `"user".company_id = ?`,
 				ID:    1},
 		},
 		{
@@ -45,7 +45,7 @@ func TestList(t *testing.T) {
 				LocationID: 2,
 			}},
 			wantData: &gorsk.ListQuery{
-				Query: "location_id = ?",
+				Query: `"user".location_id = ?`,
 				ID:    2},
 		},
 		{
@@ -64,3 +64,95 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestCompany(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     *gorsk.AuthUser
+		wantData *gorsk.ListQuery
+		wantErr  error
+	}{
+		{
+			name: "Admin user",
+			user: &gorsk.AuthUser{Role: gorsk.AdminRole},
+		},
+		{
+			name:     "Company admin user",
+			user:     &gorsk.AuthUser{Role: gorsk.CompanyAdminRole, CompanyID: 4},
+			wantData: &gorsk.ListQuery{Query: "id = ?", ID: 4},
+		},
+		{
+			name:    "Location admin user",
+			user:    &gorsk.AuthUser{Role: gorsk.LocationAdminRole, CompanyID: 4, LocationID: 2},
+			wantErr: echo.ErrForbidden,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := query.Company(tt.user)
+			assert.Equal(t, tt.wantData, q)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     *gorsk.AuthUser
+		wantData *gorsk.ListQuery
+		wantErr  error
+	}{
+		{
+			name: "Super admin user",
+			user: &gorsk.AuthUser{Role: gorsk.SuperAdminRole},
+		},
+		{
+			name:     "Company admin user",
+			user:     &gorsk.AuthUser{Role: gorsk.CompanyAdminRole, CompanyID: 4},
+			wantData: &gorsk.ListQuery{Query: "company_id = ?", ID: 4},
+		},
+		{
+			name:     "Location admin user",
+			user:     &gorsk.AuthUser{Role: gorsk.LocationAdminRole, CompanyID: 4, LocationID: 2},
+			wantData: &gorsk.ListQuery{Query: "id = ?", ID: 2},
+		},
+		{
+			name:    "Normal user",
+			user:    &gorsk.AuthUser{Role: gorsk.UserRole},
+			wantErr: echo.ErrForbidden,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := query.Location(tt.user)
+			assert.Equal(t, tt.wantData, q)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestRole(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     *gorsk.AuthUser
+		wantData *gorsk.ListQuery
+	}{
+		{
+			name: "Admin user",
+			user: &gorsk.AuthUser{Role: gorsk.AdminRole},
+		},
+		{
+			name:     "Location admin user",
+			user:     &gorsk.AuthUser{Role: gorsk.LocationAdminRole, CompanyID: 4, LocationID: 2},
+			wantData: &gorsk.ListQuery{Query: "company_id IS NULL OR company_id = ?", ID: 4},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := query.Role(tt.user)
+			assert.Equal(t, tt.wantData, q)
+			assert.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewPG creates new PostgreSQL backed role permission store
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a PostgreSQL backed role permission store, using role_permissions table.
+// It also looks up company and location of users and locations being accessed.
+type PG struct {
+	db orm.DB
+}
+
+// RolePermissions returns permissions granted to each role
+func (p *PG) RolePermissions(ctx context.Context) (map[gorsk.AccessRole][]gorsk.Permission, error) {
+	var grants []gorsk.RolePermission
+	if err := p.db.ModelContext(ctx, &grants).Select(); err != nil {
+		return nil, err
+	}
+	rp := make(map[gorsk.AccessRole][]gorsk.Permission)
+	for _, g := range grants {
+		rp[g.RoleID] = append(rp[g.RoleID], g.Permission)
+	}
+	return rp, nil
+}
+
+// User returns company, location and role of user with given ID
+func (p *PG) User(ctx context.Context, id int) (*gorsk.User, error) {
+	var user = &gorsk.User{Base: gorsk.Base{ID: id}}
+	if err := p.db.ModelContext(ctx, user).Column("user.company_id", "user.location_id", "Role").WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Location returns company of location with given ID
+func (p *PG) Location(ctx context.Context, id int) (*gorsk.Location, error) {
+	var location = &gorsk.Location{Base: gorsk.Base{ID: id}}
+	if err := p.db.ModelContext(ctx, location).Column("company_id").WherePK().Select(); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
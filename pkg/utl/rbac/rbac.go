@@ -1,17 +1,74 @@
 package rbac
 
 import (
-	"github.com/labstack/echo"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/labstack/echo"
 )
 
-// New creates new RBAC service
-func New() *Service {
-	return &Service{}
+// DefaultCacheTTL is how long permissions loaded from store are used before reloading them
+const DefaultCacheTTL = time.Minute
+
+// New creates new RBAC service granting built-in roles their default permissions.
+// Company, location and role of accessed data are looked up in sc. Without it, admins
+// are only allowed to access their own account, and company and location admins their own location.
+func New(sc Scope) *Service {
+	s := &Service{scope: sc}
+	s.grants.Store(newGrants(gorsk.DefaultPermissions, time.Now()))
+	return s
+}
+
+// NewWithStore creates new RBAC service loading role permissions from st.
+// Loaded permissions are cached for ttl, DefaultCacheTTL is used if it is zero.
+func NewWithStore(st Store, sc Scope, ttl time.Duration) *Service {
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Service{store: st, scope: sc, ttl: ttl}
+}
+
+// Service is RBAC application service.
+// Permissions decide what a user may do, while access level of user's role decides
+// whose data it may do it to: all, its company's, its location's or only its own.
+type Service struct {
+	store  Store
+	scope  Scope
+	ttl    time.Duration
+	mu     sync.Mutex
+	grants atomic.Value
 }
 
-// Service is RBAC application service
-type Service struct{}
+// Store represents role permission store interface
+type Store interface {
+	RolePermissions(context.Context) (map[gorsk.AccessRole][]gorsk.Permission, error)
+}
+
+// Scope represents repository of data whose company and location decide who may access it.
+// User must load role of the user as well, as only users with a more privileged role may access it.
+type Scope interface {
+	User(context.Context, int) (*gorsk.User, error)
+	Location(context.Context, int) (*gorsk.Location, error)
+}
+
+type grants struct {
+	roles  map[gorsk.AccessRole]map[gorsk.Permission]bool
+	loaded time.Time
+}
+
+func newGrants(rp map[gorsk.AccessRole][]gorsk.Permission, loaded time.Time) *grants {
+	g := &grants{roles: make(map[gorsk.AccessRole]map[gorsk.Permission]bool, len(rp)), loaded: loaded}
+	for role, perms := range rp {
+		g.roles[role] = make(map[gorsk.Permission]bool, len(perms))
+		for _, p := range perms {
+			g.roles[role][p] = true
+		}
+	}
+	return g
+}
 
 func checkBool(b bool) error {
 	if b {
@@ -20,84 +77,260 @@ func checkBool(b bool) error {
 	return echo.ErrForbidden
 }
 
-// User returns user data stored in jwt token
-func (s *Service) User(c echo.Context) *gorsk.AuthUser {
-	id := c.Get("id").(int)
-	companyID := c.Get("company_id").(int)
-	locationID := c.Get("location_id").(int)
-	user := c.Get("username").(string)
-	email := c.Get("email").(string)
-	role := c.Get("role").(gorsk.AccessRole)
-	return &gorsk.AuthUser{
-		ID:         id,
-		Username:   user,
-		CompanyID:  companyID,
-		LocationID: locationID,
-		Email:      email,
-		Role:       role,
+// principal returns the authenticated user, anonymous requests are never authorized
+func principal(ctx context.Context) (*gorsk.AuthUser, error) {
+	if u := gorsk.UserFromContext(ctx); u != nil {
+		return u, nil
+	}
+	return nil, echo.ErrForbidden
+}
+
+// Refresh reloads role permissions from the store, so changed roles apply before the cache expires
+func (s *Service) Refresh(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.load(ctx)
+	return err
+}
+
+// current returns cached role permissions, reloading them once they expire
+func (s *Service) current(ctx context.Context) (*grants, error) {
+	if g, ok := s.fresh(); ok {
+		return g, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.fresh(); ok {
+		return g, nil
+	}
+	return s.load(ctx)
+}
+
+func (s *Service) fresh() (*grants, bool) {
+	g, _ := s.grants.Load().(*grants)
+	return g, g != nil && (s.store == nil || time.Since(g.loaded) < s.ttl)
+}
+
+func (s *Service) load(ctx context.Context) (*grants, error) {
+	rp, err := s.store.RolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g := newGrants(rp, time.Now())
+	s.grants.Store(g)
+	return g, nil
+}
+
+// User returns user data stored in jwt token, empty for anonymous requests
+func (s *Service) User(ctx context.Context) *gorsk.AuthUser {
+	if u := gorsk.UserFromContext(ctx); u != nil {
+		return u
 	}
+	return &gorsk.AuthUser{}
 }
 
-// EnforceRole authorizes request by AccessRole
-func (s *Service) EnforceRole(c echo.Context, r gorsk.AccessRole) error {
-	return checkBool(!(c.Get("role").(gorsk.AccessRole) > r))
+// Enforce checks whether user's role grants the permission, regardless of whose data it is applied to
+func (s *Service) Enforce(ctx context.Context, p gorsk.Permission) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	return s.enforce(ctx, u, p)
 }
 
-// EnforceUser checks whether the request to change user data is done by the same user
-func (s *Service) EnforceUser(c echo.Context, ID int) error {
-	// TODO: Implement querying db and checking the requested user's company_id/location_id
-	// to allow company/location admins to view the user
-	if s.isAdmin(c) {
+func (s *Service) enforce(ctx context.Context, u *gorsk.AuthUser, p gorsk.Permission) error {
+	g, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	return checkBool(g.roles[roleOf(u)][p])
+}
+
+// roleOf returns id of user's role
+func roleOf(u *gorsk.AuthUser) gorsk.AccessRole {
+	// Tokens without role id were issued for built-in roles, whose id equals access level
+	if u.RoleID == 0 {
+		return u.Role
+	}
+	return u.RoleID
+}
+
+// RequirePermission returns middleware rejecting requests unless user's role grants all permissions.
+// It has to run after the middleware authenticating the request.
+func (s *Service) RequirePermission(perms ...gorsk.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, p := range perms {
+				if err := s.Enforce(c.Request().Context(), p); err != nil {
+					return err
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// EnforceRole authorizes request by access level of user's role
+func (s *Service) EnforceRole(ctx context.Context, r gorsk.AccessRole) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	return checkBool(!(u.Role > r))
+}
+
+// EnforceUser checks whether user has the permission and applies it to itself or to a user
+// of the company or location it administers. Admins pass the check for company and location.
+// Apart from its own account, user may only access users with a less privileged role.
+func (s *Service) EnforceUser(ctx context.Context, p gorsk.Permission, ID int) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.enforce(ctx, u, p); err != nil {
+		return err
+	}
+	if u.ID == ID {
 		return nil
 	}
-	return checkBool(c.Get("id").(int) == ID)
+	if u.Role > gorsk.LocationAdminRole || s.scope == nil {
+		return echo.ErrForbidden
+	}
+	usr, err := s.scope.User(ctx, ID)
+	if err != nil {
+		return err
+	}
+	if !outranks(u, usr) {
+		return echo.ErrForbidden
+	}
+	if isAdmin(u) {
+		return nil
+	}
+	return checkBool(u.CompanyID == usr.CompanyID && (isCompanyAdmin(u) || u.LocationID == usr.LocationID))
+}
+
+// outranks checks whether user's role is more privileged than the role of target.
+// Super admins have no one above them, so they manage each other too.
+func outranks(u *gorsk.AuthUser, target *gorsk.User) bool {
+	if u.Role == gorsk.SuperAdminRole {
+		return true
+	}
+	return target.Role != nil && u.Role < target.Role.AccessLevel
 }
 
-// EnforceCompany checks whether the request to apply change to company data
-// is done by the user belonging to the that company and that the user has role CompanyAdmin.
+// EnforceCompany checks whether user has the permission and applies it to data of the company it administers.
 // If user has admin role, the check for company doesnt need to pass.
-func (s *Service) EnforceCompany(c echo.Context, ID int) error {
-	if s.isAdmin(c) {
-		return nil
+func (s *Service) EnforceCompany(ctx context.Context, p gorsk.Permission, ID int) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
 	}
-	if err := s.EnforceRole(c, gorsk.CompanyAdminRole); err != nil {
+	if err := s.enforce(ctx, u, p); err != nil {
 		return err
 	}
-	return checkBool(c.Get("company_id").(int) == ID)
+	if isAdmin(u) {
+		return nil
+	}
+	return checkBool(isCompanyAdmin(u) && u.CompanyID == ID)
 }
 
-// EnforceLocation checks whether the request to change location data
-// is done by the user belonging to the requested location
-func (s *Service) EnforceLocation(c echo.Context, ID int) error {
-	if s.isCompanyAdmin(c) {
+// EnforceLocation checks whether user has the permission and applies it to data of the location it administers,
+// or of any location of the company it administers. Admins pass the check for location.
+func (s *Service) EnforceLocation(ctx context.Context, p gorsk.Permission, ID int) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.enforce(ctx, u, p); err != nil {
+		return err
+	}
+	if isAdmin(u) {
+		return nil
+	}
+	if u.Role > gorsk.LocationAdminRole {
+		return echo.ErrForbidden
+	}
+	if u.LocationID == ID {
 		return nil
 	}
-	if err := s.EnforceRole(c, gorsk.LocationAdminRole); err != nil {
+	if !isCompanyAdmin(u) || s.scope == nil {
+		return echo.ErrForbidden
+	}
+	l, err := s.scope.Location(ctx, ID)
+	if err != nil {
 		return err
 	}
-	return checkBool((c.Get("location_id").(int) == ID))
+	return checkBool(l.CompanyID == u.CompanyID)
 }
 
-func (s *Service) isAdmin(c echo.Context) bool {
-	return !(c.Get("role").(gorsk.AccessRole) > gorsk.AdminRole)
+func isAdmin(u *gorsk.AuthUser) bool {
+	return !(u.Role > gorsk.AdminRole)
 }
 
-func (s *Service) isCompanyAdmin(c echo.Context) bool {
+func isCompanyAdmin(u *gorsk.AuthUser) bool {
 	// Must query company ID in database for the given user
-	return !(c.Get("role").(gorsk.AccessRole) > gorsk.CompanyAdminRole)
+	return !(u.Role > gorsk.CompanyAdminRole)
+}
+
+// EnforceGrant checks whether user may define a role with given access level and permissions.
+// The role can't be more privileged than user's own, neither by access level nor by permissions.
+func (s *Service) EnforceGrant(ctx context.Context, level gorsk.AccessRole, perms []gorsk.Permission) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if level < u.Role {
+		return echo.ErrForbidden
+	}
+	for _, p := range perms {
+		if err := s.enforce(ctx, u, p); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// AccountCreate performs auth check when creating a new account
-// Location admin cannot create accounts, needs to be fixed on EnforceLocation function
-func (s *Service) AccountCreate(c echo.Context, roleID gorsk.AccessRole, companyID, locationID int) error {
-	if err := s.EnforceLocation(c, locationID); err != nil {
+// AccountCreate performs auth check when creating a new account with the role.
+// Company and location admins create accounts in locations they administer, and only for their company.
+// Company roles can be assigned only to users of their company, and no role may grant
+// permissions the requesting user doesn't have.
+func (s *Service) AccountCreate(ctx context.Context, role *gorsk.Role, companyID, locationID int) error {
+	if err := s.EnforceLocation(ctx, gorsk.PermUsersCreate, locationID); err != nil {
 		return err
 	}
-	return s.IsLowerRole(c, roleID)
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(u) && u.CompanyID != companyID {
+		return echo.ErrForbidden
+	}
+	if role.CompanyID != 0 && role.CompanyID != companyID {
+		return echo.ErrForbidden
+	}
+	if err := s.IsLowerRole(ctx, role.AccessLevel); err != nil {
+		return err
+	}
+	g, err := s.current(ctx)
+	if err != nil {
+		return err
+	}
+	perms := make([]gorsk.Permission, 0, len(g.roles[role.ID]))
+	for p := range g.roles[role.ID] {
+		perms = append(perms, p)
+	}
+	return s.EnforceGrant(ctx, role.AccessLevel, perms)
 }
 
 // IsLowerRole checks whether the requesting user has higher role than the user it wants to change
 // Used for account creation/deletion
-func (s *Service) IsLowerRole(c echo.Context, r gorsk.AccessRole) error {
-	return checkBool(c.Get("role").(gorsk.AccessRole) < r)
+func (s *Service) IsLowerRole(ctx context.Context, r gorsk.AccessRole) error {
+	u, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	return checkBool(u.Role < r)
 }
@@ -1,7 +1,12 @@
 package rbac_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/figassis/goduck/pkg/utl/model"
 
@@ -14,9 +19,7 @@ import (
 )
 
 func TestUser(t *testing.T) {
-	ctx := mock.EchoCtxWithKeys([]string{
-		"id", "company_id", "location_id", "username", "email", "role"},
-		9, 15, 52, "ribice", "ribice@gmail.com", gorsk.SuperAdminRole)
+	ctx := mock.UserCtx(&gorsk.AuthUser{ID: 9, CompanyID: 15, LocationID: 52, Username: "ribice", Email: "ribice@gmail.com", Role: gorsk.SuperAdminRole, SessionID: 3})
 	wantUser := &gorsk.AuthUser{
 		ID:         9,
 		Username:   "ribice",
@@ -24,14 +27,15 @@ func TestUser(t *testing.T) {
 		LocationID: 52,
 		Email:      "ribice@gmail.com",
 		Role:       gorsk.SuperAdminRole,
+		SessionID:  3,
 	}
-	rbacSvc := rbac.New()
+	rbacSvc := rbac.New(nil)
 	assert.Equal(t, wantUser, rbacSvc.User(ctx))
 }
 
 func TestEnforceRole(t *testing.T) {
 	type args struct {
-		ctx  echo.Context
+		ctx  context.Context
 		role gorsk.AccessRole
 	}
 	cases := []struct {
@@ -41,27 +45,144 @@ func TestEnforceRole(t *testing.T) {
 	}{
 		{
 			name:    "Not authorized",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, gorsk.CompanyAdminRole), role: gorsk.SuperAdminRole},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{Role: gorsk.CompanyAdminRole}), role: gorsk.SuperAdminRole},
 			wantErr: true,
 		},
 		{
 			name:    "Authorized",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"role"}, gorsk.SuperAdminRole), role: gorsk.CompanyAdminRole},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{Role: gorsk.SuperAdminRole}), role: gorsk.CompanyAdminRole},
 			wantErr: false,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New()
+			rbacSvc := rbac.New(nil)
 			res := rbacSvc.EnforceRole(tt.args.ctx, tt.args.role)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
 }
 
+func TestEnforce(t *testing.T) {
+	cases := []struct {
+		name    string
+		role    gorsk.AccessRole
+		roleID  gorsk.AccessRole
+		perm    gorsk.Permission
+		wantErr bool
+	}{
+		{name: "Granted to built-in role", role: gorsk.UserRole, perm: gorsk.PermUsersUpdate},
+		{name: "Not granted to built-in role", role: gorsk.UserRole, perm: gorsk.PermUsersDelete, wantErr: true},
+		{name: "Granted to role by id", role: gorsk.UserRole, roleID: gorsk.LocationAdminRole, perm: gorsk.PermUsersDelete},
+		{name: "Unknown role", role: gorsk.UserRole, roleID: 999, perm: gorsk.PermUsersRead, wantErr: true},
+		{name: "Unknown permission", role: gorsk.SuperAdminRole, perm: "users:fly", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mock.UserCtx(&gorsk.AuthUser{Role: tt.role, RoleID: tt.roleID})
+			err := rbac.New(nil).Enforce(ctx, tt.perm)
+			assert.Equal(t, tt.wantErr, err == echo.ErrForbidden)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	rbacSvc := rbac.New(nil)
+	h := rbacSvc.RequirePermission(gorsk.PermUsersRead, gorsk.PermUsersDelete)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	cases := []struct {
+		name    string
+		user    *gorsk.AuthUser
+		wantErr error
+	}{
+		{name: "Anonymous", wantErr: echo.ErrForbidden},
+		{name: "Missing one permission", user: &gorsk.AuthUser{Role: gorsk.UserRole}, wantErr: echo.ErrForbidden},
+		{name: "All permissions", user: &gorsk.AuthUser{Role: gorsk.LocationAdminRole}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.GET, "/", nil)
+			if tt.user != nil {
+				req = req.WithContext(gorsk.NewUserContext(req.Context(), tt.user))
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			assert.Equal(t, tt.wantErr, h(c))
+		})
+	}
+}
+
+type store struct {
+	calls int
+	rp    map[gorsk.AccessRole][]gorsk.Permission
+	err   error
+}
+
+func (s *store) RolePermissions(context.Context) (map[gorsk.AccessRole][]gorsk.Permission, error) {
+	s.calls++
+	return s.rp, s.err
+}
+
+type scope struct{}
+
+var (
+	users = map[int]*gorsk.User{
+		30: {CompanyID: 2, LocationID: 3, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}},
+		31: {CompanyID: 2, LocationID: 3, Role: &gorsk.Role{AccessLevel: gorsk.LocationAdminRole}},
+		40: {CompanyID: 2, LocationID: 4, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}},
+		41: {CompanyID: 2, LocationID: 4, Role: &gorsk.Role{AccessLevel: gorsk.CompanyAdminRole}},
+		44: {CompanyID: 7, LocationID: 8, Role: &gorsk.Role{AccessLevel: gorsk.AdminRole}},
+		45: {CompanyID: 7, LocationID: 8, Role: &gorsk.Role{AccessLevel: gorsk.SuperAdminRole}},
+		70: {CompanyID: 7, LocationID: 8, Role: &gorsk.Role{AccessLevel: gorsk.UserRole}},
+	}
+	locations = map[int]*gorsk.Location{
+		3: {CompanyID: 2},
+		4: {CompanyID: 2},
+		8: {CompanyID: 7},
+	}
+	errNotFound = errors.New("not found")
+)
+
+func (scope) User(_ context.Context, id int) (*gorsk.User, error) {
+	if u, ok := users[id]; ok {
+		return u, nil
+	}
+	return nil, errNotFound
+}
+
+func (scope) Location(_ context.Context, id int) (*gorsk.Location, error) {
+	if l, ok := locations[id]; ok {
+		return l, nil
+	}
+	return nil, errNotFound
+}
+
+func TestNewWithStore(t *testing.T) {
+	ctx := mock.UserCtx(&gorsk.AuthUser{Role: gorsk.UserRole, RoleID: 300})
+	st := &store{rp: map[gorsk.AccessRole][]gorsk.Permission{300: {gorsk.PermUsersRead}}}
+	rbacSvc := rbac.NewWithStore(st, nil, time.Hour)
+
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.PermUsersRead))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.Enforce(ctx, gorsk.PermUsersUpdate))
+	assert.Equal(t, 1, st.calls, "permissions should be cached")
+
+	st.rp[300] = append(st.rp[300], gorsk.PermUsersUpdate)
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.Enforce(ctx, gorsk.PermUsersUpdate))
+	assert.Nil(t, rbacSvc.Refresh(context.Background()))
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.PermUsersUpdate))
+	assert.Equal(t, 2, st.calls)
+
+	st.err = errors.New("db down")
+	assert.Equal(t, st.err, rbacSvc.Refresh(context.Background()))
+	assert.Nil(t, rbacSvc.Enforce(ctx, gorsk.PermUsersUpdate), "permissions should be kept on failed refresh")
+
+	expired := rbac.NewWithStore(st, nil, time.Nanosecond)
+	assert.Equal(t, st.err, expired.Enforce(ctx, gorsk.PermUsersRead))
+}
+
 func TestEnforceUser(t *testing.T) {
 	type args struct {
-		ctx echo.Context
+		ctx context.Context
 		id  int
 	}
 	cases := []struct {
@@ -71,24 +192,74 @@ func TestEnforceUser(t *testing.T) {
 	}{
 		{
 			name:    "Not same user, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 15, gorsk.LocationAdminRole), id: 122},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.UserRole}), id: 30},
+			wantErr: true,
+		},
+		{
+			name:    "User of another location, location admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), id: 40},
+			wantErr: true,
+		},
+		{
+			name:    "User of the location, location admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), id: 30},
+			wantErr: false,
+		},
+		{
+			name:    "User of another company, company admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), id: 70},
+			wantErr: true,
+		},
+		{
+			name:    "User of the company, company admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), id: 40},
+			wantErr: false,
+		},
+		{
+			name:    "Location admin of the location, location admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), id: 31},
+			wantErr: true,
+		},
+		{
+			name:    "Company admin of the company, company admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 15, CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), id: 41},
 			wantErr: true,
 		},
 		{
 			name:    "Not same user, but admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 22, gorsk.SuperAdminRole), id: 44},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 22, Role: gorsk.AdminRole}), id: 70},
+			wantErr: false,
+		},
+		{
+			name:    "Another admin, admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 22, Role: gorsk.AdminRole}), id: 44},
+			wantErr: true,
+		},
+		{
+			name:    "Super admin, admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 22, Role: gorsk.AdminRole}), id: 45},
+			wantErr: true,
+		},
+		{
+			name:    "Admin, super admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 22, Role: gorsk.SuperAdminRole}), id: 44},
+			wantErr: false,
+		},
+		{
+			name:    "Another super admin, super admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 22, Role: gorsk.SuperAdminRole}), id: 45},
 			wantErr: false,
 		},
 		{
 			name:    "Same user",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"id", "role"}, 8, gorsk.AdminRole), id: 8},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{ID: 8, Role: gorsk.AdminRole}), id: 8},
 			wantErr: false,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New()
-			res := rbacSvc.EnforceUser(tt.args.ctx, tt.args.id)
+			rbacSvc := rbac.New(scope{})
+			res := rbacSvc.EnforceUser(tt.args.ctx, gorsk.PermUsersRead, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
@@ -96,7 +267,7 @@ func TestEnforceUser(t *testing.T) {
 
 func TestEnforceCompany(t *testing.T) {
 	type args struct {
-		ctx echo.Context
+		ctx context.Context
 		id  int
 	}
 	cases := []struct {
@@ -106,29 +277,29 @@ func TestEnforceCompany(t *testing.T) {
 	}{
 		{
 			name:    "Not same company, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "role"}, 7, gorsk.UserRole), id: 9},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 7, Role: gorsk.UserRole}), id: 9},
 			wantErr: true,
 		},
 		{
 			name:    "Same company, not company admin or admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "role"}, 22, gorsk.UserRole), id: 22},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 22, Role: gorsk.UserRole}), id: 22},
 			wantErr: true,
 		},
 		{
 			name:    "Same company, company admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "role"}, 5, gorsk.CompanyAdminRole), id: 5},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 5, Role: gorsk.CompanyAdminRole}), id: 5},
 			wantErr: false,
 		},
 		{
 			name:    "Not same company but admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "role"}, 8, gorsk.AdminRole), id: 9},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 8, Role: gorsk.AdminRole}), id: 9},
 			wantErr: false,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New()
-			res := rbacSvc.EnforceCompany(tt.args.ctx, tt.args.id)
+			rbacSvc := rbac.New(nil)
+			res := rbacSvc.EnforceCompany(tt.args.ctx, gorsk.PermCompaniesRead, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
@@ -136,7 +307,7 @@ func TestEnforceCompany(t *testing.T) {
 
 func TestEnforceLocation(t *testing.T) {
 	type args struct {
-		ctx echo.Context
+		ctx context.Context
 		id  int
 	}
 	cases := []struct {
@@ -146,29 +317,49 @@ func TestEnforceLocation(t *testing.T) {
 	}{
 		{
 			name:    "Not same location, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"location_id", "role"}, 7, gorsk.UserRole), id: 9},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{LocationID: 7, Role: gorsk.UserRole}), id: 9},
 			wantErr: true,
 		},
 		{
 			name:    "Same location, not company admin or admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"location_id", "role"}, 22, gorsk.UserRole), id: 22},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{LocationID: 22, Role: gorsk.UserRole}), id: 22},
 			wantErr: true,
 		},
 		{
 			name:    "Same location, company admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"location_id", "role"}, 5, gorsk.CompanyAdminRole), id: 5},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{LocationID: 5, Role: gorsk.CompanyAdminRole}), id: 5},
 			wantErr: false,
 		},
 		{
 			name:    "Location admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"location_id", "role"}, 5, gorsk.LocationAdminRole), id: 5},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{LocationID: 5, Role: gorsk.LocationAdminRole}), id: 5},
+			wantErr: false,
+		},
+		{
+			name:    "Another location, location admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), id: 4},
+			wantErr: true,
+		},
+		{
+			name:    "Location of the company, company admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), id: 4},
+			wantErr: false,
+		},
+		{
+			name:    "Location of another company, company admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), id: 8},
+			wantErr: true,
+		},
+		{
+			name:    "Location of another company, admin",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.AdminRole}), id: 8},
 			wantErr: false,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New()
-			res := rbacSvc.EnforceLocation(tt.args.ctx, tt.args.id)
+			rbacSvc := rbac.New(scope{})
+			res := rbacSvc.EnforceLocation(tt.args.ctx, gorsk.PermLocationsRead, tt.args.id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
@@ -176,8 +367,8 @@ func TestEnforceLocation(t *testing.T) {
 
 func TestAccountCreate(t *testing.T) {
 	type args struct {
-		ctx         echo.Context
-		roleID      gorsk.AccessRole
+		ctx         context.Context
+		role        *gorsk.Role
 		company_id  int
 		location_id int
 	}
@@ -188,47 +379,98 @@ func TestAccountCreate(t *testing.T) {
 	}{
 		{
 			name:    "Different location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: 500, company_id: 7, location_id: 8},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.UserRole}), role: &gorsk.Role{ID: 500, AccessLevel: 500}, company_id: 7, location_id: 8},
 			wantErr: true,
 		},
 		{
 			name:    "Same location, not company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.UserRole), roleID: 500, company_id: 2, location_id: 8},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.UserRole}), role: &gorsk.Role{ID: 500, AccessLevel: 500}, company_id: 2, location_id: 8},
 			wantErr: true,
 		},
 		{
 			name:    "Different location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 400, company_id: 2, location_id: 4},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 400, AccessLevel: 400}, company_id: 2, location_id: 4},
 			wantErr: false,
 		},
 		{
 			name:    "Same location, company, creating user role, not an admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 500, company_id: 2, location_id: 3},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 500, AccessLevel: 500}, company_id: 2, location_id: 3},
 			wantErr: false,
 		},
 		{
 			name:    "Same location, company, creating user role, admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.CompanyAdminRole), roleID: 500, company_id: 2, location_id: 3},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 500, AccessLevel: 500}, company_id: 2, location_id: 3},
 			wantErr: false,
 		},
 		{
 			name:    "Different everything, admin",
-			args:    args{ctx: mock.EchoCtxWithKeys([]string{"company_id", "location_id", "role"}, 2, 3, gorsk.AdminRole), roleID: 200, company_id: 7, location_id: 4},
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.AdminRole}), role: &gorsk.Role{ID: 200, AccessLevel: 200}, company_id: 7, location_id: 4},
 			wantErr: false,
 		},
+		{
+			name:    "Location admin, same location",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), role: &gorsk.Role{ID: 200, AccessLevel: 200}, company_id: 2, location_id: 3},
+			wantErr: false,
+		},
+		{
+			name:    "Location admin, different location",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.LocationAdminRole}), role: &gorsk.Role{ID: 200, AccessLevel: 200}, company_id: 2, location_id: 4},
+			wantErr: true,
+		},
+		{
+			name:    "Company admin, location of another company",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 200, AccessLevel: 200}, company_id: 7, location_id: 8},
+			wantErr: true,
+		},
+		{
+			name:    "Company admin, another company",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 200, AccessLevel: 200}, company_id: 7, location_id: 3},
+			wantErr: true,
+		},
+		{
+			name:    "Company role of the same company",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 301, AccessLevel: 200, CompanyID: 2}, company_id: 2, location_id: 3},
+			wantErr: false,
+		},
+		{
+			name:    "Company role of another company",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.AdminRole}), role: &gorsk.Role{ID: 301, AccessLevel: 200, CompanyID: 2}, company_id: 7, location_id: 4},
+			wantErr: true,
+		},
+		{
+			name:    "Role granting permission user doesn't have",
+			args:    args{ctx: mock.UserCtx(&gorsk.AuthUser{CompanyID: 2, LocationID: 3, Role: gorsk.CompanyAdminRole}), role: &gorsk.Role{ID: 300, AccessLevel: 200}, company_id: 2, location_id: 3},
+			wantErr: true,
+		},
+	}
+	rp := map[gorsk.AccessRole][]gorsk.Permission{
+		300: {gorsk.PermUsersRead, gorsk.PermSessionsRevoke},
+		301: {gorsk.PermUsersRead},
+	}
+	for role, perms := range gorsk.DefaultPermissions {
+		rp[role] = perms
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			rbacSvc := rbac.New()
-			res := rbacSvc.AccountCreate(tt.args.ctx, tt.args.roleID, tt.args.company_id, tt.args.location_id)
+			rbacSvc := rbac.NewWithStore(&store{rp: rp}, scope{}, time.Hour)
+			res := rbacSvc.AccountCreate(tt.args.ctx, tt.args.role, tt.args.company_id, tt.args.location_id)
 			assert.Equal(t, tt.wantErr, res == echo.ErrForbidden)
 		})
 	}
 }
 
+func TestEnforceGrant(t *testing.T) {
+	ctx := mock.UserCtx(&gorsk.AuthUser{Role: gorsk.CompanyAdminRole})
+	rbacSvc := rbac.New(nil)
+	assert.Nil(t, rbacSvc.EnforceGrant(ctx, gorsk.CompanyAdminRole, []gorsk.Permission{gorsk.PermUsersRead, gorsk.PermRolesCreate}))
+	assert.Nil(t, rbacSvc.EnforceGrant(ctx, gorsk.UserRole, nil))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceGrant(ctx, gorsk.AdminRole, nil))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceGrant(ctx, gorsk.UserRole, []gorsk.Permission{gorsk.PermCompaniesCreate}))
+}
+
 func TestIsLowerRole(t *testing.T) {
-	ctx := mock.EchoCtxWithKeys([]string{"role"}, gorsk.CompanyAdminRole)
-	rbacSvc := rbac.New()
+	ctx := mock.UserCtx(&gorsk.AuthUser{Role: gorsk.CompanyAdminRole})
+	rbacSvc := rbac.New(nil)
 	if rbacSvc.IsLowerRole(ctx, gorsk.LocationAdminRole) != nil {
 		t.Error("The requested user is higher role than the user requesting it")
 	}
@@ -236,3 +478,17 @@ func TestIsLowerRole(t *testing.T) {
 		t.Error("The requested user is lower role than the user requesting it")
 	}
 }
+
+func TestAnonymous(t *testing.T) {
+	ctx := context.Background()
+	rbacSvc := rbac.New(nil)
+	assert.Equal(t, &gorsk.AuthUser{}, rbacSvc.User(ctx))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.Enforce(ctx, gorsk.PermUsersRead))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceRole(ctx, gorsk.UserRole))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceUser(ctx, gorsk.PermUsersRead, 0))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceCompany(ctx, gorsk.PermCompaniesRead, 0))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceLocation(ctx, gorsk.PermLocationsRead, 0))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.EnforceGrant(ctx, gorsk.UserRole, nil))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.AccountCreate(ctx, &gorsk.Role{ID: gorsk.UserRole, AccessLevel: gorsk.UserRole}, 0, 0))
+	assert.Equal(t, echo.ErrForbidden, rbacSvc.IsLowerRole(ctx, gorsk.UserRole))
+}
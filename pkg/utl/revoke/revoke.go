@@ -0,0 +1,96 @@
+// Package revoke provides access token revocation stores
+package revoke
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewMemory creates new in-memory revocation store
+func NewMemory() *Memory {
+	return &Memory{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]time.Time),
+	}
+}
+
+// Memory is an in-memory revocation store, intended for tests and single instance deployments
+type Memory struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]time.Time
+}
+
+// Revoke revokes token with given jti until it expires
+func (m *Memory) Revoke(ctx context.Context, jti string, userID int, expires time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, exp := range m.tokens {
+		if exp.Before(now) {
+			delete(m.tokens, k)
+		}
+	}
+	m.tokens[jti] = expires
+	return nil
+}
+
+// RevokeUser revokes all user's tokens issued before given time
+func (m *Memory) RevokeUser(ctx context.Context, userID int, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users[userID] = before
+	return nil
+}
+
+// IsRevoked checks whether token was revoked, either by jti or by user cutoff
+func (m *Memory) IsRevoked(ctx context.Context, jti string, userID int, issued time.Time) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, ok := m.tokens[jti]; ok && jti != "" {
+		return true, nil
+	}
+	before, ok := m.users[userID]
+	return ok && issued.Before(before), nil
+}
+
+// NewPG creates new PostgreSQL backed revocation store
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a PostgreSQL backed revocation store, using revoked_tokens and user_revocations tables
+type PG struct {
+	db orm.DB
+}
+
+// Revoke revokes token with given jti until it expires.
+// Tokens past their expiry are removed, since they are rejected regardless.
+func (p *PG) Revoke(ctx context.Context, jti string, userID int, expires time.Time) error {
+	if _, err := p.db.ModelContext(ctx, (*gorsk.RevokedToken)(nil)).Where("expires_at < ?", time.Now()).Delete(); err != nil {
+		return err
+	}
+	_, err := p.db.ModelContext(ctx, &gorsk.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expires}).
+		OnConflict("(jti) DO NOTHING").Insert()
+	return err
+}
+
+// RevokeUser revokes all user's tokens issued before given time
+func (p *PG) RevokeUser(ctx context.Context, userID int, before time.Time) error {
+	_, err := p.db.ModelContext(ctx, &gorsk.UserRevocation{UserID: userID, RevokedBefore: before}).
+		OnConflict("(user_id) DO UPDATE").Set("revoked_before = EXCLUDED.revoked_before").Insert()
+	return err
+}
+
+// IsRevoked checks whether token was revoked, either by jti or by user cutoff
+func (p *PG) IsRevoked(ctx context.Context, jti string, userID int, issued time.Time) (bool, error) {
+	var revoked bool
+	_, err := p.db.QueryOneContext(ctx, pg.Scan(&revoked), `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+	OR EXISTS (SELECT 1 FROM user_revocations WHERE user_id = ? AND revoked_before > ?)`, jti, userID, issued)
+	return revoked, err
+}
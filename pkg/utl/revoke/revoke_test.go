@@ -0,0 +1,82 @@
+package revoke_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/revoke"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type store interface {
+	Revoke(context.Context, string, int, time.Time) error
+	RevokeUser(context.Context, int, time.Time) error
+	IsRevoked(context.Context, string, int, time.Time) (bool, error)
+}
+
+func testStore(t *testing.T, s store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.Nil(t, s.Revoke(ctx, "revoked", 1, now.Add(time.Hour)))
+	assert.Nil(t, s.RevokeUser(ctx, 2, now))
+
+	cases := []struct {
+		name   string
+		jti    string
+		userID int
+		issued time.Time
+		want   bool
+	}{
+		{
+			name:   "Token not revoked",
+			jti:    "valid",
+			userID: 1,
+			issued: now,
+		},
+		{
+			name:   "Token revoked by jti",
+			jti:    "revoked",
+			userID: 1,
+			issued: now,
+			want:   true,
+		},
+		{
+			name:   "Token issued before user revocation",
+			jti:    "old",
+			userID: 2,
+			issued: now.Add(-time.Minute),
+			want:   true,
+		},
+		{
+			name:   "Token issued after user revocation",
+			jti:    "new",
+			userID: 2,
+			issued: now.Add(time.Minute),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := s.IsRevoked(ctx, tt.jti, tt.userID, tt.issued)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, revoked)
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, revoke.NewMemory())
+}
+
+func TestPG(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.RevokedToken{}, &gorsk.UserRevocation{})
+
+	testStore(t, revoke.NewPG(db))
+}
@@ -0,0 +1,240 @@
+package secure
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Sizes of salts and keys of argon2id and scrypt hashes
+const (
+	saltLen = 16
+	keyLen  = 32
+)
+
+// ErrMalformedHash is returned for hashes which can't be parsed
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// Hasher hashes passwords into self-describing strings in PHC format,
+// e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>, which hold the algorithm and parameters used.
+type Hasher interface {
+	// ID identifies algorithm of the hashes, e.g. argon2id
+	ID() string
+	// Hash hashes password with a random salt
+	Hash(password []byte) (string, error)
+	// Verify reports whether password matches hash of the algorithm,
+	// and whether hash was created with other parameters than Hash uses
+	Verify(hash string, password []byte) (match, outdated bool, err error)
+}
+
+// hashers verify hashes of algorithms other than the configured one
+var hashers = map[string]Hasher{
+	"bcrypt":   Bcrypt{},
+	"argon2id": Argon2id{},
+	"scrypt":   Scrypt{},
+}
+
+// hashID returns ID of algorithm of the hash
+func hashID(hash string) string {
+	// bcrypt predates PHC format, its hashes are $2a$, $2b$ or $2y$ followed by cost
+	if strings.HasPrefix(hash, "$2") {
+		return "bcrypt"
+	}
+	parts := strings.SplitN(hash, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// Bcrypt hashes passwords using bcrypt, which uses just the first 72 bytes of passwords
+type Bcrypt struct {
+	// Cost is log2 of the number of rounds, bcrypt.DefaultCost if 0
+	Cost int
+}
+
+// ID implements Hasher
+func (Bcrypt) ID() string { return "bcrypt" }
+
+func (b Bcrypt) cost() int {
+	if b.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return b.Cost
+}
+
+// Hash implements Hasher
+func (b Bcrypt) Hash(password []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(password, b.cost())
+	return string(hash), err
+}
+
+// Verify implements Hasher
+func (b Bcrypt) Verify(hash string, password []byte) (bool, bool, error) {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, ErrMalformedHash
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(hash), password)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost != b.cost(), nil
+}
+
+// Argon2id hashes passwords using Argon2id, the memory-hard winner of the Password Hashing Competition
+type Argon2id struct {
+	// Memory in KiB, 64 MiB if 0
+	Memory uint32
+	// Iterations over the memory, 3 if 0
+	Iterations uint32
+	// Parallelism is the number of threads, 2 if 0
+	Parallelism uint8
+}
+
+// ID implements Hasher
+func (Argon2id) ID() string { return "argon2id" }
+
+func (a Argon2id) params() Argon2id {
+	if a.Memory == 0 {
+		a.Memory = 64 * 1024
+	}
+	if a.Iterations == 0 {
+		a.Iterations = 3
+	}
+	if a.Parallelism == 0 {
+		a.Parallelism = 2
+	}
+	return a
+}
+
+// Hash implements Hasher
+func (a Argon2id) Hash(password []byte) (string, error) {
+	p := a.params()
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, keyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify implements Hasher
+func (a Argon2id) Verify(hash string, password []byte) (bool, bool, error) {
+	var (
+		version int
+		h       Argon2id
+	)
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, false, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.Memory, &h.Iterations, &h.Parallelism); err != nil ||
+		h.Iterations == 0 || h.Parallelism == 0 {
+		return false, false, ErrMalformedHash
+	}
+	salt, key, err := saltAndKey(parts[4], parts[5])
+	if err != nil {
+		return false, false, err
+	}
+	match := subtle.ConstantTimeCompare(key, argon2.IDKey(password, salt, h.Iterations, h.Memory, h.Parallelism, uint32(len(key)))) == 1
+	return match, match && (h != a.params() || len(key) != keyLen), nil
+}
+
+// Scrypt hashes passwords using scrypt
+type Scrypt struct {
+	// Cost is log2 of the CPU/memory cost N, 15 if 0
+	Cost uint8
+	// BlockSize r, 8 if 0
+	BlockSize int
+	// Parallelism p, 1 if 0
+	Parallelism int
+}
+
+// ID implements Hasher
+func (Scrypt) ID() string { return "scrypt" }
+
+func (s Scrypt) params() Scrypt {
+	if s.Cost == 0 {
+		s.Cost = 15
+	}
+	if s.BlockSize == 0 {
+		s.BlockSize = 8
+	}
+	if s.Parallelism == 0 {
+		s.Parallelism = 1
+	}
+	return s
+}
+
+// Hash implements Hasher
+func (s Scrypt) Hash(password []byte) (string, error) {
+	p := s.params()
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key(password, salt, 1<<p.Cost, p.BlockSize, p.Parallelism, keyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", p.Cost, p.BlockSize, p.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify implements Hasher
+func (s Scrypt) Verify(hash string, password []byte) (bool, bool, error) {
+	var h Scrypt
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return false, false, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &h.Cost, &h.BlockSize, &h.Parallelism); err != nil || h.Cost == 0 || h.Cost > 62 {
+		return false, false, ErrMalformedHash
+	}
+	salt, key, err := saltAndKey(parts[3], parts[4])
+	if err != nil {
+		return false, false, err
+	}
+	derived, err := scrypt.Key(password, salt, 1<<h.Cost, h.BlockSize, h.Parallelism, len(key))
+	if err != nil {
+		return false, false, ErrMalformedHash
+	}
+	match := subtle.ConstantTimeCompare(key, derived) == 1
+	return match, match && (h != s.params() || len(key) != keyLen), nil
+}
+
+// b64 is the unpadded standard base64 encoding of PHC format
+var b64 = base64.RawStdEncoding
+
+func newSalt() ([]byte, error) {
+	b := make([]byte, saltLen)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+func saltAndKey(salt, key string) ([]byte, []byte, error) {
+	s, err := b64.DecodeString(salt)
+	if err != nil {
+		return nil, nil, ErrMalformedHash
+	}
+	k, err := b64.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, nil, ErrMalformedHash
+	}
+	return s, k, nil
+}
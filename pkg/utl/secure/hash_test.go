@@ -0,0 +1,79 @@
+package secure_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/secure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher(t *testing.T) {
+	cases := []struct {
+		name         string
+		hasher       secure.Hasher
+		current      secure.Hasher
+		wantPrefix   string
+		wantOutdated bool
+	}{
+		{
+			name:       "Bcrypt",
+			hasher:     secure.Bcrypt{Cost: 5},
+			current:    secure.Bcrypt{Cost: 5},
+			wantPrefix: "$2a$05$",
+		},
+		{
+			name:         "Bcrypt with other cost",
+			hasher:       secure.Bcrypt{Cost: 4},
+			current:      secure.Bcrypt{Cost: 5},
+			wantPrefix:   "$2a$04$",
+			wantOutdated: true,
+		},
+		{
+			name:       "Argon2id",
+			hasher:     secure.Argon2id{Memory: 64, Iterations: 2, Parallelism: 1},
+			current:    secure.Argon2id{Memory: 64, Iterations: 2, Parallelism: 1},
+			wantPrefix: "$argon2id$v=19$m=64,t=2,p=1$",
+		},
+		{
+			name:         "Argon2id with other parameters",
+			hasher:       secure.Argon2id{Memory: 64, Iterations: 2, Parallelism: 1},
+			current:      secure.Argon2id{Memory: 64, Iterations: 3, Parallelism: 1},
+			wantPrefix:   "$argon2id$v=19$m=64,t=2,p=1$",
+			wantOutdated: true,
+		},
+		{
+			name:       "Scrypt",
+			hasher:     secure.Scrypt{Cost: 4, BlockSize: 2},
+			current:    secure.Scrypt{Cost: 4, BlockSize: 2},
+			wantPrefix: "$scrypt$ln=4,r=2,p=1$",
+		},
+		{
+			name:         "Scrypt with other parameters",
+			hasher:       secure.Scrypt{Cost: 4, BlockSize: 2},
+			current:      secure.Scrypt{Cost: 5, BlockSize: 2},
+			wantPrefix:   "$scrypt$ln=4,r=2,p=1$",
+			wantOutdated: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hasher.Hash([]byte("gamepad"))
+			assert.Nil(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.wantPrefix), hash)
+
+			match, outdated, err := tt.current.Verify(hash, []byte("gamepad"))
+			assert.Nil(t, err)
+			assert.True(t, match)
+			assert.Equal(t, tt.wantOutdated, outdated)
+
+			match, outdated, err = tt.current.Verify(hash, []byte("gamepat"))
+			assert.Nil(t, err)
+			assert.False(t, match)
+			assert.False(t, outdated)
+
+			_, _, err = tt.current.Verify(strings.Replace(hash, "$", "", 1), []byte("gamepad"))
+			assert.NotNil(t, err)
+		})
+	}
+}
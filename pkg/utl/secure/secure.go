@@ -1,46 +1,136 @@
 package secure
 
 import (
-	"fmt"
-	"hash"
-	"strconv"
-	"time"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 
 	zxcvbn "github.com/nbutton23/zxcvbn-go"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// New initalizes security service
-func New(minPWStr int, h hash.Hash) *Service {
-	return &Service{minPWStr: minPWStr, h: h}
+// New initalizes security service, hashing passwords using bcrypt with default cost
+func New(minPWStr int, tokenKey string) *Service {
+	return NewWithHasher(minPWStr, tokenKey, Bcrypt{}, "")
+}
+
+// NewWithHasher initalizes security service hashing passwords using hasher.
+// tokenKey keys hashes of tokens stored at rest, see TokenHash. It may only be empty if tokens aren't hashed.
+// Non-empty pepper is mixed into all passwords hashed, and must be kept apart from the database.
+func NewWithHasher(minPWStr int, tokenKey string, hasher Hasher, pepper string) *Service {
+	s := &Service{minPWStr: int32(minPWStr), hasher: hasher}
+	if tokenKey != "" {
+		s.tokenKey = []byte(tokenKey)
+	}
+	if pepper != "" {
+		s.pepper = []byte(pepper)
+	}
+	return s
 }
 
 // Service holds security related methods
 type Service struct {
-	minPWStr int
-	h        hash.Hash
+	minPWStr int32
+	minPWLen int32
+	tokenKey []byte
+	hasher   Hasher
+	pepper   []byte
+	breaches BreachList
+}
+
+// BreachList reports whether password was found in breach
+type BreachList interface {
+	Contains(string) (bool, error)
+}
+
+// SetBreachList sets list of breached passwords reported by Breached, and must be called before the service is used.
+// Passwords aren't screened if the list is nil.
+func (s *Service) SetBreachList(l BreachList) {
+	s.breaches = l
+}
+
+// SetMinPasswordStrength changes minimal password strength required by Password, safe for concurrent use
+func (s *Service) SetMinPasswordStrength(minPWStr int) {
+	atomic.StoreInt32(&s.minPWStr, int32(minPWStr))
 }
 
-// Password checks whether password is secure enough using zxcvbn library
+// SetMinPasswordLength changes minimal number of characters of passwords required by Password, safe for concurrent use
+func (s *Service) SetMinPasswordLength(minPWLen int) {
+	atomic.StoreInt32(&s.minPWLen, int32(minPWLen))
+}
+
+// Password checks whether password is long enough, and secure enough using zxcvbn library
 func (s *Service) Password(pass string, inputs ...string) bool {
+	if utf8.RuneCountInString(pass) < int(atomic.LoadInt32(&s.minPWLen)) {
+		return false
+	}
 	pwStrength := zxcvbn.PasswordStrength(pass, inputs)
-	return pwStrength.Score >= s.minPWStr
+	return pwStrength.Score >= int(atomic.LoadInt32(&s.minPWStr))
 }
 
-// Hash hashes the password using bcrypt
-func (*Service) Hash(password string) string {
-	hashedPW, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedPW)
+// Breached checks whether password was found in breach
+func (s *Service) Breached(pass string) (bool, error) {
+	if s.breaches == nil {
+		return false, nil
+	}
+	return s.breaches.Contains(pass)
+}
+
+// pepperedPrefix marks hashes of peppered passwords, e.g. $peppered$argon2id$v=19$...
+const pepperedPrefix = "$peppered"
+
+// Hash hashes the password using the configured hasher.
+// Hashes of peppered passwords are marked as such.
+func (s *Service) Hash(password string) (string, error) {
+	hash, err := s.hasher.Hash(s.peppered(password))
+	if err != nil || s.pepper == nil {
+		return hash, err
+	}
+	return pepperedPrefix + hash, nil
 }
 
 // HashMatchesPassword matches hash with password. Returns true if hash and password match.
-func (*Service) HashMatchesPassword(hash, password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+func (s *Service) HashMatchesPassword(hash, password string) bool {
+	match, _ := s.VerifyPassword(hash, password)
+	return match
+}
+
+// VerifyPassword reports whether hash matches password, and whether the password should be hashed again
+// because hash uses another algorithm or other parameters than the configured hasher, or lacks the pepper.
+// Hashes of any supported algorithm are verified. Hashes created before the pepper was configured
+// are still accepted, until they are rehashed.
+func (s *Service) VerifyPassword(hash, password string) (match, rehash bool) {
+	pass := []byte(password)
+	if strings.HasPrefix(hash, pepperedPrefix+"$") {
+		if s.pepper == nil {
+			return false, false
+		}
+		hash, pass = hash[len(pepperedPrefix):], s.peppered(password)
+	} else if s.pepper != nil {
+		rehash = true
+	}
+	h := s.hasher
+	if id := hashID(hash); id != h.ID() {
+		if h = hashers[id]; h == nil {
+			return false, false
+		}
+		rehash = true
+	}
+	match, outdated, err := h.Verify(hash, pass)
+	if err != nil {
+		return false, false
+	}
+	return match, match && (rehash || outdated)
 }
 
-// Token generates new unique token
-func (s *Service) Token(str string) string {
-	s.h.Reset()
-	fmt.Fprintf(s.h, "%s%s", str, strconv.Itoa(time.Now().Nanosecond()))
-	return fmt.Sprintf("%x", s.h.Sum(nil))
+// peppered returns HMAC of password keyed by the pepper, encoded to fit bcrypt's 72 bytes limit
+func (s *Service) peppered(password string) []byte {
+	if s.pepper == nil {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
 }
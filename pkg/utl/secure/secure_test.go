@@ -1,7 +1,8 @@
 package secure_test
 
 import (
-	"crypto/sha1"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/figassis/goduck/pkg/utl/secure"
@@ -35,37 +36,195 @@ func TestPassword(t *testing.T) {
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := secure.New(1, nil)
+			s := secure.New(1, "")
 			got := s.Password(tt.pass, tt.inputs...)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
+func TestSetMinPasswordStrength(t *testing.T) {
+	s := secure.New(1, "")
+	assert.True(t, s.Password("callgophers"))
+	s.SetMinPasswordStrength(4)
+	assert.False(t, s.Password("callgophers"))
+}
+
+func TestSetMinPasswordLength(t *testing.T) {
+	s := secure.New(1, "")
+	assert.True(t, s.Password("callgophers"))
+	s.SetMinPasswordLength(12)
+	assert.False(t, s.Password("callgophers"))
+	assert.True(t, s.Password("callgophersë"), "length is counted in characters")
+}
+
+type breachList map[string]bool
+
+func (l breachList) Contains(pass string) (bool, error) {
+	if pass == "error" {
+		return false, errors.New("read failed")
+	}
+	return l[pass], nil
+}
+
+func TestBreached(t *testing.T) {
+	s := secure.New(1, "")
+	found, err := s.Breached("password")
+	assert.Nil(t, err)
+	assert.False(t, found, "passwords aren't screened without breach list")
+
+	s.SetBreachList(breachList{"password": true})
+	found, err = s.Breached("password")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	found, err = s.Breached("callgophers")
+	assert.Nil(t, err)
+	assert.False(t, found)
+	_, err = s.Breached("error")
+	assert.NotNil(t, err)
+}
+
 func TestHashAndMatch(t *testing.T) {
 	cases := []struct {
-		name string
-		pass string
-		want bool
+		name   string
+		hasher secure.Hasher
+		pepper string
+	}{
+		{
+			name:   "Bcrypt",
+			hasher: secure.Bcrypt{Cost: 4},
+		},
+		{
+			name:   "Argon2id",
+			hasher: secure.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1},
+		},
+		{
+			name:   "Scrypt with pepper",
+			hasher: secure.Scrypt{Cost: 4},
+			pepper: "pepper",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := secure.NewWithHasher(1, "", tt.hasher, tt.pepper)
+			hash, err := s.Hash("gamepad")
+			assert.Nil(t, err)
+			assert.True(t, s.HashMatchesPassword(hash, "gamepad"))
+			assert.False(t, s.HashMatchesPassword(hash, "gamepat"))
+		})
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash := func(h secure.Hasher, pepper string) string {
+		hash, err := secure.NewWithHasher(1, "", h, pepper).Hash("gamepad")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	argon := secure.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+	cases := []struct {
+		name       string
+		hash       string
+		pass       string
+		pepper     string
+		wantMatch  bool
+		wantRehash bool
 	}{
 		{
-			name: "Success",
+			name: "Wrong password",
+			hash: hash(argon, ""),
+			pass: "gamepat",
+		},
+		{
+			name: "Unknown algorithm",
+			hash: "$md5$gamepad",
+			pass: "gamepad",
+		},
+		{
+			name: "Malformed hash",
+			hash: "$argon2id$v=19$m=64,t=1$salt$key",
+			pass: "gamepad",
+		},
+		{
+			name:      "Current",
+			hash:      hash(argon, ""),
+			pass:      "gamepad",
+			wantMatch: true,
+		},
+		{
+			name:       "Outdated parameters",
+			hash:       hash(secure.Argon2id{Memory: 32, Iterations: 1, Parallelism: 1}, ""),
+			pass:       "gamepad",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "Outdated algorithm",
+			hash:       hash(secure.Bcrypt{Cost: 4}, ""),
+			pass:       "gamepad",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "Without pepper",
+			hash:       hash(argon, ""),
+			pass:       "gamepad",
+			pepper:     "pepper",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:      "With pepper",
+			hash:      hash(argon, "pepper"),
+			pass:      "gamepad",
+			pepper:    "pepper",
+			wantMatch: true,
+		},
+		{
+			name:   "Other pepper",
+			hash:   hash(argon, "salt"),
+			pass:   "gamepad",
+			pepper: "pepper",
+		},
+		{
+			name: "Pepper removed",
+			hash: hash(argon, "pepper"),
 			pass: "gamepad",
-			want: true,
 		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			s := secure.New(1, nil)
-			hash := s.Hash(tt.pass)
-			assert.Equal(t, tt.want, s.HashMatchesPassword(hash, tt.pass))
+			s := secure.NewWithHasher(1, "", argon, tt.pepper)
+			match, rehash := s.VerifyPassword(tt.hash, tt.pass)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantRehash, rehash)
 		})
 	}
 }
 
-func TestToken(t *testing.T) {
-	s := secure.New(1, sha1.New())
-	token := "token"
-	tokenized := s.Token(token)
-	assert.NotEqual(t, tokenized, token)
+// countingHasher counts verifications of hasher
+type countingHasher struct {
+	secure.Hasher
+	verified int
+}
+
+func (c *countingHasher) Verify(hash string, password []byte) (bool, bool, error) {
+	c.verified++
+	return c.Hasher.Verify(hash, password)
+}
+
+func TestVerifyPasswordOnce(t *testing.T) {
+	h := &countingHasher{Hasher: secure.Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}}
+	s := secure.NewWithHasher(1, "", h, "pepper")
+	hash, err := s.Hash("gamepad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(hash, "$peppered$argon2id$"))
+
+	match, _ := s.VerifyPassword(hash, "gamepat")
+	assert.False(t, match)
+	assert.Equal(t, 1, h.verified, "mismatched password should be hashed once")
 }
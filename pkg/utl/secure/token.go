@@ -0,0 +1,41 @@
+package secure
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Prefixes of tokens issued to clients, which make leaked tokens recognisable by secret scanners
+const (
+	RefreshTokenPrefix   = "rt_"
+	ResetTokenPrefix     = "pr_"
+	ChallengeTokenPrefix = "lc_"
+	EmailTokenPrefix     = "ev_"
+)
+
+// tokenBytes is the number of random bytes of tokens
+const tokenBytes = 32
+
+// RandomToken generates new cryptographically secure random token, hex encoded after the prefix, safe for concurrent use
+func (*Service) RandomToken(prefix string) (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// TokenHash returns HMAC-SHA256 of the token keyed by the token key, used for storing tokens at rest,
+// so tokens can't be used or forged by someone who only gained access to the database.
+// Stored tokens are looked up by the hash rather than compared with it.
+// It panics if the service has no token key, as tokens must not be stored unkeyed.
+func (s *Service) TokenHash(token string) string {
+	if s.tokenKey == nil {
+		panic("secure: token key is required for hashing tokens")
+	}
+	mac := hmac.New(sha256.New, s.tokenKey)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
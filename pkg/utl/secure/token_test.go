@@ -0,0 +1,32 @@
+package secure_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/figassis/goduck/pkg/utl/secure"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomToken(t *testing.T) {
+	s := secure.New(1, "")
+	first, err := s.RandomToken(secure.RefreshTokenPrefix)
+	assert.Nil(t, err)
+	second, err := s.RandomToken(secure.RefreshTokenPrefix)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(first, "rt_"))
+	assert.Len(t, first, 67)
+	assert.NotEqual(t, first, second)
+
+	raw, err := s.RandomToken("")
+	assert.Nil(t, err)
+	assert.Len(t, raw, 64)
+}
+
+func TestTokenHash(t *testing.T) {
+	s := secure.New(1, "key")
+	assert.Equal(t, "646b8eac0c4ae4178299c9bd924e2bf073dfb07e024901a8c864f65b9462bf0d", s.TokenHash("token"))
+	assert.NotEqual(t, s.TokenHash("token"), s.TokenHash("tokem"))
+	assert.NotEqual(t, s.TokenHash("token"), secure.New(1, "other").TokenHash("token"))
+	assert.Panics(t, func() { secure.New(1, "").TokenHash("token") }, "tokens must not be hashed without key")
+}
@@ -26,6 +26,15 @@ func getVldErrorMsg(s string) string {
 	return " failed on " + s + " validation"
 }
 
+// ValidationMessages describes failed validations, e.g. "Email failed on email validation"
+func ValidationMessages(errs validator.ValidationErrors) []string {
+	var msgs []string
+	for _, v := range errs {
+		msgs = append(msgs, fmt.Sprintf("%s%s", v.Field(), getVldErrorMsg(v.ActualTag())))
+	}
+	return msgs
+}
+
 func (ce *customErrHandler) handler(err error, c echo.Context) {
 	var (
 		code = http.StatusInternalServerError
@@ -53,11 +62,7 @@ func (ce *customErrHandler) handler(err error, c echo.Context) {
 				msg = fmt.Sprintf("%v, %v", err, e.Internal)
 			}
 		case validator.ValidationErrors:
-			var errMsg []string
-			for _, v := range e {
-				errMsg = append(errMsg, fmt.Sprintf("%s%s", v.Field(), getVldErrorMsg(v.ActualTag())))
-			}
-			msg = resp{Message: errMsg}
+			msg = resp{Message: ValidationMessages(e)}
 			code = http.StatusBadRequest
 		default:
 			msg = http.StatusText(code)
@@ -2,23 +2,34 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/middleware"
 	"github.com/figassis/goduck/pkg/utl/middleware/secure"
+	"github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/labstack/echo"
 )
 
-// New instantates new Echo server
+// New instantates new Echo server, allowing cross-origin requests from all origins
 func New() *echo.Echo {
+	return NewWithCORS(secure.CORS(), nil)
+}
+
+// NewWithCORS instantates new Echo server handling cross-origin requests with cors middleware.
+// Forwarded client addresses are honoured only on requests coming from proxies.
+func NewWithCORS(cors echo.MiddlewareFunc, proxies []*net.IPNet) *echo.Echo {
 	e := echo.New()
-	e.Use(middleware.Logger(), middleware.Recover(),
-		secure.CORS(), secure.Headers())
+	e.Use(middleware.Logger(), abortable(middleware.Recover()),
+		cors, secure.Headers(), clientContext(proxies))
 	e.GET("/", healthCheck)
 	e.Validator = &CustomValidator{V: validator.New()}
 	custErr := &customErrHandler{e: e}
@@ -27,6 +38,112 @@ func New() *echo.Echo {
 	return e
 }
 
+// errAborted is returned by handlers which panicked with http.ErrAbortHandler
+var errAborted = errors.New("handler aborted")
+
+// abortable lets http.ErrAbortHandler panics through the rec middleware, so the response
+// is aborted by the HTTP server instead of reporting an error after it has started
+func abortable(rec echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := rec(func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r == http.ErrAbortHandler {
+					err = errAborted
+				} else if r != nil {
+					panic(r)
+				}
+			}()
+			return next(c)
+		})
+		return func(c echo.Context) error {
+			err := h(c)
+			if err == errAborted {
+				panic(http.ErrAbortHandler)
+			}
+			return err
+		}
+	}
+}
+
+// ParseProxies parses trusted proxy addresses, given as IPs or CIDR ranges
+func ParseProxies(addrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(addrs))
+	for _, a := range addrs {
+		if !strings.Contains(a, "/") {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", a)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q", a)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// clientContext makes client details available to services through request context
+func clientContext(proxies []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(gorsk.NewClientContext(req.Context(), gorsk.Client{
+				IP:        clientIP(req, proxies),
+				UserAgent: req.UserAgent(),
+			})))
+			return next(c)
+		}
+	}
+}
+
+// clientIP returns the address of the connecting peer, unless it is a trusted proxy.
+// Then X-Forwarded-For is walked from the right, skipping the proxies, since only
+// the entries appended by them can be trusted.
+func clientIP(req *http.Request, proxies []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	if !trusted(ip, proxies) {
+		return ip
+	}
+	if fwd := req.Header.Get(echo.HeaderXForwardedFor); fwd != "" {
+		hops := strings.Split(fwd, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip = strings.TrimSpace(hops[i])
+			if !trusted(ip, proxies) {
+				return ip
+			}
+		}
+		return ip
+	}
+	if real := req.Header.Get(echo.HeaderXRealIP); real != "" {
+		return real
+	}
+	return ip
+}
+
+func trusted(addr string, proxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func healthCheck(c echo.Context) error {
 	return c.JSON(http.StatusOK, "OK")
 }
@@ -57,7 +174,7 @@ func Start(e *echo.Echo, cfg *Config) {
 
 	// Wait for interrupt signal to gracefully shutdown the server with
 	// a timeout of 10 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -1,9 +1,18 @@
 package server_test
 
 import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/figassis/goduck/pkg/utl/middleware/secure"
+	"github.com/figassis/goduck/pkg/utl/model"
 	"github.com/figassis/goduck/pkg/utl/server"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
 )
 
 // Improve tests
@@ -13,3 +22,103 @@ func TestNew(t *testing.T) {
 		t.Errorf("Server should not be nil")
 	}
 }
+
+func TestParseProxies(t *testing.T) {
+	nets, err := server.ParseProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::1/128"},
+		[]string{nets[0].String(), nets[1].String(), nets[2].String()})
+
+	_, err = server.ParseProxies([]string{"proxy.local"})
+	assert.NotNil(t, err)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := server.ParseProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		proxies []*net.IPNet
+		wantIP  string
+	}{
+		{
+			name:    "Forwarded headers ignored without trusted proxies",
+			remote:  "198.51.100.7:4321",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9", "X-Real-IP": "203.0.113.9"},
+			wantIP:  "198.51.100.7",
+		},
+		{
+			name:    "Forwarded headers ignored from untrusted peer",
+			remote:  "198.51.100.7:4321",
+			headers: map[string]string{"X-Forwarded-For": "203.0.113.9"},
+			proxies: proxies,
+			wantIP:  "198.51.100.7",
+		},
+		{
+			name:    "Rightmost untrusted hop from trusted proxy",
+			remote:  "10.0.0.2:4321",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.9, 10.0.0.3"},
+			proxies: proxies,
+			wantIP:  "203.0.113.9",
+		},
+		{
+			name:    "X-Real-IP from trusted proxy",
+			remote:  "10.0.0.2:4321",
+			headers: map[string]string{"X-Real-IP": "203.0.113.9"},
+			proxies: proxies,
+			wantIP:  "203.0.113.9",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e := server.NewWithCORS(secure.CORS(), tt.proxies)
+			var ip string
+			e.GET("/ip", func(c echo.Context) error {
+				ip = gorsk.ClientFromContext(c.Request().Context()).IP
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			e.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.wantIP, ip)
+		})
+	}
+}
+
+func TestPanics(t *testing.T) {
+	e := server.New()
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+	e.GET("/abort", func(c echo.Context) error {
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("partial"))
+		c.Response().Flush()
+		panic(http.ErrAbortHandler)
+	})
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	res, err = http.Get(ts.URL + "/abort")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	assert.NotNil(t, err)
+	assert.Equal(t, "partial", string(body))
+}
@@ -0,0 +1,113 @@
+// Package throttle provides failed login attempt stores
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/figassis/goduck/pkg/utl/model"
+)
+
+// NewMemory creates new in-memory attempt store
+func NewMemory() *Memory {
+	return &Memory{attempts: make(map[string]gorsk.LoginAttempt)}
+}
+
+// Memory is an in-memory attempt store, intended for tests and single instance deployments
+type Memory struct {
+	mu       sync.Mutex
+	attempts map[string]gorsk.LoginAttempt
+}
+
+// Get returns attempts recorded for the key
+func (m *Memory) Get(ctx context.Context, key string) (*gorsk.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.attempts[key]
+	if !ok {
+		a = gorsk.LoginAttempt{Key: key}
+	}
+	return &a, nil
+}
+
+// Fail records a failed attempt for the key.
+// Failures older than window are forgotten, so the count starts over.
+func (m *Memory) Fail(ctx context.Context, key string, at time.Time, window time.Duration) (*gorsk.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.attempts[key]
+	a.Key = key
+	if a.LastFailure.Before(at.Add(-window)) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailure = at
+	m.attempts[key] = a
+	return &a, nil
+}
+
+// Lock refuses login attempts for the key until given time
+func (m *Memory) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.attempts[key]
+	a.Key = key
+	a.LockedUntil = until
+	m.attempts[key] = a
+	return nil
+}
+
+// Reset forgets attempts recorded for the key, lifting any lock
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
+
+// NewPG creates new PostgreSQL backed attempt store
+func NewPG(db orm.DB) *PG {
+	return &PG{db: db}
+}
+
+// PG is a PostgreSQL backed attempt store, using login_attempts table
+type PG struct {
+	db orm.DB
+}
+
+// Get returns attempts recorded for the key
+func (p *PG) Get(ctx context.Context, key string) (*gorsk.LoginAttempt, error) {
+	a := &gorsk.LoginAttempt{Key: key}
+	err := p.db.ModelContext(ctx, a).WherePK().Select()
+	if err == pg.ErrNoRows {
+		return a, nil
+	}
+	return a, err
+}
+
+// Fail records a failed attempt for the key.
+// Failures older than window are forgotten, so the count starts over.
+func (p *PG) Fail(ctx context.Context, key string, at time.Time, window time.Duration) (*gorsk.LoginAttempt, error) {
+	a := new(gorsk.LoginAttempt)
+	_, err := p.db.QueryOneContext(ctx, a, `INSERT INTO login_attempts (key, failures, last_failure) VALUES (?, 1, ?)
+	ON CONFLICT (key) DO UPDATE SET last_failure = EXCLUDED.last_failure,
+	failures = CASE WHEN login_attempts.last_failure < ? THEN 1 ELSE login_attempts.failures + 1 END
+	RETURNING *`, key, at, at.Add(-window))
+	return a, err
+}
+
+// Lock refuses login attempts for the key until given time
+func (p *PG) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := p.db.ModelContext(ctx, &gorsk.LoginAttempt{Key: key, LockedUntil: until}).
+		OnConflict("(key) DO UPDATE").Set("locked_until = EXCLUDED.locked_until").Insert()
+	return err
+}
+
+// Reset forgets attempts recorded for the key, lifting any lock
+func (p *PG) Reset(ctx context.Context, key string) error {
+	_, err := p.db.ModelContext(ctx, (*gorsk.LoginAttempt)(nil)).Where("key = ?", key).Delete()
+	return err
+}
@@ -0,0 +1,73 @@
+package throttle_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/mock"
+	"github.com/figassis/goduck/pkg/utl/model"
+	"github.com/figassis/goduck/pkg/utl/throttle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type store interface {
+	Get(context.Context, string) (*gorsk.LoginAttempt, error)
+	Fail(context.Context, string, time.Time, time.Duration) (*gorsk.LoginAttempt, error)
+	Lock(context.Context, string, time.Time) error
+	Reset(context.Context, string) error
+}
+
+func testStore(t *testing.T, s store) {
+	ctx := context.Background()
+	now := mock.TestTime(2018).Truncate(time.Second)
+	window := 15 * time.Minute
+
+	a, err := s.Get(ctx, "user:johndoe")
+	assert.Nil(t, err)
+	assert.Equal(t, &gorsk.LoginAttempt{Key: "user:johndoe"}, a)
+
+	for i := 1; i <= 3; i++ {
+		a, err = s.Fail(ctx, "user:johndoe", now.Add(time.Duration(i)*time.Minute), window)
+		assert.Nil(t, err)
+		assert.Equal(t, i, a.Failures)
+	}
+	_, err = s.Fail(ctx, "ip:10.0.0.1", now, window)
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Lock(ctx, "user:johndoe", now.Add(time.Hour)))
+	a, err = s.Get(ctx, "user:johndoe")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, a.Failures)
+	assert.True(t, a.LastFailure.Equal(now.Add(3*time.Minute)))
+	assert.True(t, a.Locked(now.Add(30*time.Minute)))
+	assert.False(t, a.Locked(now.Add(2*time.Hour)))
+
+	a, err = s.Fail(ctx, "user:johndoe", now.Add(time.Hour), window)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, a.Failures, "failures outside of window are forgotten")
+
+	assert.Nil(t, s.Reset(ctx, "user:johndoe"))
+	a, err = s.Get(ctx, "user:johndoe")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, a.Failures)
+	assert.False(t, a.Locked(now))
+
+	a, err = s.Get(ctx, "ip:10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, a.Failures)
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, throttle.NewMemory())
+}
+
+func TestPG(t *testing.T) {
+	dbCon := mock.NewPGContainer(t)
+	defer dbCon.Shutdown()
+
+	db := mock.NewDB(t, dbCon, &gorsk.LoginAttempt{})
+
+	testStore(t, throttle.NewPG(db))
+}
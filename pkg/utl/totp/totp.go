@@ -0,0 +1,96 @@
+// Package totp implements time-based one-time passwords as described in RFC 6238,
+// using HMAC-SHA1, 30 second steps and 6 digit codes, as expected by authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the length of generated codes
+	Digits = 6
+
+	// Period is the duration of a single time step
+	Period = 30 * time.Second
+
+	// Skew is the number of time steps before and after the current one accepted when validating
+	Skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates new random base32 encoded secret
+func NewSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Counter returns the time step counter for the given time
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code generates the code for the given secret and time
+func Code(secret string, t time.Time) (string, error) {
+	return code(secret, Counter(t))
+}
+
+// Validate checks the code against the given secret and time, allowing for clock skew.
+// On success it returns the matched time step counter, which callers should store
+// and use to reject replays of the same code.
+func Validate(secret, passcode string, t time.Time) (int64, bool) {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != Digits {
+		return 0, false
+	}
+	counter := Counter(t)
+	for i := int64(-Skew); i <= Skew; i++ {
+		c, err := code(secret, counter+i)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(c), []byte(passcode)) == 1 {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the provisioning URI, usually rendered as a QR code for authenticator apps
+func URI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period/time.Second)))
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+func code(secret string, counter int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	h := hmac.New(sha1.New, key)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000), nil
+}
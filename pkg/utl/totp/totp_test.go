@@ -0,0 +1,103 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/figassis/goduck/pkg/utl/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// Base32 encoding of the RFC 6238 SHA1 test secret "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// Test vectors from RFC 6238 Appendix B, truncated to 6 digits
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range cases {
+		code, err := totp.Code(rfcSecret, time.Unix(tt.unix, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, tt.want, code)
+	}
+
+	_, err := totp.Code("not base32!", time.Unix(59, 0))
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	cases := []struct {
+		name   string
+		code   string
+		at     time.Time
+		wantOK bool
+	}{
+		{
+			name:   "Current step",
+			code:   "005924",
+			at:     now,
+			wantOK: true,
+		},
+		{
+			name:   "Previous step within skew",
+			code:   "005924",
+			at:     now.Add(totp.Period),
+			wantOK: true,
+		},
+		{
+			name: "Outside of skew",
+			code: "005924",
+			at:   now.Add(3 * totp.Period),
+		},
+		{
+			name: "Wrong code",
+			code: "123456",
+			at:   now,
+		},
+		{
+			name: "Wrong length",
+			code: "5924",
+			at:   now,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := totp.Validate(rfcSecret, tt.code, tt.at)
+			assert.Equal(t, tt.wantOK, ok)
+			if ok {
+				assert.Equal(t, totp.Counter(now), counter)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := totp.NewSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	now := time.Now()
+	code, err := totp.Code(secret, now)
+	assert.Nil(t, err)
+	_, ok := totp.Validate(secret, code, now)
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("GoDuck", "johndoe", rfcSecret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/GoDuck:johndoe?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=GoDuck")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
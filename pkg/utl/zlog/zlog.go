@@ -1,28 +1,48 @@
 package zlog
 
 import (
+	"context"
 	"os"
+	"sync/atomic"
 
-	"github.com/labstack/echo"
+	"github.com/figassis/goduck/pkg/utl/model"
 
 	"github.com/rs/zerolog"
 )
 
 // Log represents zerolog logger
 type Log struct {
-	logger *zerolog.Logger
+	logger atomic.Value
 }
 
 // New instantiates new zero logger
 func New() *Log {
 	z := zerolog.New(os.Stdout)
-	return &Log{
-		logger: &z,
+	l := new(Log)
+	l.logger.Store(&z)
+	return l
+}
+
+// SetLevel changes minimal level of logged messages, e.g. info or error. Empty level logs everything.
+func (z *Log) SetLevel(level string) error {
+	if level == "" {
+		level = zerolog.DebugLevel.String()
+	}
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
 	}
+	logger := z.load().Level(lvl)
+	z.logger.Store(&logger)
+	return nil
+}
+
+func (z *Log) load() *zerolog.Logger {
+	return z.logger.Load().(*zerolog.Logger)
 }
 
 // Log logs using zerolog
-func (z *Log) Log(ctx echo.Context, source, msg string, err error, params map[string]interface{}) {
+func (z *Log) Log(ctx context.Context, source, msg string, err error, params map[string]interface{}) {
 
 	if params == nil {
 		params = make(map[string]interface{})
@@ -30,16 +50,16 @@ func (z *Log) Log(ctx echo.Context, source, msg string, err error, params map[st
 
 	params["source"] = source
 
-	if id, ok := ctx.Get("id").(int); ok {
-		params["id"] = id
-		params["user"] = ctx.Get("username").(string)
+	if u := gorsk.UserFromContext(ctx); u != nil {
+		params["id"] = u.ID
+		params["user"] = u.Username
 	}
 
 	if err != nil {
 		params["error"] = err
-		z.logger.Error().Fields(params).Msg(msg)
+		z.load().Error().Fields(params).Msg(msg)
 		return
 	}
 
-	z.logger.Info().Fields(params).Msg(msg)
+	z.load().Info().Fields(params).Msg(msg)
 }